@@ -16,6 +16,8 @@ import (
 	"github.com/wileytor/go-market/auth/internal/repository"
 	"github.com/wileytor/go-market/auth/internal/server"
 	"github.com/wileytor/go-market/auth/internal/server/routes"
+	"github.com/wileytor/go-market/common/broker"
+	"github.com/wileytor/go-market/common/jwt"
 	"github.com/wileytor/go-market/common/rabbitmq"
 	"golang.org/x/sync/errgroup"
 )
@@ -33,13 +35,12 @@ func main() {
 	cfg := config.ReadConfig()
 	zlog := logger.SetupLogger(cfg.DebugFlag)
 
-	rabbitURL := os.Getenv("RABBITMQ_URL")
-	rabbit, err := rabbitmq.InitRabbit(rabbitURL)
+	messageBroker, err := initBroker(cfg.Broker)
 	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %s", err)
+		log.Fatalf("Failed to init message broker: %s", err)
 	}
-	defer rabbit.CloseRabbit()
-	log.Println("RabbitMQ initialized, starting listener for user_check_queue")
+	defer messageBroker.Close()
+	log.Println("Message broker initialized, starting listener for user_check_queue")
 
 	err = repository.EnsureAuthDatabaseExists(cfg.DBAddr)
 	if err != nil {
@@ -64,14 +65,29 @@ func main() {
 	}
 	defer dbStorage.Close()
 
+	if cfg.AdminUser != "" {
+		if err := dbStorage.PromoteToAdmin(cfg.AdminUser); err != nil {
+			zlog.Warn().Err(err).Str("username", cfg.AdminUser).Msg("Failed to grant admin role")
+		}
+	}
+
+	keys, err := jwt.LoadKeySet(cfg.KeysPath, cfg.SigningKID)
+	if err != nil {
+		zlog.Fatal().Err(err).Msg("Failed to load JWT signing keys")
+	}
+	zlog.Info().Str("kid", keys.SigningKID()).Msg("JWT signing keys loaded")
+
 	var wg sync.WaitGroup
 	group, gCtx := errgroup.WithContext(ctx)
-	srv := server.NewServer(gCtx, dbStorage, zlog, rabbit)
+	srv := server.NewServer(gCtx, dbStorage, zlog, messageBroker, keys)
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		server.StartListener(srv)
+		opts := broker.DefaultConsumerOptions
+		opts.Workers = cfg.Workers
+		opts.Prefetch = cfg.Prefetch
+		server.StartListener(srv, opts)
 	}()
 
 	group.Go(func() error {
@@ -106,6 +122,22 @@ func main() {
 	}
 }
 
+// initBroker подключается к RabbitMQ или создает брокер в памяти для локального запуска
+func initBroker(kind string) (broker.Broker, error) {
+	if kind == "memory" {
+		return broker.NewMemory(), nil
+	}
+	rabbit, err := rabbitmq.InitRabbit(os.Getenv("RABBITMQ_URL"))
+	if err != nil {
+		return nil, err
+	}
+	if err := rabbit.DeclareQueue(rabbitmq.UserCheckQueue); err != nil {
+		rabbit.Close()
+		return nil, err
+	}
+	return rabbit, nil
+}
+
 func initDB(ctx context.Context, addr string) (*pgxpool.Pool, error) {
 	var pool *pgxpool.Pool
 	var err error
@@ -0,0 +1,60 @@
+#!/bin/sh
+
+# Каталог ключей подписи JWT (смонтированный секрет)
+KEYS_DIR="${JWT_KEYS_PATH:-/auth/keys}"
+
+# Ключи не хранятся в репозитории. Auth подписывает токены ключом с наибольшим kid
+# и публикует в JWKS все ключи каталога, поэтому ротация проходит без отзыва токенов:
+#   sh gen-jwt-key.sh         - создает ключ, только если в каталоге нет ни одного;
+#   sh gen-jwt-key.sh rotate  - добавляет новый ключ, старые остаются в JWKS;
+#   sh gen-jwt-key.sh prune   - удаляет все ключи, кроме новейшего.
+# После rotate перезапустите auth, чтобы он начал подписывать новым ключом.
+# prune запускайте не раньше, чем истекут токены старого ключа (время жизни
+# access-токена - 15 минут), и снова перезапустите auth.
+MODE="${1:-init}"
+
+newest_key() {
+  ls "$KEYS_DIR"/*.pem 2>/dev/null | sort | tail -n 1
+}
+
+case "$MODE" in
+  init)
+    if [ -n "$(newest_key)" ]; then
+      echo "Ключи подписи уже есть в $KEYS_DIR"
+      exit 0
+    fi
+    ;;
+  rotate)
+    ;;
+  prune)
+    NEWEST=$(newest_key)
+    if [ -z "$NEWEST" ]; then
+      echo "В $KEYS_DIR нет ключей подписи. Прерывание."
+      exit 1
+    fi
+    for KEY in "$KEYS_DIR"/*.pem; do
+      if [ "$KEY" != "$NEWEST" ]; then
+        rm -f "$KEY"
+        echo "Удален ключ подписи $(basename "$KEY" .pem)"
+      fi
+    done
+    exit 0
+    ;;
+  *)
+    echo "Использование: $0 [rotate|prune]"
+    exit 1
+    ;;
+esac
+
+# kid - время создания до секунды: новые kid больше старых, в том числе созданных в тот же день
+KID=$(date -u +%Y-%m-%dT%H%M%SZ)
+if [ -e "$KEYS_DIR/$KID.pem" ]; then
+  echo "Ключ подписи $KID уже существует. Прерывание."
+  exit 1
+fi
+umask 077
+if ! openssl genpkey -algorithm ed25519 -out "$KEYS_DIR/$KID.pem"; then
+  echo "Не удалось создать ключ подписи. Прерывание."
+  exit 1
+fi
+echo "Создан ключ подписи $KID"
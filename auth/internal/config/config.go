@@ -3,6 +3,7 @@ package config
 import (
 	"flag"
 	"os"
+	"strconv"
 )
 
 type Config struct {
@@ -11,6 +12,12 @@ type Config struct {
 	MPath        string
 	DebugFlag    bool
 	RabbitMQHost string
+	Broker       string
+	KeysPath     string
+	SigningKID   string
+	AdminUser    string
+	Workers      int
+	Prefetch     int
 }
 
 const (
@@ -18,6 +25,10 @@ const (
 	defaultDbDSN        = "postgres://nastya:pgspgs@db:5432/auth?sslmode=disable"
 	defaultMigratePath  = "migrations"
 	defaultRabbitMQHost = "rabbitmq"
+	defaultBroker       = "amqp"
+	defaultKeysPath     = "keys"
+	defaultWorkers      = 4
+	defaultPrefetch     = 20
 )
 
 func ReadConfig() Config {
@@ -25,12 +36,22 @@ func ReadConfig() Config {
 	var dbAddr string
 	var migratePath string
 	var rabbitMQHost string
+	var brokerKind string
+	var keysPath string
+	var signingKID string
+	var adminUser string
 	debug := flag.Bool("debug", false, "enable debug logger level")
+	workers := flag.Int("workers", defaultWorkers, "number of concurrent token check workers")
+	prefetch := flag.Int("prefetch", defaultPrefetch, "rabbitMQ prefetch count for token checks")
 
 	flag.StringVar(&addr, "addr", defaultAddr, "Server address") // mani.exe -help
 	flag.StringVar(&dbAddr, "db", defaultDbDSN, "database connection addres")
 	flag.StringVar(&migratePath, "m", defaultMigratePath, "path to migrations")
 	flag.StringVar(&rabbitMQHost, "rabbitMQ", defaultRabbitMQHost, "rabbitMQ host to connect")
+	flag.StringVar(&brokerKind, "broker", defaultBroker, "message broker: amqp or memory")
+	flag.StringVar(&keysPath, "keys", defaultKeysPath, "path to JWT signing keys")
+	flag.StringVar(&signingKID, "kid", "", "kid of the active signing key")
+	flag.StringVar(&adminUser, "admin", "", "username to grant admin role on startup")
 	flag.Parse()
 
 	if temp := os.Getenv("SERVER_ADDR"); temp != "" {
@@ -45,6 +66,24 @@ func ReadConfig() Config {
 	if temp := os.Getenv("RABBITMQ_HOST"); temp != "" {
 		rabbitMQHost = temp
 	}
+	if temp := os.Getenv("BROKER"); temp != "" {
+		brokerKind = temp
+	}
+	if temp := os.Getenv("JWT_KEYS_PATH"); temp != "" {
+		keysPath = temp
+	}
+	if temp := os.Getenv("JWT_SIGNING_KID"); temp != "" {
+		signingKID = temp
+	}
+	if temp := os.Getenv("ADMIN_USERNAME"); temp != "" {
+		adminUser = temp
+	}
+	if temp, err := strconv.Atoi(os.Getenv("RABBITMQ_WORKERS")); err == nil {
+		*workers = temp
+	}
+	if temp, err := strconv.Atoi(os.Getenv("RABBITMQ_PREFETCH")); err == nil {
+		*prefetch = temp
+	}
 
 	return Config{
 		Addr:         addr,
@@ -52,5 +91,11 @@ func ReadConfig() Config {
 		MPath:        migratePath,
 		DebugFlag:    *debug,
 		RabbitMQHost: rabbitMQHost,
+		Broker:       brokerKind,
+		KeysPath:     keysPath,
+		SigningKID:   signingKID,
+		AdminUser:    adminUser,
+		Workers:      *workers,
+		Prefetch:     *prefetch,
 	}
 }
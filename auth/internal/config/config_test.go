@@ -26,22 +26,32 @@ func TestReadConfig(t *testing.T) {
 			flags: []string{"test", "--addr", "testaddr", "--debug"},
 			want: want{
 				cfg: Config{
-					Addr:      "testaddr",
-					DBAddr:    defaultDbDSN,
-					MPath:     defaultMigratePath,
-					DebugFlag: true,
+					Addr:         "testaddr",
+					DBAddr:       defaultDbDSN,
+					MPath:        defaultMigratePath,
+					DebugFlag:    true,
+					RabbitMQHost: defaultRabbitMQHost,
+					Broker:       defaultBroker,
+					KeysPath:     defaultKeysPath,
+					Workers:      defaultWorkers,
+					Prefetch:     defaultPrefetch,
 				},
 			},
 		},
 		{
 			name:  "TestReadConfig func; Test 2",
-			flags: []string{"test", "--addr", "testaddr", "--db", "dbaddr", "--m", "mPath"},
+			flags: []string{"test", "--addr", "testaddr", "--db", "dbaddr", "--m", "mPath", "--workers", "8"},
 			want: want{
 				cfg: Config{
-					Addr:      "testaddr",
-					DBAddr:    "dbaddr",
-					MPath:     "mPath",
-					DebugFlag: false,
+					Addr:         "testaddr",
+					DBAddr:       "dbaddr",
+					MPath:        "mPath",
+					DebugFlag:    false,
+					RabbitMQHost: defaultRabbitMQHost,
+					Broker:       defaultBroker,
+					KeysPath:     defaultKeysPath,
+					Workers:      8,
+					Prefetch:     defaultPrefetch,
 				},
 			},
 		},
@@ -54,10 +64,15 @@ func TestReadConfig(t *testing.T) {
 			},
 			want: want{
 				cfg: Config{
-					Addr:      "envSrvAddr",
-					DBAddr:    "envDbAddr",
-					MPath:     defaultMigratePath,
-					DebugFlag: true,
+					Addr:         "envSrvAddr",
+					DBAddr:       "envDbAddr",
+					MPath:        defaultMigratePath,
+					DebugFlag:    true,
+					RabbitMQHost: defaultRabbitMQHost,
+					Broker:       defaultBroker,
+					KeysPath:     defaultKeysPath,
+					Workers:      defaultWorkers,
+					Prefetch:     defaultPrefetch,
 				},
 			},
 		},
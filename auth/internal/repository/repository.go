@@ -1,6 +1,21 @@
 package repository
 
-import "github.com/wileytor/go-market/common/models"
+import (
+	"errors"
+	"time"
+
+	"github.com/wileytor/go-market/common/models"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found or expired")
+	ErrUserNotFound    = errors.New("user not found")
+)
+
+type Repository interface {
+	UserRepository
+	SessionRepository
+}
 
 type UserRepository interface {
 	GetUserProfile(int) (models.User, error)
@@ -8,4 +23,14 @@ type UserRepository interface {
 	LoginUser(string, string) (int, error)
 	IsUsernameUnique(string) (bool, error)
 	UserExists(int) (bool, error)
+	GetUserRole(int) (string, error)
+	SetUserRole(int, string) error
+	PromoteToAdmin(string) error
+}
+
+type SessionRepository interface {
+	CreateSession(int, string, time.Time) (int, error)
+	RotateSession(string, string, time.Time) (int, int, error)
+	RevokeSession(string) error
+	IsSessionActive(int) (bool, error)
 }
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateSession сохраняет хэш refresh-токена новой сессии пользователя
+func (db *DBstorage) CreateSession(userID int, refreshHash string, expiresAt time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewInsertBuilder()
+	query, args := sb.InsertInto("sessions").Cols("user_id", "refresh_hash", "expires_at").
+		Values(userID, refreshHash, expiresAt).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+	query += " RETURNING id"
+
+	var ID int
+	if err := db.Pool.QueryRow(ctx, query, args...).Scan(&ID); err != nil {
+		return -1, fmt.Errorf("failed to create session: %w", err)
+	}
+	return ID, nil
+}
+
+// RotateSession заменяет refresh-токен активной сессии на новый.
+// Возвращает id сессии и id пользователя.
+func (db *DBstorage) RotateSession(oldHash, newHash string, expiresAt time.Time) (int, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `UPDATE sessions SET refresh_hash = $1, expires_at = $2
+		WHERE refresh_hash = $3 AND revoked = false AND expires_at > NOW()
+		RETURNING id, user_id`
+
+	var sessionID, userID int
+	err := db.Pool.QueryRow(ctx, query, newHash, expiresAt, oldHash).Scan(&sessionID, &userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return -1, -1, ErrSessionNotFound
+		}
+		return -1, -1, fmt.Errorf("failed to rotate session: %w", err)
+	}
+	return sessionID, userID, nil
+}
+
+// RevokeSession отзывает сессию по хэшу refresh-токена
+func (db *DBstorage) RevokeSession(refreshHash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewUpdateBuilder()
+	query, args := sb.Update("sessions").
+		Set(sb.Assign("revoked", true)).
+		Where(sb.Equal("refresh_hash", refreshHash), sb.Equal("revoked", false)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	result, err := db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// IsSessionActive проверяет, что сессия не отозвана и не истекла
+func (db *DBstorage) IsSessionActive(id int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var active bool
+	query := "SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked = false AND expires_at > NOW())"
+	if err := db.Pool.QueryRow(ctx, query, id).Scan(&active); err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return active, nil
+}
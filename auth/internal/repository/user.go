@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
 	"github.com/wileytor/go-market/common/models"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -21,7 +23,7 @@ func (db *DBstorage) GetUserProfile(id int) (models.User, error) {
 	row := db.Pool.QueryRow(ctx, query, args...)
 	var user models.User
 	//Нужно ли пароль выводить?
-	if err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Email); err != nil {
+	if err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.Role); err != nil {
 		return models.User{}, err
 	}
 	return user, nil
@@ -32,8 +34,8 @@ func (db *DBstorage) RegisterUser(user models.User) (int, error) {
 	defer cancel()
 
 	sb := sqlbuilder.NewInsertBuilder()
-	query, args := sb.InsertInto("users").Cols("username", "email", "password").
-		Values(user.Username, user.Email, user.Password).
+	query, args := sb.InsertInto("users").Cols("username", "email", "password", "role").
+		Values(user.Username, user.Email, user.Password, models.RoleBuyer).
 		BuildWithFlavor(sqlbuilder.PostgreSQL)
 	query += " RETURNING id"
 	var ID int
@@ -92,3 +94,61 @@ func (db *DBstorage) UserExists(id int) (bool, error) {
 	}
 	return exists, nil
 }
+
+func (db *DBstorage) GetUserRole(id int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	query, args := sb.Select("role").From("users").Where(sb.Equal("id", id)).BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	var role string
+	if err := db.Pool.QueryRow(ctx, query, args...).Scan(&role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to get user role: %w", err)
+	}
+	return role, nil
+}
+
+func (db *DBstorage) SetUserRole(id int, role string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewUpdateBuilder()
+	query, args := sb.Update("users").
+		Set(sb.Assign("role", role)).
+		Where(sb.Equal("id", id)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	result, err := db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// PromoteToAdmin назначает роль администратора пользователю с указанным логином
+func (db *DBstorage) PromoteToAdmin(username string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewUpdateBuilder()
+	query, args := sb.Update("users").
+		Set(sb.Assign("role", models.RoleAdmin)).
+		Where(sb.Equal("username", username)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	result, err := db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to promote user: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
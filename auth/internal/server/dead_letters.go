@@ -0,0 +1,107 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/auth/internal/server/responses"
+	"github.com/wileytor/go-market/common/broker"
+)
+
+const (
+	defaultDeadLettersLimit = 20
+	maxDeadLettersLimit     = 500
+)
+
+// GetDeadLettersHandler показывает сообщения из очереди недоставленных
+// @Summary Просмотр недоставленных сообщений
+// @Description Возвращает сообщения, которые не удалось обработать после всех повторов. Сообщения остаются в очереди. Доступно только администратору
+// @Tags Очереди
+// @Produce json
+// @Param queue path string true "Имя исходной очереди"
+// @Param limit query int false "Максимальное количество сообщений"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Failure 501 {object} responses.Error
+// @Router /admin/dead-letters/{queue} [get]
+func (s *Server) GetDeadLettersHandler(ctx *gin.Context) {
+	limit, err := deadLettersLimit(ctx)
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+	store, ok := s.Broker.(broker.DeadLetterStore)
+	if !ok {
+		responses.SendError(ctx, http.StatusNotImplemented, "Dead letters are not supported by the broker", nil)
+		return
+	}
+	letters, err := store.PeekDeadLetters(ctx.Param("queue"), limit)
+	if err != nil {
+		if errors.Is(err, broker.ErrQueueNotFound) {
+			responses.SendError(ctx, http.StatusNotFound, "Queue not found", err)
+			return
+		}
+		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Dead letters", letters)
+}
+
+// ReplayDeadLettersHandler возвращает недоставленные сообщения в исходную очередь
+// @Summary Повтор недоставленных сообщений
+// @Description Переносит сообщения из очереди недоставленных обратно в исходную очередь со сброшенным счетчиком повторов. Доступно только администратору
+// @Tags Очереди
+// @Produce json
+// @Param queue path string true "Имя исходной очереди"
+// @Param limit query int false "Максимальное количество сообщений"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Failure 501 {object} responses.Error
+// @Router /admin/dead-letters/{queue}/replay [post]
+func (s *Server) ReplayDeadLettersHandler(ctx *gin.Context) {
+	limit, err := deadLettersLimit(ctx)
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid limit", err)
+		return
+	}
+	store, ok := s.Broker.(broker.DeadLetterStore)
+	if !ok {
+		responses.SendError(ctx, http.StatusNotImplemented, "Dead letters are not supported by the broker", nil)
+		return
+	}
+	replayed, err := store.ReplayDeadLetters(ctx.Request.Context(), ctx.Param("queue"), limit)
+	if err != nil {
+		if errors.Is(err, broker.ErrQueueNotFound) {
+			responses.SendError(ctx, http.StatusNotFound, "Queue not found", err)
+			return
+		}
+		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Dead letters replayed", gin.H{"replayed": replayed})
+}
+
+func deadLettersLimit(ctx *gin.Context) (int, error) {
+	raw := ctx.Query("limit")
+	if raw == "" {
+		return defaultDeadLettersLimit, nil
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 || limit > maxDeadLettersLimit {
+		return 0, errors.New("limit must be between 1 and 500")
+	}
+	return limit, nil
+}
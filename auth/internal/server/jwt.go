@@ -1,39 +1,62 @@
 package server
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"net/http"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	j "github.com/wileytor/go-market/common/jwt"
 )
 
-func CreateJWTToken(id int) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, j.Claims{
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+func (s *Server) CreateJWTToken(id, sessionID int, role string) (string, error) {
+	return s.Keys.Sign(j.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 3)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)),
 		},
-		UserID: id,
+		UserID:    id,
+		SessionID: sessionID,
+		Role:      role,
 	})
-	key := []byte(j.SecretKey)
-	tokenStr, err := token.SignedString(key)
-	if err != nil {
-		return "", err
-	}
-	return tokenStr, nil
 }
 
-func CheckToken(tokenStr string) (int, error) {
-	claims := &j.Claims{}
+func (s *Server) CheckToken(tokenStr string) (*j.Claims, error) {
+	return s.Keys.Verify(tokenStr)
+}
 
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
-		return []byte(j.SecretKey), nil
-	})
-	if err != nil {
-		return -1, err
-	}
-	if !token.Valid {
-		return -1, fmt.Errorf("invalid token")
+// JWKSHandler публикует открытые ключи для проверки токенов
+// @Summary Открытые ключи подписи
+// @Description Возвращает JWKS со всеми действующими ключами подписи токенов
+// @Tags Пользователи
+// @Produce json
+// @Success 200 {object} j.JWKS
+// @Router /.well-known/jwks.json [get]
+func (s *Server) JWKSHandler(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, s.Keys.JWKS())
+}
+
+// NewRefreshToken генерирует случайный refresh-токен и его хэш для хранения в БД
+func NewRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
-	return claims.UserID, nil
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
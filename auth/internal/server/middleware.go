@@ -0,0 +1,28 @@
+package server
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/auth/internal/repository"
+	"github.com/wileytor/go-market/common/auth"
+	j "github.com/wileytor/go-market/common/jwt"
+)
+
+// sessionChecker проверяет отзыв сессии напрямую в БД сервиса auth
+type sessionChecker struct {
+	db repository.SessionRepository
+}
+
+func (c sessionChecker) IsRevoked(_ context.Context, _ string, claims *j.Claims) (bool, error) {
+	active, err := c.db.IsSessionActive(claims.SessionID)
+	if err != nil {
+		return false, err
+	}
+	return !active, nil
+}
+
+// Authenticate проверяет access-токен и активность сессии
+func (s *Server) Authenticate() gin.HandlerFunc {
+	return auth.Authenticate(s.Keys, auth.WithRevocationCheck(sessionChecker{db: s.Db}))
+}
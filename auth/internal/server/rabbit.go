@@ -1,46 +1,43 @@
 package server
 
 import (
-	"encoding/json"
+	"fmt"
 	"log"
 
-	"github.com/streadway/amqp"
+	"github.com/wileytor/go-market/common/broker"
 	"github.com/wileytor/go-market/common/models"
 )
 
-func StartListener(s *Server) {
-	err := s.Rabbit.ConsumeMessage("user_check_queue", s.TokenCheck)
+func StartListener(s *Server, opts broker.ConsumerOptions) {
+	err := s.Broker.ServeRPC(models.UserCheckQueue, opts, s.TokenCheck)
 	if err != nil {
 		log.Fatalf("failed to consume message: %v", err)
 	}
 }
 
-func (s *Server) TokenCheck(msg amqp.Delivery) {
+// TokenCheck отвечает на запрос проверки токена, ответ уходит в ReplyTo запроса
+func (s *Server) TokenCheck(body []byte) ([]byte, error) {
 	var request models.TokenCheckMessage
-	if err := json.Unmarshal(msg.Body, &request); err != nil {
-		log.Printf("Failed to unmarshal message: %v", err)
-		return
-	}
-
-	userID, err := CheckToken(request.Token)
-	valid := err == nil
-
-	response := models.TokenCheckResponse{
-		Valid:  valid,
-		UserID: userID,
-	}
-	if !valid {
-		response.Error = "Invalid token"
+	env, err := models.DecodeMessage(body, models.MessageTypeTokenCheck, &request)
+	if err != nil {
+		// повтор не поможет, сообщение сразу уходит в очередь недоставленных
+		return nil, broker.Permanent(fmt.Errorf("failed to decode message: %w", err))
 	}
 
-	responseBytes, err := json.Marshal(response)
+	response := models.TokenCheckResponse{UserID: -1}
+	claims, err := s.CheckToken(request.Token)
 	if err != nil {
-		log.Printf("Failed to marshal response: %v", err)
-		return
+		response.Error = models.TokenCheckInvalidToken
+	} else if active, err := s.Db.IsSessionActive(claims.SessionID); err != nil {
+		log.Printf("Failed to check session: %v", err)
+		response.Error = models.TokenCheckFailed
+	} else if !active {
+		response.Error = models.TokenCheckSessionRevoked
+	} else {
+		response.Valid = true
+		response.UserID = claims.UserID
+		response.Role = claims.Role
 	}
 
-	// Отправляем ответ в указанную temp_queue
-	if err := s.Rabbit.PublishMessage(request.TempQueue, responseBytes); err != nil {
-		log.Printf("Failed to publish response to temp_queue: %v", err)
-	}
+	return models.EncodeMessage(models.MessageTypeTokenCheckResult, response, env.Trace)
 }
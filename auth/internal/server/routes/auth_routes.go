@@ -3,16 +3,28 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/wileytor/go-market/auth/internal/server"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
 )
 
 func SetupAuthRoutes(s *server.Server) *gin.Engine {
 	r := gin.Default()
+	r.GET("/.well-known/jwks.json", s.JWKSHandler)
 
 	userGroup := r.Group("/users")
 	{
 		userGroup.GET(":id", s.GetUserProfileHandler)
 		userGroup.POST("/register", s.RegisterUserHandler)
 		userGroup.POST("/login", s.LoginUserHandler)
+		userGroup.POST("/refresh", s.RefreshTokenHandler)
+		userGroup.POST("/logout", s.LogoutHandler)
+		userGroup.PUT("/:id/role", s.Authenticate(), auth.RequireRole(models.RoleAdmin), s.SetUserRoleHandler)
+	}
+
+	adminGroup := r.Group("/admin", s.Authenticate(), auth.RequireRole(models.RoleAdmin))
+	{
+		adminGroup.GET("/dead-letters/:queue", s.GetDeadLettersHandler)
+		adminGroup.POST("/dead-letters/:queue/replay", s.ReplayDeadLettersHandler)
 	}
 	return r
 }
@@ -2,7 +2,8 @@ package server
 
 import (
 	"context"
-	"github.com/wileytor/go-market/common/rabbitmq"
+	"github.com/wileytor/go-market/common/broker"
+	j "github.com/wileytor/go-market/common/jwt"
 
 	"github.com/go-playground/validator"
 	"github.com/rs/zerolog"
@@ -10,14 +11,15 @@ import (
 )
 
 type Server struct {
-	Db        repository.UserRepository
+	Db        repository.Repository
 	ErrorChan chan error
 	Valid     *validator.Validate
 	log       zerolog.Logger
-	Rabbit    *rabbitmq.RabbitMQ
+	Broker    broker.Broker
+	Keys      *j.KeySet
 }
 
-func NewServer(ctx context.Context, db repository.UserRepository, zlog *zerolog.Logger, rabbitClient *rabbitmq.RabbitMQ) *Server {
+func NewServer(ctx context.Context, db repository.Repository, zlog *zerolog.Logger, messageBroker broker.Broker, keys *j.KeySet) *Server {
 	validate := validator.New()
 	errChan := make(chan error)
 	srv := &Server{
@@ -25,13 +27,14 @@ func NewServer(ctx context.Context, db repository.UserRepository, zlog *zerolog.
 		ErrorChan: errChan,
 		log:       *zlog,
 		Valid:     validate,
-		Rabbit:    rabbitClient,
+		Broker:    messageBroker,
+		Keys:      keys,
 	}
 	return srv
 }
 
 func (s *Server) Close() {
-	if s.Rabbit != nil {
-		s.Rabbit.CloseRabbit()
+	if s.Broker != nil {
+		s.Broker.Close()
 	}
 }
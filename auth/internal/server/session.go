@@ -0,0 +1,123 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/auth/internal/repository"
+	"github.com/wileytor/go-market/auth/internal/server/responses"
+	"github.com/wileytor/go-market/common/models"
+)
+
+// RefreshTokenHandler выдает новую пару токенов по refresh-токену
+// @Summary Обновление токенов
+// @Description Обменивает действующий refresh-токен на новый access-токен и новый refresh-токен
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh-токен"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /users/refresh [post]
+func (s *Server) RefreshTokenHandler(ctx *gin.Context) {
+	var request models.RefreshRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid refresh request", err)
+		return
+	}
+
+	refreshToken, refreshHash, err := NewRefreshToken()
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
+		return
+	}
+	sessionID, userID, err := s.Db.RotateSession(HashRefreshToken(request.RefreshToken), refreshHash, time.Now().Add(refreshTokenTTL))
+	if err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			responses.SendError(ctx, http.StatusUnauthorized, "Invalid refresh token", err)
+			return
+		}
+		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
+		return
+	}
+	role, err := s.Db.GetUserRole(userID)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
+		return
+	}
+	accessToken, err := s.CreateJWTToken(userID, sessionID, role)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
+		return
+	}
+	ctx.Header("Authorization", accessToken)
+	responses.SendSuccess(ctx, http.StatusOK, "Token refreshed", models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+// LogoutHandler отзывает сессию пользователя
+// @Summary Выход пользователя
+// @Description Отзывает refresh-токен и все выданные по нему access-токены
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh-токен"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /users/logout [post]
+func (s *Server) LogoutHandler(ctx *gin.Context) {
+	var request models.RefreshRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid refresh request", err)
+		return
+	}
+	if err := s.Db.RevokeSession(HashRefreshToken(request.RefreshToken)); err != nil {
+		if errors.Is(err, repository.ErrSessionNotFound) {
+			responses.SendError(ctx, http.StatusUnauthorized, "Invalid refresh token", err)
+			return
+		}
+		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "User was logout successfully", nil)
+}
+
+// startSession создает сессию и выдает для нее пару токенов
+func (s *Server) startSession(userID int) (models.TokenPair, error) {
+	role, err := s.Db.GetUserRole(userID)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	refreshToken, refreshHash, err := NewRefreshToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	sessionID, err := s.Db.CreateSession(userID, refreshHash, time.Now().Add(refreshTokenTTL))
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	accessToken, err := s.CreateJWTToken(userID, sessionID, role)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
@@ -1,10 +1,12 @@
 package server
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/auth/internal/repository"
 	"github.com/wileytor/go-market/auth/internal/server/responses"
 	"github.com/wileytor/go-market/common/models"
 	"golang.org/x/crypto/bcrypt"
@@ -127,11 +129,52 @@ func (s *Server) LoginUserHandler(ctx *gin.Context) {
 		}
 		return
 	}
-	token, err := CreateJWTToken(userID)
+	tokens, err := s.startSession(userID)
 	if err != nil {
 		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
 		return
 	}
-	ctx.Header("Authorization", token)
-	responses.SendSuccess(ctx, http.StatusOK, "User was login successfully", token)
+	ctx.Header("Authorization", tokens.AccessToken)
+	responses.SendSuccess(ctx, http.StatusOK, "User was login successfully", tokens)
+}
+
+// SetUserRoleHandler назначает роль пользователю
+// @Summary Изменение роли пользователя
+// @Description Назначает пользователю роль buyer, seller или admin. Доступно только администратору
+// @Tags Пользователи
+// @Accept json
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Param role body models.RoleRequest true "Новая роль"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /users/{id}/role [put]
+func (s *Server) SetUserRoleHandler(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || userID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid user id", err)
+		return
+	}
+	var request models.RoleRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid role", err)
+		return
+	}
+	if err := s.Db.SetUserRole(userID, request.Role); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			responses.SendError(ctx, http.StatusNotFound, "User not found", err)
+			return
+		}
+		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "User role updated", request)
 }
@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'buyer'
+    CHECK (role IN ('buyer', 'seller', 'admin'));
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/jwt"
+)
+
+const (
+	CtxUserID = "userID"
+	CtxRole   = "role"
+
+	revocationCheckTimeout = 5 * time.Second
+)
+
+// RevocationChecker сообщает, отозвана ли сессия, которой выдан токен
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, tokenStr string, claims *jwt.Claims) (bool, error)
+}
+
+// errorResponse повторяет формат ответов об ошибке сервисов
+type errorResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+}
+
+func abort(ctx *gin.Context, status int, message string, err error) {
+	resp := errorResponse{Status: status, Message: message}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	ctx.AbortWithStatusJSON(status, resp)
+}
+
+type Option func(*authenticator)
+
+// WithRevocationCheck включает проверку отзыва сессии после проверки подписи
+func WithRevocationCheck(checker RevocationChecker) Option {
+	return func(a *authenticator) {
+		a.revocation = checker
+	}
+}
+
+type authenticator struct {
+	verifier   jwt.Verifier
+	revocation RevocationChecker
+}
+
+// Authenticate проверяет подпись и срок действия access-токена в процессе,
+// кладет id и роль пользователя в контекст запроса
+func Authenticate(verifier jwt.Verifier, opts ...Option) gin.HandlerFunc {
+	a := &authenticator{verifier: verifier}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a.handle
+}
+
+func (a *authenticator) handle(ctx *gin.Context) {
+	tokenStr := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if tokenStr == "" {
+		abort(ctx, http.StatusUnauthorized, "The header is missing", nil)
+		return
+	}
+	claims, err := a.verifier.Verify(tokenStr)
+	if err != nil {
+		abort(ctx, http.StatusUnauthorized, "Invalid token", err)
+		return
+	}
+	if a.revocation != nil {
+		checkCtx, cancel := context.WithTimeout(ctx.Request.Context(), revocationCheckTimeout)
+		revoked, err := a.revocation.IsRevoked(checkCtx, tokenStr, claims)
+		cancel()
+		if err != nil {
+			abort(ctx, http.StatusServiceUnavailable, "Failed to check session", err)
+			return
+		}
+		if revoked {
+			abort(ctx, http.StatusUnauthorized, "Session revoked", nil)
+			return
+		}
+	}
+	ctx.Set(CtxUserID, claims.UserID)
+	ctx.Set(CtxRole, claims.Role)
+	ctx.Next()
+}
+
+// RequireRole пропускает запрос, только если роль пользователя входит в roles.
+// Используется после Authenticate.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role := ctx.GetString(CtxRole)
+		for _, allowed := range roles {
+			if role == allowed {
+				ctx.Next()
+				return
+			}
+		}
+		abort(ctx, http.StatusForbidden, "Access denied", nil)
+	}
+}
+
+func UserID(ctx *gin.Context) int {
+	return ctx.GetInt(CtxUserID)
+}
+
+func Role(ctx *gin.Context) string {
+	return ctx.GetString(CtxRole)
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	j "github.com/wileytor/go-market/common/jwt"
+	"github.com/wileytor/go-market/common/models"
+)
+
+type stubVerifier map[string]*j.Claims
+
+func (v stubVerifier) Verify(tokenStr string) (*j.Claims, error) {
+	claims, ok := v[tokenStr]
+	if !ok {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+type stubRevocation map[int]bool
+
+func (r stubRevocation) IsRevoked(_ context.Context, _ string, claims *j.Claims) (bool, error) {
+	revoked, ok := r[claims.SessionID]
+	if !ok {
+		return false, fmt.Errorf("auth unavailable")
+	}
+	return revoked, nil
+}
+
+func TestRequireRole(t *testing.T) {
+	verifier := stubVerifier{
+		"buyer":   {UserID: 1, SessionID: 1, Role: models.RoleBuyer},
+		"seller":  {UserID: 2, SessionID: 2, Role: models.RoleSeller},
+		"admin":   {UserID: 3, SessionID: 3, Role: models.RoleAdmin},
+		"revoked": {UserID: 4, SessionID: 4, Role: models.RoleAdmin},
+		"unknown": {UserID: 5, SessionID: 5, Role: models.RoleAdmin},
+	}
+	revocation := stubRevocation{1: false, 2: false, 3: false, 4: true}
+	r := gin.Default()
+	r.POST("/products/add", Authenticate(verifier, WithRevocationCheck(revocation)), RequireRole(models.RoleSeller, models.RoleAdmin), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusCreated, UserID(ctx))
+	})
+	httpSrv := httptest.NewServer(r)
+	defer httpSrv.Close()
+
+	type test struct {
+		name       string
+		token      string
+		statusCode int
+		body       string
+	}
+	tests := []test{
+		{
+			name:       "Test RequireRole; Case 1: no header",
+			token:      "",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"status":401,"message":"The header is missing"}`,
+		},
+		{
+			name:       "Test RequireRole; Case 2: invalid token",
+			token:      "forged",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"status":401,"message":"Invalid token","error":"invalid token"}`,
+		},
+		{
+			name:       "Test RequireRole; Case 3: buyer",
+			token:      "buyer",
+			statusCode: http.StatusForbidden,
+			body:       `{"status":403,"message":"Access denied"}`,
+		},
+		{
+			name:       "Test RequireRole; Case 4: seller",
+			token:      "seller",
+			statusCode: http.StatusCreated,
+			body:       `2`,
+		},
+		{
+			name:       "Test RequireRole; Case 5: admin with bearer prefix",
+			token:      "Bearer admin",
+			statusCode: http.StatusCreated,
+			body:       `3`,
+		},
+		{
+			name:       "Test RequireRole; Case 6: revoked session",
+			token:      "revoked",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"status":401,"message":"Session revoked"}`,
+		},
+		{
+			name:       "Test RequireRole; Case 7: revocation check failed",
+			token:      "unknown",
+			statusCode: http.StatusServiceUnavailable,
+			body:       `{"status":503,"message":"Failed to check session","error":"auth unavailable"}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := resty.New().R()
+			if tc.token != "" {
+				req.SetHeader("Authorization", tc.token)
+			}
+			resp, err := req.Post(httpSrv.URL + "/products/add")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.statusCode, resp.StatusCode())
+			assert.Equal(t, tc.body, string(resp.Body()))
+		})
+	}
+}
+
+type countingRevocation struct {
+	calls   int
+	revoked bool
+	err     error
+}
+
+func (c *countingRevocation) IsRevoked(_ context.Context, _ string, _ *j.Claims) (bool, error) {
+	c.calls++
+	return c.revoked, c.err
+}
+
+func TestCachedRevocationChecker(t *testing.T) {
+	remote := &countingRevocation{}
+	checker := NewCachedRevocationChecker(remote, time.Hour)
+	claims := &j.Claims{SessionID: 1}
+
+	revoked, err := checker.IsRevoked(context.Background(), "token", claims)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	revoked, err = checker.IsRevoked(context.Background(), "token", claims)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Equal(t, 1, remote.calls)
+
+	// по истечении ttl при недоступном auth используется последний известный ответ
+	checker.ttl = 0
+	remote.err = fmt.Errorf("auth unavailable")
+	revoked, err = checker.IsRevoked(context.Background(), "token", claims)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Equal(t, 2, remote.calls)
+
+	_, err = checker.IsRevoked(context.Background(), "token", &j.Claims{SessionID: 2})
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/wileytor/go-market/common/jwt"
+)
+
+// CachedRevocationChecker кэширует результат удаленной проверки по id сессии,
+// чтобы не обращаться к сервису auth на каждый запрос.
+// Если сервис недоступен, используется последний известный результат.
+type CachedRevocationChecker struct {
+	checker RevocationChecker
+	ttl     time.Duration
+
+	mu        sync.Mutex
+	cache     map[int]revocationEntry
+	lastEvict time.Time
+}
+
+type revocationEntry struct {
+	revoked   bool
+	checkedAt time.Time
+}
+
+func NewCachedRevocationChecker(checker RevocationChecker, ttl time.Duration) *CachedRevocationChecker {
+	return &CachedRevocationChecker{
+		checker: checker,
+		ttl:     ttl,
+		cache:   make(map[int]revocationEntry),
+	}
+}
+
+func (c *CachedRevocationChecker) IsRevoked(ctx context.Context, tokenStr string, claims *jwt.Claims) (bool, error) {
+	c.mu.Lock()
+	entry, ok := c.cache[claims.SessionID]
+	c.mu.Unlock()
+	// отзыв необратим, повторно проверять отозванную сессию не нужно
+	if ok && (entry.revoked || time.Since(entry.checkedAt) < c.ttl) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := c.checker.IsRevoked(ctx, tokenStr, claims)
+	if err != nil {
+		if ok {
+			return entry.revoked, nil
+		}
+		return false, err
+	}
+
+	c.mu.Lock()
+	c.cache[claims.SessionID] = revocationEntry{revoked: revoked, checkedAt: time.Now()}
+	c.evictExpired()
+	c.mu.Unlock()
+	return revoked, nil
+}
+
+// evictExpired периодически удаляет записи, пережившие срок действия access-токена
+func (c *CachedRevocationChecker) evictExpired() {
+	const maxAge = time.Hour
+	if time.Since(c.lastEvict) < maxAge {
+		return
+	}
+	c.lastEvict = time.Now()
+	for sessionID, entry := range c.cache {
+		if time.Since(entry.checkedAt) > maxAge {
+			delete(c.cache, sessionID)
+		}
+	}
+}
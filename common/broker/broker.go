@@ -0,0 +1,154 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrClosed - брокер закрыт
+	ErrClosed = errors.New("broker is closed")
+	// ErrUnroutable - ни одна очередь не подходит под ключ маршрутизации
+	ErrUnroutable = errors.New("message is unroutable")
+	// ErrNacked - брокер не смог принять сообщение
+	ErrNacked = errors.New("message was nacked by broker")
+	// ErrConfirmTimeout - подтверждение не пришло до окончания контекста
+	ErrConfirmTimeout = errors.New("timed out waiting for publish confirm")
+	// ErrQueueNotFound - очередь не объявлена
+	ErrQueueNotFound = errors.New("queue not found")
+)
+
+const (
+	RetryCountHeader = "x-retry-count"
+	LastErrorHeader  = "x-last-error"
+)
+
+// Message - сообщение независимо от транспорта
+type Message struct {
+	ID            string
+	CorrelationID string
+	ReplyTo       string
+	ContentType   string
+	Headers       map[string]interface{}
+	Timestamp     time.Time
+	Body          []byte
+}
+
+// Handler обрабатывает сообщение. Ошибка означает, что обработку нужно повторить.
+type Handler func(Message) error
+
+// RPCHandler обрабатывает запрос и возвращает тело ответа
+type RPCHandler func([]byte) ([]byte, error)
+
+// Broker публикует и потребляет сообщения и выполняет RPC-запросы
+type Broker interface {
+	// Publish доставляет сообщение в очередь и ждет подтверждения
+	Publish(ctx context.Context, queue string, body []byte) error
+	// PublishEvent рассылает событие всем подписчикам типа eventType и ждет подтверждения
+	PublishEvent(ctx context.Context, eventType string, body []byte) error
+	// Consume обрабатывает сообщения очереди, пока брокер не будет закрыт
+	Consume(queue string, opts ConsumerOptions, handler Handler) error
+	// Call отправляет запрос в очередь и ждет ответа
+	Call(ctx context.Context, queue string, body []byte) ([]byte, error)
+	// ServeRPC отвечает на запросы из очереди
+	ServeRPC(queue string, opts ConsumerOptions, handler RPCHandler) error
+	Close()
+}
+
+// DeadLetterStore дает доступ к сообщениям, которые не удалось обработать
+type DeadLetterStore interface {
+	PeekDeadLetters(queue string, limit int) ([]DeadLetter, error)
+	ReplayDeadLetters(ctx context.Context, queue string, limit int) (int, error)
+}
+
+// DeadLetter - сообщение из очереди недоставленных
+type DeadLetter struct {
+	MessageID     string    `json:"message_id,omitempty"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error"`
+	Body          string    `json:"body"`
+}
+
+type ConsumerOptions struct {
+	// Prefetch - сколько неподтвержденных сообщений брокер отдает потребителю
+	Prefetch int
+	// Workers - сколько сообщений обрабатывается одновременно
+	Workers int
+	// MaxRetries - после стольких повторов сообщение уходит в очередь недоставленных
+	MaxRetries int
+	// RetryDelay - задержка перед повтором
+	RetryDelay time.Duration
+}
+
+var DefaultConsumerOptions = ConsumerOptions{
+	Prefetch:   10,
+	Workers:    1,
+	MaxRetries: 3,
+	RetryDelay: 5 * time.Second,
+}
+
+// WithDefaults заполняет незаданные поля значениями по умолчанию
+func (o ConsumerOptions) WithDefaults() ConsumerOptions {
+	if o.Prefetch <= 0 {
+		o.Prefetch = DefaultConsumerOptions.Prefetch
+	}
+	if o.Workers <= 0 {
+		o.Workers = DefaultConsumerOptions.Workers
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	}
+	if o.RetryDelay <= 0 {
+		o.RetryDelay = DefaultConsumerOptions.RetryDelay
+	}
+	return o
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку как неисправимую: сообщение сразу уходит в очередь недоставленных
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// SafeHandle вызывает обработчик, превращая панику в ошибку
+func SafeHandle(handler Handler, msg Message) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("handler panic: %v", p)
+		}
+	}()
+	return handler(msg)
+}
+
+// RetryCount возвращает, сколько раз сообщение уже обрабатывалось с ошибкой
+func RetryCount(headers map[string]interface{}) int {
+	switch v := headers[RetryCountHeader].(type) {
+	case int64:
+		return int(v)
+	case int32:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// ShouldDeadLetter решает, отправить ли сообщение в очередь недоставленных после ошибки err
+func ShouldDeadLetter(err error, attempts int, opts ConsumerOptions) bool {
+	return attempts >= opts.MaxRetries || IsPermanent(err)
+}
@@ -0,0 +1,42 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeHandle(t *testing.T) {
+	err := SafeHandle(func(Message) error { panic("boom") }, Message{})
+	assert.EqualError(t, err, "handler panic: boom")
+
+	err = SafeHandle(func(Message) error { return nil }, Message{})
+	assert.NoError(t, err)
+}
+
+func TestRetryCount(t *testing.T) {
+	assert.Equal(t, 0, RetryCount(nil))
+	assert.Equal(t, 2, RetryCount(map[string]interface{}{RetryCountHeader: int64(2)}))
+	assert.Equal(t, 3, RetryCount(map[string]interface{}{RetryCountHeader: int32(3)}))
+	assert.Equal(t, 0, RetryCount(map[string]interface{}{RetryCountHeader: "1"}))
+}
+
+func TestShouldDeadLetter(t *testing.T) {
+	opts := ConsumerOptions{MaxRetries: 2}
+	cause := errors.New("bad payload")
+
+	assert.False(t, ShouldDeadLetter(cause, 1, opts))
+	assert.True(t, ShouldDeadLetter(cause, 2, opts))
+	assert.True(t, ShouldDeadLetter(fmt.Errorf("token check: %w", Permanent(cause)), 0, opts))
+	assert.ErrorIs(t, Permanent(cause), cause)
+}
+
+func TestConsumerOptionsDefaults(t *testing.T) {
+	opts := ConsumerOptions{Workers: 4, MaxRetries: -1}.WithDefaults()
+	assert.Equal(t, 4, opts.Workers)
+	assert.Equal(t, DefaultConsumerOptions.Prefetch, opts.Prefetch)
+	assert.Equal(t, 0, opts.MaxRetries)
+	assert.Equal(t, DefaultConsumerOptions.RetryDelay, opts.RetryDelay)
+}
@@ -0,0 +1,295 @@
+package broker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const memoryQueueSize = 1024
+
+// Memory - брокер в памяти процесса. Подходит для тестов и локального запуска без RabbitMQ.
+// Очереди создаются при первом обращении, сообщения теряются при остановке процесса.
+type Memory struct {
+	mu     sync.Mutex
+	queues map[string]chan Message
+	// consumers - число потребителей очереди; события без потребителей отбрасываются
+	consumers map[string]int
+	dead      map[string][]Message
+	pending   map[string]chan []byte
+	done      chan struct{}
+	closed    bool
+}
+
+var (
+	_ Broker          = (*Memory)(nil)
+	_ DeadLetterStore = (*Memory)(nil)
+)
+
+func NewMemory() *Memory {
+	return &Memory{
+		queues:    make(map[string]chan Message),
+		consumers: make(map[string]int),
+		dead:      make(map[string][]Message),
+		pending:   make(map[string]chan []byte),
+		done:      make(chan struct{}),
+	}
+}
+
+func (m *Memory) queue(name string) (chan Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	q, ok := m.queues[name]
+	if !ok {
+		q = make(chan Message, memoryQueueSize)
+		m.queues[name] = q
+	}
+	return q, nil
+}
+
+func (m *Memory) Publish(ctx context.Context, queue string, body []byte) error {
+	id, err := NewID()
+	if err != nil {
+		return err
+	}
+	return m.publish(ctx, queue, Message{
+		ID:          id,
+		ContentType: "application/json",
+		Timestamp:   time.Now(),
+		Body:        body,
+	})
+}
+
+// PublishEvent кладет событие в очередь с именем eventType, если ее кто-то потребляет.
+// Без потребителей событие отбрасывается, как в точке обмена без привязанных очередей.
+func (m *Memory) PublishEvent(ctx context.Context, eventType string, body []byte) error {
+	m.mu.Lock()
+	subscribed := m.consumers[eventType] > 0
+	m.mu.Unlock()
+	if !subscribed {
+		return nil
+	}
+	return m.Publish(ctx, eventType, body)
+}
+
+func (m *Memory) publish(ctx context.Context, queue string, msg Message) error {
+	q, err := m.queue(queue)
+	if err != nil {
+		return err
+	}
+	select {
+	case q <- msg:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %v", ErrConfirmTimeout, ctx.Err())
+	case <-m.done:
+		return ErrClosed
+	}
+}
+
+func (m *Memory) Consume(queue string, opts ConsumerOptions, handler Handler) error {
+	opts = opts.WithDefaults()
+	q, err := m.queue(queue)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.consumers[queue]++
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.consumers[queue]--
+		m.mu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case msg := <-q:
+					m.process(queue, opts, handler, msg)
+				case <-m.done:
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (m *Memory) process(queue string, opts ConsumerOptions, handler Handler, msg Message) {
+	err := SafeHandle(handler, msg)
+	if err == nil {
+		return
+	}
+
+	attempts := RetryCount(msg.Headers)
+	headers := make(map[string]interface{}, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[RetryCountHeader] = attempts + 1
+	headers[LastErrorHeader] = err.Error()
+	msg.Headers = headers
+
+	if ShouldDeadLetter(err, attempts, opts) {
+		log.Printf("message from %s dead-lettered after %d retries: %v", queue, attempts, err)
+		m.mu.Lock()
+		m.dead[queue] = append(m.dead[queue], msg)
+		m.mu.Unlock()
+		return
+	}
+	time.AfterFunc(opts.RetryDelay, func() {
+		if err := m.publish(context.Background(), queue, msg); err != nil {
+			log.Printf("failed to retry message from %s: %v", queue, err)
+		}
+	})
+}
+
+func (m *Memory) Call(ctx context.Context, queue string, body []byte) ([]byte, error) {
+	id, err := NewID()
+	if err != nil {
+		return nil, err
+	}
+	reply := make(chan []byte, 1)
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrClosed
+	}
+	m.pending[id] = reply
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.pending, id)
+		m.mu.Unlock()
+	}()
+
+	err = m.publish(ctx, queue, Message{
+		ID:            id,
+		CorrelationID: id,
+		ContentType:   "application/json",
+		Timestamp:     time.Now(),
+		Body:          body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-reply:
+		return response, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("rpc call to %s: %w", queue, ctx.Err())
+	case <-m.done:
+		return nil, ErrClosed
+	}
+}
+
+func (m *Memory) ServeRPC(queue string, opts ConsumerOptions, handler RPCHandler) error {
+	return m.Consume(queue, opts, func(msg Message) error {
+		response, err := handler(msg.Body)
+		if err != nil {
+			return err
+		}
+		m.mu.Lock()
+		reply, ok := m.pending[msg.CorrelationID]
+		m.mu.Unlock()
+		if ok {
+			select {
+			case reply <- response:
+			default:
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Memory) PeekDeadLetters(queue string, limit int) ([]DeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.queues[queue]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrQueueNotFound, queue)
+	}
+
+	letters := make([]DeadLetter, 0)
+	for _, msg := range m.dead[queue] {
+		if len(letters) >= limit {
+			break
+		}
+		lastError, _ := msg.Headers[LastErrorHeader].(string)
+		letters = append(letters, DeadLetter{
+			MessageID:     msg.ID,
+			CorrelationID: msg.CorrelationID,
+			Timestamp:     msg.Timestamp,
+			Attempts:      RetryCount(msg.Headers),
+			LastError:     lastError,
+			Body:          string(msg.Body),
+		})
+	}
+	return letters, nil
+}
+
+func (m *Memory) ReplayDeadLetters(ctx context.Context, queue string, limit int) (int, error) {
+	m.mu.Lock()
+	if _, ok := m.queues[queue]; !ok {
+		m.mu.Unlock()
+		return 0, fmt.Errorf("%w: %s", ErrQueueNotFound, queue)
+	}
+	dead := m.dead[queue]
+	if limit > len(dead) {
+		limit = len(dead)
+	}
+	replay := dead[:limit]
+	m.dead[queue] = dead[limit:]
+	m.mu.Unlock()
+
+	for i, msg := range replay {
+		headers := make(map[string]interface{}, len(msg.Headers))
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		delete(headers, RetryCountHeader)
+		delete(headers, LastErrorHeader)
+		msg.Headers = headers
+
+		if err := m.publish(ctx, queue, msg); err != nil {
+			// возвращаем неперенесенные сообщения обратно
+			m.mu.Lock()
+			m.dead[queue] = append(append([]Message(nil), replay[i:]...), m.dead[queue]...)
+			m.mu.Unlock()
+			return i, err
+		}
+	}
+	return len(replay), nil
+}
+
+func (m *Memory) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closed = true
+		close(m.done)
+	}
+}
+
+// NewID возвращает случайный идентификатор сообщения
+func NewID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
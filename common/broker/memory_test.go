@@ -0,0 +1,136 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryPublishConsume(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+
+	received := make(chan Message, 1)
+	go m.Consume("events", ConsumerOptions{}, func(msg Message) error {
+		received <- msg
+		return nil
+	})
+
+	require.NoError(t, m.Publish(context.Background(), "events", []byte(`{"id":1}`)))
+	select {
+	case msg := <-received:
+		assert.Equal(t, `{"id":1}`, string(msg.Body))
+		assert.NotEmpty(t, msg.ID)
+	case <-time.After(time.Second):
+		t.Fatal("message was not consumed")
+	}
+}
+
+func TestMemoryCall(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+
+	go m.ServeRPC("echo", ConsumerOptions{Workers: 4}, func(body []byte) ([]byte, error) {
+		return append([]byte("re:"), body...), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, body := range []string{"a", "b", "c"} {
+		response, err := m.Call(ctx, "echo", []byte(body))
+		require.NoError(t, err)
+		assert.Equal(t, "re:"+body, string(response))
+	}
+}
+
+func TestMemoryCallTimeout(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := m.Call(ctx, "nobody", []byte("ping"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMemoryDeadLetters(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+
+	var calls int32
+	opts := ConsumerOptions{MaxRetries: 2, RetryDelay: time.Millisecond}
+	go m.Consume("jobs", opts, func(Message) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("boom")
+	})
+
+	require.NoError(t, m.Publish(context.Background(), "jobs", []byte("job")))
+	require.Eventually(t, func() bool {
+		letters, err := m.PeekDeadLetters("jobs", 10)
+		return err == nil && len(letters) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	letters, err := m.PeekDeadLetters("jobs", 10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, letters[0].Attempts)
+	assert.Equal(t, "boom", letters[0].LastError)
+	assert.Equal(t, "job", letters[0].Body)
+
+	replayed, err := m.ReplayDeadLetters(context.Background(), "jobs", 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) == 6
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = m.PeekDeadLetters("unknown", 10)
+	assert.ErrorIs(t, err, ErrQueueNotFound)
+}
+
+func TestMemoryPermanentError(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+
+	go m.Consume("jobs", ConsumerOptions{MaxRetries: 5}, func(Message) error {
+		return Permanent(errors.New("bad payload"))
+	})
+	require.NoError(t, m.Publish(context.Background(), "jobs", []byte("job")))
+	require.Eventually(t, func() bool {
+		letters, err := m.PeekDeadLetters("jobs", 10)
+		return err == nil && len(letters) == 1 && letters[0].Attempts == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestMemoryPublishEventWithoutConsumers(t *testing.T) {
+	m := NewMemory()
+	defer m.Close()
+
+	// Событие без потребителей отбрасывается и не заполняет очередь
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 2*memoryQueueSize; i++ {
+		require.NoError(t, m.PublishEvent(ctx, "order.created", []byte("dropped")))
+	}
+
+	received := make(chan Message, 1)
+	go m.Consume("order.created", ConsumerOptions{}, func(msg Message) error {
+		received <- msg
+		return nil
+	})
+	require.Eventually(t, func() bool {
+		require.NoError(t, m.PublishEvent(ctx, "order.created", []byte("delivered")))
+		select {
+		case msg := <-received:
+			assert.Equal(t, "delivered", string(msg.Body))
+			return true
+		case <-time.After(10 * time.Millisecond):
+			return false
+		}
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,112 @@
+package jwt
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer отдает JWKS текущего набора ключей, как /.well-known/jwks.json сервиса auth
+type jwksServer struct {
+	mu       sync.Mutex
+	keys     *KeySet
+	fail     bool
+	requests int
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	json.NewEncoder(w).Encode(s.keys.JWKS())
+}
+
+func (s *jwksServer) set(keys *KeySet, fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.fail = fail
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func TestJWKSClientRefresh(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "2026-01-01", newEd25519Key(t))
+	oldKeys, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+	oldToken, err := oldKeys.Sign(testClaims())
+	require.NoError(t, err)
+
+	server := &jwksServer{keys: oldKeys}
+	httpSrv := httptest.NewServer(server)
+	defer httpSrv.Close()
+	client := NewJWKSClient(httpSrv.URL, nil)
+
+	// Первый токен загружает ключи, следующие проверяются по кэшу
+	claims, err := client.Verify(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+	_, err = client.Verify(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.count())
+
+	// Ротация: auth подписывает новым ключом и публикует оба
+	writeKey(t, dir, "2026-02-01", newEd25519Key(t))
+	newKeys, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+	server.set(newKeys, false)
+	newToken, err := newKeys.Sign(testClaims())
+	require.NoError(t, err)
+
+	// Неизвестный kid не перечитывает ключи чаще раза в jwksMinRefresh
+	_, err = client.Verify(newToken)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, 1, server.count())
+
+	client.mu.Lock()
+	client.attempted = time.Now().Add(-jwksMinRefresh)
+	client.mu.Unlock()
+	_, err = client.Verify(newToken)
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.count())
+	_, err = client.Verify(oldToken)
+	assert.NoError(t, err)
+
+	// auth недоступен после истечения кэша - работаем с закэшированными ключами
+	server.set(newKeys, true)
+	client.mu.Lock()
+	client.fetched = time.Now().Add(-jwksCacheTTL)
+	client.attempted = time.Now().Add(-jwksMinRefresh)
+	client.mu.Unlock()
+	_, err = client.Verify(newToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, server.count())
+
+	// Старый ключ удален из каталога - после обновления его токены отклоняются
+	prunedDir := t.TempDir()
+	writeKey(t, prunedDir, newKeys.SigningKID(), newKeys.signing.private)
+	prunedKeys, err := LoadKeySet(prunedDir, "")
+	require.NoError(t, err)
+	server.set(prunedKeys, false)
+	require.NoError(t, client.Refresh(context.Background()))
+	_, err = client.Verify(newToken)
+	assert.NoError(t, err)
+	_, err = client.Verify(oldToken)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
@@ -1,10 +1,48 @@
 package jwt
 
-import "github.com/golang-jwt/jwt/v4"
+import (
+	"errors"
 
-const SecretKey = "marketPrivateKey"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// validMethods - алгоритмы, которые принимаются при проверке токенов.
+// HS256 не входит в список: проверяющий сервис не должен уметь выпускать токены.
+var validMethods = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
 
 type Claims struct {
 	jwt.RegisteredClaims
-	UserID int
+	UserID    int
+	SessionID int
+	Role      string
+}
+
+// Verifier проверяет подпись и срок действия токена
+type Verifier interface {
+	Verify(tokenStr string) (*Claims, error)
+}
+
+// parse разбирает токен, получая публичный ключ по kid из заголовка
+func parse(tokenStr string, lookup func(kid string) (interface{}, error)) (*Claims, error) {
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(validMethods))
+	token, err := parser.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok || kid == "" {
+			return nil, ErrUnknownKey
+		}
+		return lookup(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
 }
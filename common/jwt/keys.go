@@ -0,0 +1,143 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Key - ключ подписи. kid совпадает с именем PEM-файла без расширения.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+func (k *Key) Public() crypto.PublicKey {
+	return k.private.Public()
+}
+
+// KeySet - набор ключей подписи сервиса auth.
+// Новые токены подписываются одним активным ключом, а проверяются всеми ключами набора,
+// поэтому при ротации старый ключ остается в каталоге, пока не истекут выданные им токены.
+type KeySet struct {
+	keys    map[string]*Key
+	signing *Key
+}
+
+// LoadKeySet загружает все *.pem ключи (RSA или Ed25519) из каталога.
+// Если signingKID пуст, активным становится ключ с наибольшим kid.
+func LoadKeySet(dir, signingKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys in %s: %w", dir, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	ks := &KeySet{keys: make(map[string]*Key, len(paths))}
+	for _, path := range paths {
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[key.ID] = key
+		if signingKID == "" || key.ID == signingKID {
+			ks.signing = key
+		}
+	}
+	if signingKID != "" && (ks.signing == nil || ks.signing.ID != signingKID) {
+		return nil, fmt.Errorf("signing key %q not found in %s", signingKID, dir)
+	}
+	return ks, nil
+}
+
+func loadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM in %s", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", path, err)
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.private = k
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.private = k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T in %s", parsed, path)
+	}
+	return key, nil
+}
+
+// SigningKID возвращает kid активного ключа
+func (ks *KeySet) SigningKID() string {
+	return ks.signing.ID
+}
+
+// Sign подписывает claims активным ключом и проставляет kid в заголовок
+func (ks *KeySet) Sign(claims Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	tokenStr, err := token.SignedString(ks.signing.private)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return tokenStr, nil
+}
+
+// Verify проверяет токен любым ключом набора
+func (ks *KeySet) Verify(tokenStr string) (*Claims, error) {
+	return parse(tokenStr, func(kid string) (interface{}, error) {
+		key, ok := ks.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return key.Public(), nil
+	})
+}
+
+// JWKS возвращает публичные ключи набора для /.well-known/jwks.json
+func (ks *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		set.Keys = append(set.Keys, newJWK(ks.keys[kid]))
+	}
+	return set
+}
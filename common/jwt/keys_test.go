@@ -0,0 +1,153 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKey сохраняет ключ в dir/<kid>.pem: RSA в PKCS1, Ed25519 в PKCS8, как их создает openssl
+func writeKey(t *testing.T, dir, kid string, key interface{}) {
+	t.Helper()
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(block), 0o600))
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func testClaims() Claims {
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		UserID:           7,
+		SessionID:        3,
+		Role:             "buyer",
+	}
+}
+
+func TestKeySetSignVerify(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeKey(t, dir, "2026-01-01", rsaKey)
+	writeKey(t, dir, "2026-02-01", newEd25519Key(t))
+
+	// Без явного kid подписывает ключ с наибольшим kid
+	ks, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-02-01", ks.SigningKID())
+
+	tokenStr, err := ks.Sign(testClaims())
+	require.NoError(t, err)
+	claims, err := ks.Verify(tokenStr)
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+	assert.Equal(t, 3, claims.SessionID)
+	assert.Equal(t, "buyer", claims.Role)
+
+	// Токены старого ключа проверяются, пока он остается в наборе
+	old, err := LoadKeySet(dir, "2026-01-01")
+	require.NoError(t, err)
+	oldToken, err := old.Sign(testClaims())
+	require.NoError(t, err)
+	_, err = ks.Verify(oldToken)
+	assert.NoError(t, err)
+
+	set := ks.JWKS()
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "RS256", set.Keys[0].Alg)
+	assert.Equal(t, "EdDSA", set.Keys[1].Alg)
+
+	_, err = LoadKeySet(dir, "2026-03-01")
+	assert.Error(t, err)
+	_, err = LoadKeySet(t.TempDir(), "")
+	assert.Error(t, err)
+}
+
+func TestKeySetVerifyRejected(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "current", newEd25519Key(t))
+	ks, err := LoadKeySet(dir, "")
+	require.NoError(t, err)
+
+	otherDir := t.TempDir()
+	writeKey(t, otherDir, "retired", newEd25519Key(t))
+	other, err := LoadKeySet(otherDir, "")
+	require.NoError(t, err)
+	unknownKID, err := other.Sign(testClaims())
+	require.NoError(t, err)
+
+	// Ключ с kid из набора, но чужой подписью
+	forgedDir := t.TempDir()
+	writeKey(t, forgedDir, "current", newEd25519Key(t))
+	forged, err := LoadKeySet(forgedDir, "")
+	require.NoError(t, err)
+	forgedToken, err := forged.Sign(testClaims())
+	require.NoError(t, err)
+
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims())
+	hs.Header["kid"] = "current"
+	hsToken, err := hs.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims())
+	none.Header["kid"] = "current"
+	noneToken, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	noKID := jwt.NewWithClaims(jwt.SigningMethodEdDSA, testClaims())
+	noKIDToken, err := noKID.SignedString(ks.signing.private)
+	require.NoError(t, err)
+
+	expiredClaims := testClaims()
+	expiredClaims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	expiredToken, err := ks.Sign(expiredClaims)
+	require.NoError(t, err)
+
+	type test struct {
+		name  string
+		token string
+		err   error
+	}
+	tests := []test{
+		{name: "Case 1: unknown kid", token: unknownKID, err: ErrUnknownKey},
+		{name: "Case 2: missing kid", token: noKIDToken, err: ErrUnknownKey},
+		{name: "Case 3: wrong signature", token: forgedToken},
+		{name: "Case 4: HS256", token: hsToken},
+		{name: "Case 5: alg none", token: noneToken},
+		{name: "Case 6: expired", token: expiredToken},
+		{name: "Case 7: malformed", token: "not-a-token"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			claims, err := ks.Verify(tc.token)
+			assert.Error(t, err)
+			assert.Nil(t, claims)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
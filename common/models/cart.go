@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// CartItem - строка корзины. Цена и остаток берутся из каталога при каждом чтении.
+type CartItem struct {
+	UID       int    `json:"uid"`
+	ProductID int    `json:"productID"`
+	VariantID *int   `json:"variantID,omitempty"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	Price     Money  `json:"price"`
+	Total     Money  `json:"total"`
+	InStock   int    `json:"in_stock"`
+	// Available - товар не удален и его хватает на количество в корзине
+	Available bool `json:"available"`
+}
+
+// Cart - корзина пользователя. Total не заполняется, если в корзине товары в разных валютах.
+type Cart struct {
+	UserID    int        `json:"userID"`
+	Items     []CartItem `json:"items"`
+	Total     *Money     `json:"total,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CartQuantity - тело запроса на изменение количества в строке корзины
+type CartQuantity struct {
+	Quantity int `json:"quantity" validate:"required,min=1"`
+}
+
+// CheckoutRequest - необязательное тело запроса на оформление корзины
+type CheckoutRequest struct {
+	// Region - регион доставки для стратегии выбора склада region
+	Region string `json:"region" validate:"max=64"`
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Сортировка каталога
+const (
+	SortNewest    = "newest"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortNameAsc   = "name_asc"
+	SortNameDesc  = "name_desc"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ProductFilter - параметры выборки каталога. Cursor и Offset взаимоисключающие:
+// курсор стабилен при добавлении товаров, смещение позволяет перейти на произвольную страницу.
+type ProductFilter struct {
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" validate:"omitempty,min=0"`
+	Cursor string `form:"cursor"`
+	// MinPrice и MaxPrice - десятичные суммы в валюте Currency
+	MinPrice string `form:"min_price"`
+	MaxPrice string `form:"max_price"`
+	Currency string `form:"currency" validate:"required_with=MinPrice MaxPrice,omitempty,iso4217"`
+	InStock  bool   `form:"in_stock"`
+	Name     string `form:"name" validate:"max=100"`
+	Sort     string `form:"sort" validate:"omitempty,oneof=newest price_asc price_desc name_asc name_desc"`
+	// CategoryID ограничивает выборку категорией и всеми ее потомками
+	CategoryID int `form:"category_id" validate:"omitempty,min=1"`
+}
+
+// PriceRange разбирает границы цены. Незаданная граница возвращается как nil.
+func (f ProductFilter) PriceRange() (min, max *Money, err error) {
+	if f.MinPrice != "" {
+		m, err := ParseMoney(f.MinPrice, f.Currency)
+		if err != nil {
+			return nil, nil, fmt.Errorf("min_price: %w", err)
+		}
+		min = &m
+	}
+	if f.MaxPrice != "" {
+		m, err := ParseMoney(f.MaxPrice, f.Currency)
+		if err != nil {
+			return nil, nil, fmt.Errorf("max_price: %w", err)
+		}
+		max = &m
+	}
+	if min != nil && max != nil && min.Amount > max.Amount {
+		return nil, nil, errors.New("min_price cannot exceed max_price")
+	}
+	return min, max, nil
+}
+
+// ProductPage - страница каталога
+type ProductPage struct {
+	Items      []Product `json:"items"`
+	Total      int       `json:"total"`
+	Limit      int       `json:"limit"`
+	Offset     int       `json:"offset,omitempty"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// ProductSearch - параметры полнотекстового поиска
+type ProductSearch struct {
+	Query  string `form:"q" validate:"required,max=200"`
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" validate:"omitempty,min=0"`
+}
+
+// ProductSearchResult - найденный товар с релевантностью и фрагментом с подсветкой совпадений
+type ProductSearchResult struct {
+	Product
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// ProductSearchPage - страница результатов поиска, отсортированных по релевантности
+type ProductSearchPage struct {
+	Items  []ProductSearchResult `json:"items"`
+	Total  int                   `json:"total"`
+	Limit  int                   `json:"limit"`
+	Offset int                   `json:"offset,omitempty"`
+}
+
+// Category - узел дерева категорий. У корневых категорий ParentID не задан.
+type Category struct {
+	UID      int    `json:"uid"`
+	Name     string `json:"name" validate:"required,max=100"`
+	ParentID *int   `json:"parent_id,omitempty" validate:"omitempty,min=1"`
+}
+
+// ProductCategories - набор категорий, к которым относится товар
+type ProductCategories struct {
+	CategoryIDs []int `json:"category_ids" validate:"max=20,dive,min=1"`
+}
+
+// ProductDetails - товар с вариантами, остатками по складам и путями от корня до каждой его категории
+type ProductDetails struct {
+	Product
+	Variants     []ProductVariant `json:"variants,omitempty"`
+	Availability *Availability    `json:"availability,omitempty"`
+	Breadcrumbs  [][]Category     `json:"breadcrumbs,omitempty"`
+}
+
+// ProductVariant - вариант товара (размер, цвет) со своим артикулом и остатком.
+// Если у товара есть варианты, покупается конкретный вариант, а не сам товар.
+type ProductVariant struct {
+	UID        int               `json:"uid"`
+	ProductID  int               `json:"product_id"`
+	SKU        string            `json:"sku" validate:"required,max=64"`
+	Attributes map[string]string `json:"attributes" validate:"max=10,dive,keys,min=1,max=50,endkeys,max=100"`
+	// Price переопределяет цену товара, если задана. Валюта совпадает с валютой товара.
+	Price    *Money `json:"price,omitempty"`
+	Quantity int    `json:"quantity" validate:"min=0"`
+}
@@ -0,0 +1,262 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Типы сообщений между сервисами
+const (
+	MessageTypeTokenCheck       = "auth.token_check.request"
+	MessageTypeTokenCheckResult = "auth.token_check.response"
+)
+
+var (
+	ErrInvalidEnvelope     = errors.New("invalid message envelope")
+	ErrUnknownMessageType  = errors.New("unknown message type")
+	ErrUnsupportedVersion  = errors.New("unsupported message version")
+	ErrUnexpectedMessage   = errors.New("unexpected message type")
+	ErrInvalidMessageValue = errors.New("invalid message payload")
+)
+
+// Envelope - общая обертка всех сообщений между сервисами
+type Envelope struct {
+	Type      string          `json:"type"`
+	Version   int             `json:"version"`
+	ID        string          `json:"id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Trace     TraceContext    `json:"trace"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// TraceContext - контекст трассировки в формате W3C Trace Context
+type TraceContext struct {
+	TraceParent string `json:"traceparent,omitempty"`
+	TraceState  string `json:"tracestate,omitempty"`
+}
+
+// messageSchema описывает поддерживаемые версии сообщения
+type messageSchema struct {
+	current  int
+	versions []int
+	payload  func() interface{}
+	// upgrade приводит payload старой версии к текущей, если их формат различается
+	upgrade map[int]func(json.RawMessage) (json.RawMessage, error)
+}
+
+// messageSchemas - реестр всех сообщений. Новая версия добавляется сюда,
+// старая удаляется только после того, как ее перестали отправлять все сервисы.
+var messageSchemas = map[string]messageSchema{
+	MessageTypeTokenCheck: {
+		current:  1,
+		versions: []int{1},
+		payload:  func() interface{} { return &TokenCheckMessage{} },
+	},
+	MessageTypeTokenCheckResult: {
+		current:  1,
+		versions: []int{1},
+		payload:  func() interface{} { return &TokenCheckResponse{} },
+	},
+	EventPurchaseCreated: {
+		current:  2,
+		versions: []int{1, 2},
+		payload:  func() interface{} { return &PurchaseCreatedEvent{} },
+	},
+	EventOrderCreated: {
+		current:  1,
+		versions: []int{1},
+		payload:  func() interface{} { return &OrderCreatedEvent{} },
+	},
+	EventOrderStatus: {
+		current:  1,
+		versions: []int{1},
+		payload:  func() interface{} { return &OrderStatusChangedEvent{} },
+	},
+	EventProductCreated: {
+		current:  2,
+		versions: []int{1, 2},
+		payload:  func() interface{} { return &ProductChangedEvent{} },
+		upgrade:  map[int]func(json.RawMessage) (json.RawMessage, error){1: upgradeProductChangedV1},
+	},
+	EventProductUpdated: {
+		current:  2,
+		versions: []int{1, 2},
+		payload:  func() interface{} { return &ProductChangedEvent{} },
+		upgrade:  map[int]func(json.RawMessage) (json.RawMessage, error){1: upgradeProductChangedV1},
+	},
+	EventProductDeleted: {
+		current:  1,
+		versions: []int{1},
+		payload:  func() interface{} { return &ProductDeletedEvent{} },
+	},
+	EventStockLow: {
+		current:  1,
+		versions: []int{1},
+		payload:  func() interface{} { return &StockLowEvent{} },
+	},
+}
+
+// payloadValidator реализуют сообщения с собственными правилами проверки
+type payloadValidator interface {
+	Validate() error
+}
+
+// MessageTypes возвращает все зарегистрированные типы сообщений
+func MessageTypes() []string {
+	types := make([]string, 0, len(messageSchemas))
+	for t := range messageSchemas {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// NewMessagePayload возвращает указатель на пустое сообщение типа msgType
+func NewMessagePayload(msgType string) (interface{}, error) {
+	schema, ok := messageSchemas[msgType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMessageType, msgType)
+	}
+	return schema.payload(), nil
+}
+
+// EncodeMessage оборачивает payload в конверт текущей версии
+func EncodeMessage(msgType string, payload interface{}, trace TraceContext) ([]byte, error) {
+	schema, ok := messageSchemas[msgType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMessageType, msgType)
+	}
+	if v, ok := payload.(payloadValidator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidMessageValue, msgType, err)
+		}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", msgType, err)
+	}
+	id, err := newMessageID()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{
+		Type:      msgType,
+		Version:   schema.current,
+		ID:        id,
+		Timestamp: time.Now().UTC(),
+		Trace:     trace,
+		Payload:   data,
+	})
+}
+
+// DecodeMessage проверяет конверт и раскладывает payload сообщения типа msgType в dst.
+// Неизвестные версии и поля payload отклоняются. Payload старой версии сначала
+// приводится к текущей, и в возвращаемом конверте лежит уже приведенный payload.
+func DecodeMessage(data []byte, msgType string, dst interface{}) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if err := env.validate(); err != nil {
+		return Envelope{}, err
+	}
+	if env.Type != msgType {
+		return Envelope{}, fmt.Errorf("%w: got %s, want %s", ErrUnexpectedMessage, env.Type, msgType)
+	}
+	if upgrade, ok := messageSchemas[env.Type].upgrade[env.Version]; ok {
+		payload, err := upgrade(env.Payload)
+		if err != nil {
+			return Envelope{}, fmt.Errorf("%w: %s v%d: %v", ErrInvalidMessageValue, msgType, env.Version, err)
+		}
+		env.Payload = payload
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(env.Payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %s: %v", ErrInvalidMessageValue, msgType, err)
+	}
+	if v, ok := dst.(payloadValidator); ok {
+		if err := v.Validate(); err != nil {
+			return Envelope{}, fmt.Errorf("%w: %s: %v", ErrInvalidMessageValue, msgType, err)
+		}
+	}
+	return env, nil
+}
+
+func (e Envelope) validate() error {
+	if e.Type == "" || e.ID == "" || e.Timestamp.IsZero() || len(e.Payload) == 0 {
+		return fmt.Errorf("%w: type, id, timestamp and payload are required", ErrInvalidEnvelope)
+	}
+	schema, ok := messageSchemas[e.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownMessageType, e.Type)
+	}
+	for _, v := range schema.versions {
+		if v == e.Version {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s v%d", ErrUnsupportedVersion, e.Type, e.Version)
+}
+
+// upgradeProductChangedV1 переводит цену v1 (число в рублях) в Money v2
+func upgradeProductChangedV1(payload json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+	if raw, ok := fields["price"]; ok {
+		var price float64
+		if err := json.Unmarshal(raw, &price); err != nil {
+			return nil, fmt.Errorf("price: %w", err)
+		}
+		// Так же цены переводились в копейки миграцией 6_money
+		money, err := NewMoney(int64(math.Round(price*100)), DefaultCurrency)
+		if err != nil {
+			return nil, fmt.Errorf("price: %w", err)
+		}
+		if fields["price"], err = json.Marshal(money); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+func newMessageID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate message id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+type traceKey struct{}
+
+// ContextWithTrace сохраняет контекст трассировки для исходящих сообщений
+func ContextWithTrace(ctx context.Context, trace TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// TraceFromContext возвращает контекст трассировки, сохраненный ContextWithTrace
+func TraceFromContext(ctx context.Context) TraceContext {
+	trace, _ := ctx.Value(traceKey{}).(TraceContext)
+	return trace
+}
+
+// TraceFromHeader читает заголовки traceparent и tracestate
+func TraceFromHeader(h http.Header) TraceContext {
+	return TraceContext{
+		TraceParent: h.Get("traceparent"),
+		TraceState:  h.Get("tracestate"),
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMessageContracts проверяет, что каждое сообщение, которым обмениваются auth и products,
+// разбирается из зафиксированного примера и обратно сериализуется без потерь.
+// Пример лежит в testdata/contracts/<type>.v<version>.json.
+func TestMessageContracts(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "contracts", "*.json"))
+	require.NoError(t, err)
+	covered := make(map[string]bool)
+
+	for _, msgType := range MessageTypes() {
+		for _, version := range messageSchemas[msgType].versions {
+			name := fmt.Sprintf("%s.v%d.json", msgType, version)
+			covered[name] = true
+			t.Run(name, func(t *testing.T) {
+				data, err := os.ReadFile(filepath.Join("testdata", "contracts", name))
+				require.NoError(t, err, "contract fixture is missing")
+
+				payload, err := NewMessagePayload(msgType)
+				require.NoError(t, err)
+				env, err := DecodeMessage(data, msgType, payload)
+				require.NoError(t, err)
+				assert.Equal(t, version, env.Version)
+
+				encoded, err := json.Marshal(payload)
+				require.NoError(t, err)
+				assert.JSONEq(t, string(env.Payload), string(encoded))
+			})
+		}
+	}
+
+	for _, fixture := range fixtures {
+		assert.True(t, covered[filepath.Base(fixture)], "fixture %s has no registered message type", fixture)
+	}
+}
+
+func TestEncodeDecodeMessage(t *testing.T) {
+	trace := TraceContext{TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	data, err := EncodeMessage(MessageTypeTokenCheck, TokenCheckMessage{Token: "token"}, trace)
+	require.NoError(t, err)
+
+	var request TokenCheckMessage
+	env, err := DecodeMessage(data, MessageTypeTokenCheck, &request)
+	require.NoError(t, err)
+	assert.Equal(t, "token", request.Token)
+	assert.Equal(t, trace, env.Trace)
+	assert.Equal(t, 1, env.Version)
+	assert.NotEmpty(t, env.ID)
+	assert.False(t, env.Timestamp.IsZero())
+}
+
+func TestDecodeMessageErrors(t *testing.T) {
+	envelope := func(msgType string, version int, payload string) []byte {
+		return []byte(fmt.Sprintf(`{"type":%q,"version":%d,"id":"1","timestamp":"2024-11-01T10:00:00Z","payload":%s}`, msgType, version, payload))
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		msgType string
+		err     error
+	}{
+		{"not json", []byte("token"), MessageTypeTokenCheck, ErrInvalidEnvelope},
+		{"bare payload", []byte(`{"token":"abc"}`), MessageTypeTokenCheck, ErrInvalidEnvelope},
+		{"unknown type", envelope("auth.unknown", 1, `{}`), MessageTypeTokenCheck, ErrUnknownMessageType},
+		{"unknown version", envelope(MessageTypeTokenCheck, 2, `{"token":"abc"}`), MessageTypeTokenCheck, ErrUnsupportedVersion},
+		{"unexpected type", envelope(MessageTypeTokenCheckResult, 1, `{"valid":false,"user_id":-1,"error":"Invalid token"}`), MessageTypeTokenCheck, ErrUnexpectedMessage},
+		{"unknown field", envelope(MessageTypeTokenCheck, 1, `{"token":"abc","temp_queue":"temp_queue"}`), MessageTypeTokenCheck, ErrInvalidMessageValue},
+		{"invalid payload", envelope(MessageTypeTokenCheck, 1, `{"token":""}`), MessageTypeTokenCheck, ErrInvalidMessageValue},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := NewMessagePayload(tc.msgType)
+			require.NoError(t, err)
+			_, err = DecodeMessage(tc.data, tc.msgType, payload)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestDecodeLegacyProductChanged(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "contracts", "products.product.updated.v1.json"))
+	require.NoError(t, err)
+
+	var event ProductChangedEvent
+	env, err := DecodeMessage(data, EventProductUpdated, &event)
+	require.NoError(t, err)
+	assert.Equal(t, 1, env.Version)
+	assert.Equal(t, Money{Amount: 1250, Currency: DefaultCurrency}, event.Price)
+}
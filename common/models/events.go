@@ -0,0 +1,145 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Доменные события сервиса products. Публикуются через outbox после фиксации транзакции.
+const (
+	EventPurchaseCreated = "products.purchase.created"
+	EventOrderCreated    = "products.order.created"
+	EventOrderStatus     = "products.order.status_changed"
+	EventProductCreated  = "products.product.created"
+	EventProductUpdated  = "products.product.updated"
+	EventProductDeleted  = "products.product.deleted"
+	EventStockLow        = "products.stock.low"
+)
+
+// OutboxMessage - событие, записанное в outbox и ожидающее публикации
+type OutboxMessage struct {
+	ID        int64
+	EventType string
+	Message   []byte
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// PurchaseCreatedEvent больше не отправляется: покупки заменены заказами, см. OrderCreatedEvent.
+// Схема остается в реестре, пока ее не перестанут ждать потребители.
+type PurchaseCreatedEvent struct {
+	PurchaseID   int       `json:"purchase_id"`
+	UserID       int       `json:"user_id"`
+	ProductID    int       `json:"product_id"`
+	Quantity     int       `json:"quantity"`
+	PurchaseDate time.Time `json:"purchase_date"`
+	// VariantID появился во второй версии события
+	VariantID *int `json:"variant_id,omitempty"`
+}
+
+func (e PurchaseCreatedEvent) Validate() error {
+	if e.PurchaseID <= 0 || e.UserID <= 0 || e.ProductID <= 0 {
+		return errors.New("purchase_id, user_id and product_id are required")
+	}
+	if e.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	return nil
+}
+
+type OrderCreatedEvent struct {
+	OrderID   int              `json:"order_id"`
+	UserID    int              `json:"user_id"`
+	Items     []OrderEventItem `json:"items"`
+	Total     Money            `json:"total"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type OrderEventItem struct {
+	ProductID int   `json:"product_id"`
+	VariantID *int  `json:"variant_id,omitempty"`
+	Quantity  int   `json:"quantity"`
+	Price     Money `json:"price"`
+}
+
+func (e OrderCreatedEvent) Validate() error {
+	if e.OrderID <= 0 || e.UserID <= 0 {
+		return errors.New("order_id and user_id are required")
+	}
+	if len(e.Items) == 0 {
+		return errors.New("items are required")
+	}
+	for _, item := range e.Items {
+		if item.ProductID <= 0 || item.Quantity <= 0 {
+			return errors.New("item product_id and positive quantity are required")
+		}
+		if err := item.Price.Validate(); err != nil {
+			return err
+		}
+	}
+	return e.Total.Validate()
+}
+
+type OrderStatusChangedEvent struct {
+	OrderID   int       `json:"order_id"`
+	UserID    int       `json:"user_id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+func (e OrderStatusChangedEvent) Validate() error {
+	if e.OrderID <= 0 || e.UserID <= 0 {
+		return errors.New("order_id and user_id are required")
+	}
+	return CheckOrderTransition(e.From, e.To)
+}
+
+// ProductChangedEvent - состояние товара после создания или изменения
+type ProductChangedEvent struct {
+	ProductID   int    `json:"product_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       Money  `json:"price"`
+	Quantity    int    `json:"quantity"`
+}
+
+func (e ProductChangedEvent) Validate() error {
+	if e.ProductID <= 0 {
+		return errors.New("product_id is required")
+	}
+	return e.Price.Validate()
+}
+
+type ProductDeletedEvent struct {
+	ProductID int `json:"product_id"`
+}
+
+func (e ProductDeletedEvent) Validate() error {
+	if e.ProductID <= 0 {
+		return errors.New("product_id is required")
+	}
+	return nil
+}
+
+// StockLowEvent - заказ опустил доступный остаток позиции до порога дозаказа.
+// RestockRequestID - открытая заявка поставщику на пополнение этой позиции.
+type StockLowEvent struct {
+	ProductID        int       `json:"product_id"`
+	VariantID        *int      `json:"variant_id,omitempty"`
+	Available        int       `json:"available"`
+	Threshold        int       `json:"threshold"`
+	RestockRequestID int       `json:"restock_request_id"`
+	OrderID          int       `json:"order_id"`
+	OccurredAt       time.Time `json:"occurred_at"`
+}
+
+func (e StockLowEvent) Validate() error {
+	if e.ProductID <= 0 || e.RestockRequestID <= 0 {
+		return errors.New("product_id and restock_request_id are required")
+	}
+	if e.Threshold < 0 {
+		return errors.New("threshold must not be negative")
+	}
+	return nil
+}
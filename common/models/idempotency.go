@@ -0,0 +1,14 @@
+package models
+
+// IdempotencyKey - ключ идемпотентности запроса пользователя и хэш запроса, с которым он впервые пришел
+type IdempotencyKey struct {
+	UserID      int
+	Key         string
+	RequestHash string
+}
+
+// IdempotentResponse - сохраненный ответ, который повторяется для запросов с тем же ключом
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+}
@@ -1,12 +1,21 @@
 package models
 
-import "time"
+const (
+	RoleBuyer  = "buyer"
+	RoleSeller = "seller"
+	RoleAdmin  = "admin"
+)
 
 type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username" validate:"required,min=3,max=50"`
 	Password string `json:"password" validate:"required,min=8"`
 	Email    string `json:"email" validate:"required,email"`
+	Role     string `json:"role"`
+}
+
+type RoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=buyer seller admin"`
 }
 
 type Credentials struct {
@@ -14,26 +23,27 @@ type Credentials struct {
 	Password string `json:"password" validate:"required"`
 }
 
-type Product struct {
-	UID         int     `json:"uid"`
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description" validate:"required"`
-	Price       float64 `json:"price" validate:"required,min=1"`
-	Delete      bool    `json:"delete"`
-	Quantity    int     `json:"quantity" validate:"required,min=1"`
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
-type Purchase struct {
-	UID          int       `json:"uid"`
-	UserID       int       `json:"userID" validate:"required"`
-	ProductID    int       `json:"productID" validate:"required"`
-	Quantity     int       `json:"quantity" validate:"required"`
-	PurchaseDate time.Time `json:"purchase_date"`
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type Product struct {
+	UID         int    `json:"uid"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description" validate:"required"`
+	Price       Money  `json:"price"`
+	Delete      bool   `json:"delete"`
+	Quantity    int    `json:"quantity" validate:"required,min=1"`
 }
 
 /*{
 	"name": "apple",
 	"description": "red",
-	"price": 100,
+	"price": {"amount": "100.00", "currency": "RUB"},
 	"quantity": 10
-  }*/
\ No newline at end of file
+  }*/
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrNegativeMoney    = errors.New("money amount cannot be negative")
+	ErrMoneyOverflow    = errors.New("money amount overflow")
+	ErrInvalidAmount    = errors.New("invalid money amount")
+)
+
+// currencyExponents - число знаков дробной части для поддерживаемых валют ISO 4217
+var currencyExponents = map[string]int{
+	"RUB": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CNY": 2,
+	"KZT": 2,
+	"BYN": 2,
+	"JPY": 0,
+	"KWD": 3,
+}
+
+// DefaultCurrency - валюта цен, заведенных до появления валют
+const DefaultCurrency = "RUB"
+
+// Money - неотрицательная сумма в минимальных единицах валюты (копейках, центах).
+// В JSON сумма передается десятичной строкой, чтобы клиенты не теряли точность:
+// {"amount":"10.50","currency":"RUB"}.
+type Money struct {
+	Amount   int64  `validate:"min=0"`
+	Currency string `validate:"required,iso4217"`
+}
+
+// NewMoney создает сумму из минимальных единиц валюты
+func NewMoney(amount int64, currency string) (Money, error) {
+	m := Money{Amount: amount, Currency: currency}
+	if err := m.Validate(); err != nil {
+		return Money{}, err
+	}
+	return m, nil
+}
+
+// ParseMoney разбирает десятичную запись суммы, например "10.5" для 1050 копеек.
+// Знаков после точки не может быть больше, чем у валюты.
+func ParseMoney(amount, currency string) (Money, error) {
+	exp, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	whole, frac, hasFrac := strings.Cut(amount, ".")
+	if whole == "" || (hasFrac && frac == "") || len(frac) > exp {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	if strings.HasPrefix(whole, "-") {
+		return Money{}, ErrNegativeMoney
+	}
+	for _, part := range []string{whole, frac} {
+		if strings.Trim(part, "0123456789") != "" {
+			return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+		}
+	}
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+	value, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrMoneyOverflow, amount)
+	}
+	return Money{Amount: value, Currency: currency}, nil
+}
+
+func (m Money) Validate() error {
+	if _, ok := currencyExponents[m.Currency]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownCurrency, m.Currency)
+	}
+	if m.Amount < 0 {
+		return ErrNegativeMoney
+	}
+	return nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add складывает суммы одной валюты
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	if m.Amount > math.MaxInt64-other.Amount {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Sub вычитает сумму той же валюты. Отрицательный результат считается ошибкой.
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	if other.Amount > m.Amount {
+		return Money{}, ErrNegativeMoney
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}, nil
+}
+
+// Mul умножает сумму на количество
+func (m Money) Mul(quantity int64) (Money, error) {
+	if quantity < 0 {
+		return Money{}, ErrNegativeMoney
+	}
+	if quantity != 0 && m.Amount > math.MaxInt64/quantity {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}, nil
+}
+
+// Cmp сравнивает суммы одной валюты: -1, если m меньше, 0 при равенстве, 1, если больше
+func (m Money) Cmp(other Money) (int, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+// Decimal возвращает сумму в основных единицах валюты, например "10.50"
+func (m Money) Decimal() string {
+	exp := currencyExponents[m.Currency]
+	digits := strconv.FormatInt(m.Amount, 10)
+	if exp == 0 {
+		return digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   json.Number `json:"amount"`
+	Currency string      `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON принимает сумму строкой или числом, но всегда разбирает ее точно, без float64
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	parsed, err := ParseMoney(raw.Amount.String(), raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
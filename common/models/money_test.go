@@ -0,0 +1,122 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     Money
+		err      error
+	}{
+		{"10", "RUB", Money{Amount: 1000, Currency: "RUB"}, nil},
+		{"10.5", "RUB", Money{Amount: 1050, Currency: "RUB"}, nil},
+		{"0.01", "USD", Money{Amount: 1, Currency: "USD"}, nil},
+		{"150", "JPY", Money{Amount: 150, Currency: "JPY"}, nil},
+		{"1.234", "KWD", Money{Amount: 1234, Currency: "KWD"}, nil},
+		{"10.555", "RUB", Money{}, ErrInvalidAmount},
+		{"1.5", "JPY", Money{}, ErrInvalidAmount},
+		{"1e3", "RUB", Money{}, ErrInvalidAmount},
+		{"10.", "RUB", Money{}, ErrInvalidAmount},
+		{"-1", "RUB", Money{}, ErrNegativeMoney},
+		{"99999999999999999999", "RUB", Money{}, ErrMoneyOverflow},
+		{"10", "XXX", Money{}, ErrUnknownCurrency},
+	}
+	for _, tc := range tests {
+		t.Run(tc.amount+" "+tc.currency, func(t *testing.T) {
+			m, err := ParseMoney(tc.amount, tc.currency)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, m)
+		})
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	assert.Equal(t, "10.50", Money{Amount: 1050, Currency: "RUB"}.Decimal())
+	assert.Equal(t, "0.05", Money{Amount: 5, Currency: "USD"}.Decimal())
+	assert.Equal(t, "0.00", Money{Currency: "EUR"}.Decimal())
+	assert.Equal(t, "150", Money{Amount: 150, Currency: "JPY"}.Decimal())
+	assert.Equal(t, "0.007", Money{Amount: 7, Currency: "KWD"}.Decimal())
+	assert.Equal(t, "12.30 RUB", Money{Amount: 1230, Currency: "RUB"}.String())
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	rub := func(amount int64) Money { return Money{Amount: amount, Currency: "RUB"} }
+
+	sum, err := rub(1050).Add(rub(250))
+	require.NoError(t, err)
+	assert.Equal(t, rub(1300), sum)
+
+	diff, err := rub(1050).Sub(rub(50))
+	require.NoError(t, err)
+	assert.Equal(t, rub(1000), diff)
+
+	total, err := rub(1999).Mul(3)
+	require.NoError(t, err)
+	assert.Equal(t, rub(5997), total)
+
+	cmp, err := rub(1).Cmp(rub(2))
+	require.NoError(t, err)
+	assert.Equal(t, -1, cmp)
+
+	_, err = rub(100).Add(Money{Amount: 100, Currency: "USD"})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = rub(100).Cmp(Money{Amount: 100, Currency: "USD"})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = rub(100).Sub(rub(101))
+	assert.ErrorIs(t, err, ErrNegativeMoney)
+	_, err = rub(100).Mul(-1)
+	assert.ErrorIs(t, err, ErrNegativeMoney)
+	_, err = rub(math.MaxInt64).Add(rub(1))
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+	_, err = rub(math.MaxInt64 / 2).Mul(3)
+	assert.ErrorIs(t, err, ErrMoneyOverflow)
+
+	_, err = NewMoney(-5, "RUB")
+	assert.ErrorIs(t, err, ErrNegativeMoney)
+	_, err = NewMoney(5, "rub")
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(Money{Amount: 1050, Currency: "RUB"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"10.50","currency":"RUB"}`, string(data))
+
+	tests := []struct {
+		name string
+		data string
+		want Money
+		err  error
+	}{
+		{"string amount", `{"amount":"10.50","currency":"RUB"}`, Money{Amount: 1050, Currency: "RUB"}, nil},
+		{"number amount", `{"amount":0.1,"currency":"USD"}`, Money{Amount: 10, Currency: "USD"}, nil},
+		{"bare number", `10.5`, Money{}, ErrInvalidAmount},
+		{"unknown field", `{"amount":"1","currency":"RUB","rate":2}`, Money{}, ErrInvalidAmount},
+		{"missing currency", `{"amount":"1"}`, Money{}, ErrUnknownCurrency},
+		{"negative", `{"amount":"-1","currency":"RUB"}`, Money{}, ErrNegativeMoney},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(tc.data), &m)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, m)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Статусы заказа
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
+)
+
+var ErrInvalidOrderTransition = errors.New("order status transition is not allowed")
+
+// orderTransitions - допустимые переходы между статусами заказа.
+// cancelled и refunded конечные: остатки по ним уже возвращены.
+var orderTransitions = map[string][]string{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+	OrderStatusCancelled: {},
+	OrderStatusRefunded:  {},
+}
+
+// CheckOrderTransition возвращает ErrInvalidOrderTransition, если заказ нельзя перевести из from в to
+func CheckOrderTransition(from, to string) error {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidOrderTransition, from, to)
+}
+
+// RestocksOrder сообщает, возвращаются ли остатки при переходе заказа в статус
+func RestocksOrder(status string) bool {
+	return status == OrderStatusCancelled || status == OrderStatusRefunded
+}
+
+// OrderLine - строка оформляемого заказа
+type OrderLine struct {
+	ProductID int  `json:"productID" validate:"required,min=1"`
+	VariantID *int `json:"variantID,omitempty" validate:"omitempty,min=1"`
+	Quantity  int  `json:"quantity" validate:"required,min=1"`
+}
+
+// OrderRequest - тело запроса на оформление заказа
+type OrderRequest struct {
+	Items []OrderLine `json:"items" validate:"required,min=1,max=100,dive"`
+	// Region - регион доставки для стратегии выбора склада region
+	Region string `json:"region" validate:"max=64"`
+}
+
+// OrderItem - строка оформленного заказа с ценой на момент оформления
+type OrderItem struct {
+	UID       int   `json:"uid"`
+	ProductID int   `json:"productID"`
+	VariantID *int  `json:"variantID,omitempty"`
+	Quantity  int   `json:"quantity"`
+	Price     Money `json:"price"`
+	Total     Money `json:"total"`
+}
+
+// OrderStatusChange - запись истории статусов заказа. У первой записи From пустой.
+type OrderStatusChange struct {
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// OrderStatusRequest - тело запроса на смену статуса заказа.
+// paid и refunded выставляются только через платежи, чтобы у каждого оплаченного
+// или возвращенного заказа был платеж у провайдера.
+type OrderStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=shipped delivered cancelled"`
+}
+
+type Order struct {
+	UID     int                 `json:"uid"`
+	UserID  int                 `json:"userID"`
+	Status  string              `json:"status"`
+	Items   []OrderItem         `json:"items"`
+	Total   Money               `json:"total"`
+	History []OrderStatusChange `json:"history"`
+	// ReservedUntil - до какого момента за неоплаченным заказом держатся остатки
+	ReservedUntil *time.Time `json:"reserved_until,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+/*{
+	"items": [
+		{"productID": 1, "quantity": 2},
+		{"productID": 3, "variantID": 5, "quantity": 1}
+	]
+  }*/
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOrderTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{OrderStatusPending, OrderStatusPaid, true},
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusPending, OrderStatusShipped, false},
+		{OrderStatusPaid, OrderStatusShipped, true},
+		{OrderStatusPaid, OrderStatusRefunded, true},
+		{OrderStatusPaid, OrderStatusCancelled, false},
+		{OrderStatusShipped, OrderStatusDelivered, true},
+		{OrderStatusDelivered, OrderStatusRefunded, true},
+		{OrderStatusCancelled, OrderStatusPending, false},
+		{OrderStatusRefunded, OrderStatusPaid, false},
+		{OrderStatusPaid, OrderStatusPaid, false},
+		{"unknown", OrderStatusPaid, false},
+	}
+	for _, tc := range tests {
+		err := CheckOrderTransition(tc.from, tc.to)
+		if tc.allowed {
+			assert.NoError(t, err, "%s -> %s", tc.from, tc.to)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidOrderTransition, "%s -> %s", tc.from, tc.to)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Статусы платежа
+const (
+	PaymentStatusPending        = "pending"
+	PaymentStatusRequiresAction = "requires_action"
+	PaymentStatusAuthorized     = "authorized"
+	PaymentStatusCaptured       = "captured"
+	PaymentStatusFailed         = "failed"
+	// PaymentStatusRefunding - возврат запрошен у провайдера, но ответ еще не сохранен
+	PaymentStatusRefunding = "refunding"
+	PaymentStatusRefunded  = "refunded"
+)
+
+var ErrInvalidPaymentTransition = errors.New("payment status transition is not allowed")
+
+// paymentTransitions - допустимые переходы между статусами платежа
+var paymentTransitions = map[string][]string{
+	PaymentStatusPending:        {PaymentStatusRequiresAction, PaymentStatusAuthorized, PaymentStatusFailed},
+	PaymentStatusRequiresAction: {PaymentStatusAuthorized, PaymentStatusFailed},
+	PaymentStatusAuthorized:     {PaymentStatusCaptured, PaymentStatusFailed},
+	PaymentStatusCaptured:       {PaymentStatusRefunding},
+	// Неудачный возврат оставляет платеж списанным, и возврат можно повторить
+	PaymentStatusRefunding: {PaymentStatusRefunded, PaymentStatusCaptured},
+	PaymentStatusFailed:    {},
+	PaymentStatusRefunded:  {},
+}
+
+// CheckPaymentTransition возвращает ErrInvalidPaymentTransition, если платеж нельзя перевести из from в to
+func CheckPaymentTransition(from, to string) error {
+	for _, next := range paymentTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidPaymentTransition, from, to)
+}
+
+// Payment - оплата заказа через платежного провайдера
+type Payment struct {
+	UID               int    `json:"uid"`
+	OrderID           int    `json:"orderID"`
+	Provider          string `json:"provider"`
+	ProviderPaymentID string `json:"provider_payment_id,omitempty"`
+	Status            string `json:"status"`
+	Amount            Money  `json:"amount"`
+	FailureReason     string `json:"failure_reason,omitempty"`
+	// ActionURL - адрес, по которому покупатель проходит проверку 3-D Secure
+	ActionURL string    `json:"action_url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PaymentRequest - тело запроса на оплату заказа. Token выдает платежная форма провайдера.
+type PaymentRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPaymentTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{PaymentStatusPending, PaymentStatusAuthorized, true},
+		{PaymentStatusPending, PaymentStatusRequiresAction, true},
+		{PaymentStatusPending, PaymentStatusFailed, true},
+		{PaymentStatusPending, PaymentStatusCaptured, false},
+		{PaymentStatusRequiresAction, PaymentStatusAuthorized, true},
+		{PaymentStatusAuthorized, PaymentStatusCaptured, true},
+		{PaymentStatusCaptured, PaymentStatusRefunding, true},
+		{PaymentStatusCaptured, PaymentStatusRefunded, false},
+		{PaymentStatusRefunding, PaymentStatusRefunded, true},
+		{PaymentStatusRefunding, PaymentStatusCaptured, true},
+		{PaymentStatusCaptured, PaymentStatusFailed, false},
+		{PaymentStatusFailed, PaymentStatusAuthorized, false},
+		{PaymentStatusRefunded, PaymentStatusCaptured, false},
+		{"unknown", PaymentStatusPending, false},
+	}
+	for _, tc := range tests {
+		err := CheckPaymentTransition(tc.from, tc.to)
+		if tc.allowed {
+			assert.NoError(t, err, "%s -> %s", tc.from, tc.to)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidPaymentTransition, "%s -> %s", tc.from, tc.to)
+		}
+	}
+}
@@ -1,14 +1,44 @@
 package models
 
-// Структура сообщения для получения токена
+import "errors"
+
+// UserCheckQueue - очередь запросов проверки токена в сервис auth
+const UserCheckQueue = "user_check_queue"
+
+// Структура сообщения для получения токена.
+// Ответ приходит в очередь из ReplyTo запроса.
 type TokenCheckMessage struct {
-    Token    string `json:"token"`
-    TempQueue string `json:"temp_queue"` // Имя временной очереди для отправки результата
+	Token string `json:"token"`
+}
+
+func (m TokenCheckMessage) Validate() error {
+	if m.Token == "" {
+		return errors.New("token is required")
+	}
+	return nil
 }
 
+// Ошибки в ответе на проверку токена
+const (
+	TokenCheckInvalidToken   = "Invalid token"
+	TokenCheckSessionRevoked = "Session revoked"
+	TokenCheckFailed         = "Failed to check session"
+)
+
 // Структура ответа
 type TokenCheckResponse struct {
-    Valid  bool   `json:"valid"`
-    UserID int    `json:"user_id"`
-    Error  string `json:"error"`
-}
\ No newline at end of file
+	Valid  bool   `json:"valid"`
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+	Error  string `json:"error"`
+}
+
+func (r TokenCheckResponse) Validate() error {
+	if r.Valid && r.UserID <= 0 {
+		return errors.New("valid response must contain user_id")
+	}
+	if !r.Valid && r.Error == "" {
+		return errors.New("invalid response must contain error")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+// Причины движения остатков
+const (
+	StockReasonRestock     = "restock"
+	StockReasonSale        = "sale"
+	StockReasonReturn      = "return"
+	StockReasonAdjustment  = "adjustment"
+	StockReasonReservation = "reservation"
+	StockReasonTransfer    = "transfer"
+)
+
+// StockMovement - запись журнала остатков. Журнал только дополняется:
+// сумма Delta по позиции на складе равна ее quantity на этом складе, сумма ReservedDelta - ее резерву.
+type StockMovement struct {
+	UID         int  `json:"uid"`
+	WarehouseID int  `json:"warehouseID"`
+	ProductID   int  `json:"productID"`
+	VariantID   *int `json:"variantID,omitempty"`
+	// Delta - изменение остатка на складе
+	Delta int `json:"delta"`
+	// ReservedDelta - изменение зарезервированного под заказы количества
+	ReservedDelta int    `json:"reserved_delta"`
+	Reason        string `json:"reason"`
+	// ActorID - пользователь, который изменил остаток; пусто, если изменение сделал сервис
+	ActorID *int `json:"actorID,omitempty"`
+	OrderID *int `json:"orderID,omitempty"`
+	// TransferID - перемещение между складами, частью которого является движение
+	TransferID *int      `json:"transferID,omitempty"`
+	Comment    string    `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StockAdjustment - тело запроса на ручное изменение остатка.
+// Без WarehouseID остаток меняется на складе по умолчанию. Delta прихода (restock) только положительная.
+type StockAdjustment struct {
+	WarehouseID *int   `json:"warehouseID" validate:"omitempty,min=1"`
+	VariantID   *int   `json:"variantID"`
+	Delta       int    `json:"delta" validate:"required"`
+	Reason      string `json:"reason" validate:"required,oneof=restock adjustment"`
+	Comment     string `json:"comment" validate:"max=255"`
+}
+
+// StockLevel - остаток товара или варианта на складе, восстановленный по журналу
+type StockLevel struct {
+	WarehouseID int  `json:"warehouseID"`
+	VariantID   *int `json:"variantID,omitempty"`
+	Quantity    int  `json:"quantity"`
+	Reserved    int  `json:"reserved"`
+	Available   int  `json:"available"`
+}
+
+// StockReport - остатки товара на момент At
+type StockReport struct {
+	ProductID int          `json:"productID"`
+	At        time.Time    `json:"at"`
+	Levels    []StockLevel `json:"levels"`
+}
+
+// Статусы заявок поставщику на пополнение остатка
+const (
+	RestockStatusOpen      = "open"
+	RestockStatusFulfilled = "fulfilled"
+)
+
+// ReorderPolicy - порог дозаказа товара. Порог относится к каждой позиции товара:
+// к самому товару или к каждому его варианту.
+type ReorderPolicy struct {
+	// Threshold - доступный остаток, при котором позиция считается заканчивающейся; пусто отключает дозаказ
+	Threshold *int `json:"threshold" validate:"omitempty,min=0"`
+	// Quantity - сколько заказывать у поставщика
+	Quantity int `json:"quantity" validate:"required_with=Threshold,omitempty,min=1"`
+}
+
+// LowStockItem - позиция, доступный остаток которой не выше порога дозаказа
+type LowStockItem struct {
+	ProductID       int    `json:"productID"`
+	VariantID       *int   `json:"variantID,omitempty"`
+	Name            string `json:"name"`
+	SKU             string `json:"sku,omitempty"`
+	Available       int    `json:"available"`
+	Threshold       int    `json:"threshold"`
+	ReorderQuantity int    `json:"reorderQuantity"`
+	// RestockRequestID - открытая заявка поставщику, если она уже создана
+	RestockRequestID *int `json:"restockRequestID,omitempty"`
+}
+
+// RestockRequest - заявка поставщику на пополнение позиции. Создается, когда заказ
+// опускает остаток до порога, и выполняется приходом, поднявшим остаток выше порога.
+type RestockRequest struct {
+	UID         int        `json:"uid"`
+	ProductID   int        `json:"productID"`
+	VariantID   *int       `json:"variantID,omitempty"`
+	Quantity    int        `json:"quantity"`
+	Status      string     `json:"status"`
+	OrderID     *int       `json:"orderID,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FulfilledAt *time.Time `json:"fulfilled_at,omitempty"`
+}
+
+// IsRestockStatus проверяет, что status - известный статус заявки поставщику
+func IsRestockStatus(status string) bool {
+	return status == RestockStatusOpen || status == RestockStatusFulfilled
+}
@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Стратегии выбора склада при резервировании заказа
+const (
+	// AllocationPriority - сначала склады с большим приоритетом
+	AllocationPriority = "priority"
+	// AllocationRegion - сначала склады из региона покупателя, затем по приоритету
+	AllocationRegion = "region"
+	// AllocationLargest - сначала склады с большим доступным остатком
+	AllocationLargest = "largest"
+)
+
+// Warehouse - склад. Неактивный склад хранит остатки, но не участвует в резервировании заказов.
+type Warehouse struct {
+	UID    int    `json:"uid"`
+	Name   string `json:"name" validate:"required,max=100"`
+	Region string `json:"region" validate:"max=64"`
+	// Priority - чем больше, тем раньше склад выбирается стратегией priority
+	Priority  int       `json:"priority"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WarehouseAvailability - остаток товара или варианта на одном складе
+type WarehouseAvailability struct {
+	WarehouseID int    `json:"warehouseID"`
+	Name        string `json:"name"`
+	Region      string `json:"region,omitempty"`
+	Active      bool   `json:"active"`
+	VariantID   *int   `json:"variantID,omitempty"`
+	Quantity    int    `json:"quantity"`
+	Reserved    int    `json:"reserved"`
+	Available   int    `json:"available"`
+}
+
+// Availability - общий остаток товара и его разбивка по складам.
+// Available учитывает только активные склады: остаток неактивных нельзя купить.
+type Availability struct {
+	Quantity   int                     `json:"quantity"`
+	Reserved   int                     `json:"reserved"`
+	Available  int                     `json:"available"`
+	Warehouses []WarehouseAvailability `json:"warehouses"`
+}
+
+// StockTransferRequest - тело запроса на перемещение остатка между складами
+type StockTransferRequest struct {
+	FromWarehouseID int    `json:"fromWarehouseID" validate:"required,min=1"`
+	ToWarehouseID   int    `json:"toWarehouseID" validate:"required,min=1,nefield=FromWarehouseID"`
+	ProductID       int    `json:"productID" validate:"required,min=1"`
+	VariantID       *int   `json:"variantID,omitempty" validate:"omitempty,min=1"`
+	Quantity        int    `json:"quantity" validate:"required,min=1"`
+	Comment         string `json:"comment" validate:"max=255"`
+}
+
+// StockTransfer - перемещение остатка между складами. В журнале остатков ему соответствуют
+// два движения transfer: списание со склада-источника и поступление на склад-получатель.
+type StockTransfer struct {
+	UID             int       `json:"uid"`
+	FromWarehouseID int       `json:"fromWarehouseID"`
+	ToWarehouseID   int       `json:"toWarehouseID"`
+	ProductID       int       `json:"productID"`
+	VariantID       *int      `json:"variantID,omitempty"`
+	Quantity        int       `json:"quantity"`
+	ActorID         *int      `json:"actorID,omitempty"`
+	Comment         string    `json:"comment,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// IsAllocationStrategy сообщает, поддерживается ли стратегия выбора склада
+func IsAllocationStrategy(strategy string) bool {
+	switch strategy {
+	case AllocationPriority, AllocationRegion, AllocationLargest:
+		return true
+	}
+	return false
+}
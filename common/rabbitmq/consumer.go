@@ -1,26 +1,192 @@
 package rabbitmq
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"sync"
+	"time"
 
 	amqp "github.com/streadway/amqp"
+	"github.com/wileytor/go-market/common/broker"
 )
 
-func (r *RabbitMQ) ConsumeMessage(queueName string, handler func(amqp.Delivery)) error {
-	msgs, err := r.Channel.Consume(
+const (
+	// DeadLetterExchange принимает сообщения, которые не удалось обработать после всех повторов
+	DeadLetterExchange = "dead_letters"
+
+	republishTimeout = 5 * time.Second
+)
+
+func RetryQueueName(queueName string) string {
+	return queueName + ".retry"
+}
+
+func DeadLetterQueueName(queueName string) string {
+	return queueName + ".dlq"
+}
+
+// Consume обрабатывает сообщения из очереди, пока клиент не будет закрыт.
+// Сообщение подтверждается после успешной обработки. При ошибке оно откладывается в очередь
+// повторов, а после MaxRetries неудач уходит в DeadLetterExchange.
+// После переподключения потребитель запускается заново на новом соединении.
+func (r *RabbitMQ) Consume(queueName string, opts broker.ConsumerOptions, handler broker.Handler) error {
+	opts = opts.WithDefaults()
+	if err := r.declareRetryTopology(queueName, opts); err != nil {
+		return err
+	}
+
+	conn, err := r.connection()
+	if err != nil {
+		return err
+	}
+	for {
+		if err := r.consume(conn, queueName, opts, handler); err != nil {
+			return err
+		}
+		if !conn.IsClosed() {
+			// закрылся только канал - подписываемся заново на том же соединении
+			time.Sleep(minReconnectDelay)
+			if _, err := r.connection(); errors.Is(err, broker.ErrClosed) {
+				return nil
+			}
+			continue
+		}
+		conn, err = r.nextConnection(conn)
+		if errors.Is(err, broker.ErrClosed) {
+			return nil
+		}
+	}
+}
+
+// declareRetryTopology объявляет очередь повторов и очередь недоставленных сообщений.
+// Из очереди повторов сообщение по истечении TTL возвращается в исходную очередь.
+func (r *RabbitMQ) declareRetryTopology(queueName string, opts broker.ConsumerOptions) error {
+	retry := Queue{
+		Name:    RetryQueueName(queueName),
+		Durable: true,
+		Args: amqp.Table{
+			"x-message-ttl":             int64(opts.RetryDelay / time.Millisecond),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		},
+	}
+	if err := r.DeclareQueue(retry); err != nil {
+		return err
+	}
+	if err := r.DeclareExchange(Exchange{Name: DeadLetterExchange, Kind: amqp.ExchangeDirect, Durable: true}); err != nil {
+		return err
+	}
+	if err := r.DeclareQueue(Queue{Name: DeadLetterQueueName(queueName), Durable: true}); err != nil {
+		return err
+	}
+	return r.BindQueue(Binding{Queue: DeadLetterQueueName(queueName), Exchange: DeadLetterExchange, Key: queueName})
+}
+
+// consume читает очередь на отдельном канале, пока канал не закроется
+func (r *RabbitMQ) consume(conn *amqp.Connection, queueName string, opts broker.ConsumerOptions, handler broker.Handler) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		if conn.IsClosed() {
+			return nil
+		}
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer ch.Close()
+
+	if err := ch.Qos(opts.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+	msgs, err := ch.Consume(
 		queueName,
 		"",    //consumer tag,
-		true,  // auto-ack
+		false, // auto-ack
 		false, // exclusive
 		false, // no-local
 		false, // no-wait
 		nil,   // аргументы
 	)
 	if err != nil {
+		if conn.IsClosed() {
+			return nil
+		}
 		return fmt.Errorf("failed to consume messages from queue: %w", err)
 	}
-	for msg := range msgs {
-		handler(msg)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range msgs {
+				r.process(queueName, opts, handler, msg)
+			}
+		}()
 	}
+	wg.Wait()
 	return nil
 }
+
+func (r *RabbitMQ) process(queueName string, opts broker.ConsumerOptions, handler broker.Handler, msg amqp.Delivery) {
+	err := broker.SafeHandle(handler, toMessage(msg))
+	if err == nil {
+		if err := msg.Ack(false); err != nil {
+			log.Printf("failed to ack message from %s: %v", queueName, err)
+		}
+		return
+	}
+
+	attempts := broker.RetryCount(msg.Headers)
+	exchange, key := "", RetryQueueName(queueName)
+	if broker.ShouldDeadLetter(err, attempts, opts) {
+		exchange, key = DeadLetterExchange, queueName
+		log.Printf("message from %s dead-lettered after %d retries: %v", queueName, attempts, err)
+	} else {
+		log.Printf("message from %s failed, retry %d of %d: %v", queueName, attempts+1, opts.MaxRetries, err)
+	}
+
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[broker.RetryCountHeader] = int64(attempts + 1)
+	headers[broker.LastErrorHeader] = err.Error()
+
+	ctx, cancel := context.WithTimeout(context.Background(), republishTimeout)
+	defer cancel()
+	err = r.PublishConfirmedTo(ctx, exchange, key, amqp.Publishing{
+		Headers:       headers,
+		ContentType:   msg.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: msg.CorrelationId,
+		ReplyTo:       msg.ReplyTo,
+		MessageId:     msg.MessageId,
+		Timestamp:     msg.Timestamp,
+		Type:          msg.Type,
+		Body:          msg.Body,
+	})
+	if err != nil {
+		// не удалось отложить сообщение - пусть брокер вернет его в очередь
+		log.Printf("failed to republish message from %s: %v", queueName, err)
+		if err := msg.Nack(false, true); err != nil {
+			log.Printf("failed to nack message from %s: %v", queueName, err)
+		}
+		return
+	}
+	if err := msg.Ack(false); err != nil {
+		log.Printf("failed to ack message from %s: %v", queueName, err)
+	}
+}
+
+func toMessage(d amqp.Delivery) broker.Message {
+	return broker.Message{
+		ID:            d.MessageId,
+		CorrelationID: d.CorrelationId,
+		ReplyTo:       d.ReplyTo,
+		ContentType:   d.ContentType,
+		Headers:       d.Headers,
+		Timestamp:     d.Timestamp,
+		Body:          d.Body,
+	}
+}
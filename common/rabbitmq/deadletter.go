@@ -0,0 +1,112 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	amqp "github.com/streadway/amqp"
+	"github.com/wileytor/go-market/common/broker"
+)
+
+// PeekDeadLetters возвращает до limit сообщений из очереди недоставленных для queueName.
+// Сообщения остаются в очереди.
+func (r *RabbitMQ) PeekDeadLetters(queueName string, limit int) ([]broker.DeadLetter, error) {
+	ch, err := r.deadLetterChannel(queueName)
+	if err != nil {
+		return nil, err
+	}
+	// неподтвержденные сообщения вернутся в очередь при закрытии канала
+	defer ch.Close()
+
+	letters := make([]broker.DeadLetter, 0)
+	for len(letters) < limit {
+		msg, ok, err := ch.Get(DeadLetterQueueName(queueName), false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+		lastError, _ := msg.Headers[broker.LastErrorHeader].(string)
+		letters = append(letters, broker.DeadLetter{
+			MessageID:     msg.MessageId,
+			CorrelationID: msg.CorrelationId,
+			Timestamp:     msg.Timestamp,
+			Attempts:      broker.RetryCount(msg.Headers),
+			LastError:     lastError,
+			Body:          string(msg.Body),
+		})
+	}
+	return letters, nil
+}
+
+// ReplayDeadLetters возвращает до limit сообщений из очереди недоставленных в исходную очередь
+// со сброшенным счетчиком повторов. Возвращает количество перенесенных сообщений.
+func (r *RabbitMQ) ReplayDeadLetters(ctx context.Context, queueName string, limit int) (int, error) {
+	ch, err := r.deadLetterChannel(queueName)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	replayed := 0
+	for replayed < limit {
+		msg, ok, err := ch.Get(DeadLetterQueueName(queueName), false)
+		if err != nil {
+			return replayed, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		headers := amqp.Table{}
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		delete(headers, broker.RetryCountHeader)
+		delete(headers, broker.LastErrorHeader)
+
+		err = r.PublishConfirmedTo(ctx, "", queueName, amqp.Publishing{
+			Headers:       headers,
+			ContentType:   msg.ContentType,
+			DeliveryMode:  amqp.Persistent,
+			CorrelationId: msg.CorrelationId,
+			ReplyTo:       msg.ReplyTo,
+			MessageId:     msg.MessageId,
+			Timestamp:     msg.Timestamp,
+			Type:          msg.Type,
+			Body:          msg.Body,
+		})
+		if err != nil {
+			msg.Nack(false, true)
+			return replayed, err
+		}
+		if err := msg.Ack(false); err != nil {
+			return replayed, fmt.Errorf("failed to ack dead letter: %w", err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// deadLetterChannel открывает отдельный канал и проверяет, что очередь недоставленных существует
+func (r *RabbitMQ) deadLetterChannel(queueName string) (*amqp.Channel, error) {
+	conn, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	if _, err := ch.QueueDeclarePassive(DeadLetterQueueName(queueName), true, false, false, false, nil); err != nil {
+		// брокер закрывает канал после неудачной пассивной проверки
+		var amqpErr *amqp.Error
+		if errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+			return nil, fmt.Errorf("%w: %s", broker.ErrQueueNotFound, DeadLetterQueueName(queueName))
+		}
+		return nil, fmt.Errorf("failed to inspect dead letter queue: %w", err)
+	}
+	return ch, nil
+}
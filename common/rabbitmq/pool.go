@@ -0,0 +1,112 @@
+package rabbitmq
+
+import (
+	"sync"
+
+	amqp "github.com/streadway/amqp"
+)
+
+const maxIdleChannels = 8
+
+// channelPool раздает каналы текущего соединения.
+// Канал не разделяется между горутинами: его берут на время операции и возвращают.
+type channelPool struct {
+	r       *RabbitMQ
+	confirm bool
+	mu      sync.Mutex
+	idle    []pooledChannel
+}
+
+type pooledChannel struct {
+	ch   *amqp.Channel
+	conn *amqp.Connection
+	// заполняются только для каналов в режиме подтверждений
+	confirms chan amqp.Confirmation
+	returns  chan amqp.Return
+}
+
+// newChannelPool создает пул каналов. Если confirm = true, каналы переводятся в режим подтверждений.
+func newChannelPool(r *RabbitMQ, confirm bool) *channelPool {
+	return &channelPool{r: r, confirm: confirm}
+}
+
+func (p *channelPool) get() (pooledChannel, error) {
+	conn, err := p.r.connection()
+	if err != nil {
+		return pooledChannel{}, err
+	}
+
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		pc := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if pc.conn == conn {
+			p.mu.Unlock()
+			return pc, nil
+		}
+		// канал от старого соединения
+		pc.ch.Close()
+	}
+	p.mu.Unlock()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return pooledChannel{}, err
+	}
+	pc := pooledChannel{ch: ch, conn: conn}
+	if p.confirm {
+		if err := ch.Confirm(false); err != nil {
+			ch.Close()
+			return pooledChannel{}, err
+		}
+		pc.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+		pc.returns = ch.NotifyReturn(make(chan amqp.Return, 1))
+	}
+	return pc, nil
+}
+
+// put возвращает канал в пул. После ошибки канал мог быть закрыт брокером, поэтому он не переиспользуется.
+func (p *channelPool) put(pc pooledChannel, opErr error) {
+	conn, err := p.r.connection()
+	if opErr != nil || err != nil || pc.conn != conn {
+		pc.ch.Close()
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.idle) >= maxIdleChannels {
+		pc.ch.Close()
+		return
+	}
+	p.idle = append(p.idle, pc)
+}
+
+// reset закрывает все свободные каналы
+func (p *channelPool) reset() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, pc := range idle {
+		pc.ch.Close()
+	}
+}
+
+// withChannel выполняет fn на канале из пула
+func (r *RabbitMQ) withChannel(fn func(*amqp.Channel) error) error {
+	return r.pool.with(func(pc pooledChannel) error {
+		return fn(pc.ch)
+	})
+}
+
+func (p *channelPool) with(fn func(pooledChannel) error) error {
+	pc, err := p.get()
+	if err != nil {
+		return err
+	}
+	err = fn(pc)
+	p.put(pc, err)
+	return err
+}
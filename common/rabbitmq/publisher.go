@@ -1,17 +1,82 @@
 package rabbitmq
 
-import amqp "github.com/streadway/amqp"
-
-func (r *RabbitMQ) PublishMessage(queueName string, message []byte)error {
-	err := r.Channel.Publish(
-		"", // default exchange
-		queueName,
-		false, // mandatory,  если true, то сообщение не будет утеряно, если очередь не найдена.
-		false, // immediate, если true, сообщение отправляется только если есть потребители, готовые принять.
-		amqp.Publishing{
-            ContentType: "application/json",
-            Body:        message,
-        },
-	)
-	return err
-}
\ No newline at end of file
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/streadway/amqp"
+	"github.com/wileytor/go-market/common/broker"
+)
+
+func (r *RabbitMQ) publish(queueName string, msg amqp.Publishing) error {
+	return r.withChannel(func(ch *amqp.Channel) error {
+		return ch.Publish(
+			"", // default exchange
+			queueName,
+			false, // mandatory,  если true, то сообщение не будет утеряно, если очередь не найдена.
+			false, // immediate, если true, сообщение отправляется только если есть потребители, готовые принять.
+			msg,
+		)
+	})
+}
+
+// Publish публикует сообщение с mandatory = true и ждет подтверждения брокера.
+// Ошибка nil означает, что сообщение доставлено в очередь и брокер за него отвечает.
+func (r *RabbitMQ) Publish(ctx context.Context, queueName string, message []byte) error {
+	return r.PublishConfirmedTo(ctx, "", queueName, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Body:         message,
+	})
+}
+
+// PublishEvent публикует событие в EventsExchange с ключом маршрутизации eventType и ждет подтверждения.
+// mandatory = false: у события может не быть ни одного подписчика, и это не ошибка публикации.
+func (r *RabbitMQ) PublishEvent(ctx context.Context, eventType string, message []byte) error {
+	return r.publishConfirmed(ctx, EventsExchange.Name, eventType, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Type:         eventType,
+		Body:         message,
+	})
+}
+
+// PublishConfirmedTo публикует сообщение в exchange в режиме подтверждений
+func (r *RabbitMQ) PublishConfirmedTo(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	return r.publishConfirmed(ctx, exchange, routingKey, true, msg)
+}
+
+func (r *RabbitMQ) publishConfirmed(ctx context.Context, exchange, routingKey string, mandatory bool, msg amqp.Publishing) error {
+	var result error
+	err := r.confirmPool.with(func(pc pooledChannel) error {
+		err := pc.ch.Publish(exchange, routingKey, mandatory, false, msg)
+		if err != nil {
+			return fmt.Errorf("failed to publish message to %s: %w", routingKey, err)
+		}
+
+		select {
+		case confirm, ok := <-pc.confirms:
+			if !ok {
+				return fmt.Errorf("channel closed before publish confirm: %w", amqp.ErrClosed)
+			}
+			// basic.return приходит раньше подтверждения, поэтому его уже можно прочитать
+			select {
+			case ret := <-pc.returns:
+				result = fmt.Errorf("%w: %s (%d %s)", broker.ErrUnroutable, ret.RoutingKey, ret.ReplyCode, ret.ReplyText)
+				return nil
+			default:
+			}
+			if !confirm.Ack {
+				result = fmt.Errorf("%w: delivery tag %d", broker.ErrNacked, confirm.DeliveryTag)
+			}
+			return nil
+		case <-ctx.Done():
+			// подтверждение может прийти позже, канал больше не используется
+			return fmt.Errorf("%w: %v", broker.ErrConfirmTimeout, ctx.Err())
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
@@ -2,36 +2,167 @@ package rabbitmq
 
 import (
 	"fmt"
+	"log"
+	"sync"
+	"time"
 
 	amqp "github.com/streadway/amqp"
+	"github.com/wileytor/go-market/common/broker"
 )
 
+const (
+	minReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
+)
+
+// RabbitMQ - реализация broker.Broker поверх AMQP.
+// Следит за соединением с брокером: при обрыве переподключается
+// с экспоненциальной задержкой, заново объявляет очереди и перезапускает потребителей.
 type RabbitMQ struct {
-	Connection *amqp.Connection
-	Channel    *amqp.Channel
+	url string
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	conn   *amqp.Connection
+	closed bool
+
+	topology    []declaration
+	pool        *channelPool
+	confirmPool *channelPool
+
+	rpcMu sync.Mutex
+	rpc   *RPCClient
 }
 
+var (
+	_ broker.Broker          = (*RabbitMQ)(nil)
+	_ broker.DeadLetterStore = (*RabbitMQ)(nil)
+)
+
 func InitRabbit(url string) (*RabbitMQ, error) {
 	conn, err := amqp.Dial(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect RabbitMQ at %s: %w", url, err)
 	}
-	channel, err := conn.Channel()
-	if err != nil {
-		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	r := &RabbitMQ{
+		url:  url,
+		conn: conn,
+	}
+	r.cond = sync.NewCond(&r.mu)
+	r.pool = newChannelPool(r, false)
+	r.confirmPool = newChannelPool(r, true)
+	go r.supervise(conn)
+	return r, nil
+}
+
+// connection возвращает текущее соединение
+func (r *RabbitMQ) connection() (*amqp.Connection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, broker.ErrClosed
+	}
+	return r.conn, nil
+}
+
+// nextConnection ждет, пока соединение old не будет заменено новым
+func (r *RabbitMQ) nextConnection(old *amqp.Connection) (*amqp.Connection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for !r.closed && r.conn == old {
+		r.cond.Wait()
+	}
+	if r.closed {
+		return nil, broker.ErrClosed
 	}
+	return r.conn, nil
+}
+
+// supervise ждет обрыва соединения и восстанавливает его
+func (r *RabbitMQ) supervise(conn *amqp.Connection) {
+	for {
+		// Канал закрывается без ошибки и при CloseRabbit, и если соединение умерло
+		// до регистрации NotifyClose, поэтому о намеренном закрытии судим по r.closed
+		closeErr := <-conn.NotifyClose(make(chan *amqp.Error, 1))
+		r.mu.Lock()
+		closed := r.closed
+		r.mu.Unlock()
+		if closed {
+			return
+		}
+		log.Printf("RabbitMQ connection lost: %v", closeErr)
 
-	return &RabbitMQ{
-		Connection: conn,
-		Channel:    channel,
-	}, nil
+		conn = r.reconnect()
+		if conn == nil {
+			return
+		}
+		log.Printf("RabbitMQ connection restored")
+	}
 }
 
-func (r *RabbitMQ) CloseRabbit() {
-	if r.Channel != nil {
-		r.Channel.Close()
+// reconnect подключается заново, пока не получится или клиент не будет закрыт
+func (r *RabbitMQ) reconnect() *amqp.Connection {
+	delay := minReconnectDelay
+	for {
+		time.Sleep(delay)
+		r.mu.Lock()
+		closed := r.closed
+		r.mu.Unlock()
+		if closed {
+			return nil
+		}
+
+		conn, err := amqp.Dial(r.url)
+		if err == nil {
+			err = r.declareTopology(conn)
+			if err != nil {
+				conn.Close()
+			}
+		}
+		if err != nil {
+			log.Printf("RabbitMQ reconnect failed, retry in %s: %v", delay, err)
+			delay *= 2
+			if delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
+			continue
+		}
+
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		r.conn = conn
+		r.cond.Broadcast()
+		r.mu.Unlock()
+
+		r.pool.reset()
+		r.confirmPool.reset()
+		return conn
+	}
+}
+
+func (r *RabbitMQ) Close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	conn := r.conn
+	r.cond.Broadcast()
+	r.mu.Unlock()
+
+	r.rpcMu.Lock()
+	if r.rpc != nil {
+		r.rpc.Close()
 	}
-	if r.Connection != nil {
-		r.Connection.Close()
+	r.rpcMu.Unlock()
+	r.pool.reset()
+	r.confirmPool.reset()
+	if conn != nil {
+		conn.Close()
 	}
 }
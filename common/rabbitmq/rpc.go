@@ -0,0 +1,176 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	amqp "github.com/streadway/amqp"
+	"github.com/wileytor/go-market/common/broker"
+)
+
+var ErrRPCClientClosed = errors.New("rpc client is closed")
+
+// RPCClient отправляет запросы и ждет ответы через одну эксклюзивную очередь ответов.
+// Ответ сопоставляется с запросом по CorrelationId, поэтому одновременные вызовы не мешают друг другу.
+type RPCClient struct {
+	channel    *amqp.Channel
+	replyQueue string
+
+	publishMu sync.Mutex
+	mu        sync.Mutex
+	pending   map[string]chan amqp.Delivery
+	closed    bool
+}
+
+func NewRPCClient(conn *amqp.Connection) (*RPCClient, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rpc channel: %w", err)
+	}
+	// имя очереди назначает брокер, очередь живет пока живо соединение
+	queue, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to declare reply queue: %w", err)
+	}
+	replies, err := ch.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to consume reply queue: %w", err)
+	}
+
+	c := &RPCClient{
+		channel:    ch,
+		replyQueue: queue.Name,
+		pending:    make(map[string]chan amqp.Delivery),
+	}
+	go c.dispatch(replies)
+	return c, nil
+}
+
+// Call публикует запрос в очередь queue и ждет ответа, пока не завершится ctx
+func (c *RPCClient) Call(ctx context.Context, queue string, body []byte) ([]byte, error) {
+	correlationID, err := broker.NewID()
+	if err != nil {
+		return nil, err
+	}
+	reply := make(chan amqp.Delivery, 1)
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrRPCClientClosed
+	}
+	c.pending[correlationID] = reply
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, correlationID)
+		c.mu.Unlock()
+	}()
+
+	c.publishMu.Lock()
+	err = c.channel.Publish("", queue, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: correlationID,
+		ReplyTo:       c.replyQueue,
+		Body:          body,
+	})
+	c.publishMu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish rpc request to %s: %w", queue, err)
+	}
+
+	select {
+	case msg, ok := <-reply:
+		if !ok {
+			return nil, ErrRPCClientClosed
+		}
+		return msg.Body, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("rpc call to %s: %w", queue, ctx.Err())
+	}
+}
+
+// dispatch раздает ответы ожидающим вызовам
+func (c *RPCClient) dispatch(replies <-chan amqp.Delivery) {
+	for msg := range replies {
+		c.mu.Lock()
+		reply, ok := c.pending[msg.CorrelationId]
+		c.mu.Unlock()
+		if !ok {
+			// вызов уже завершился по таймауту
+			continue
+		}
+		select {
+		case reply <- msg:
+		default:
+		}
+	}
+
+	// канал закрыт - будим всех, кто еще ждет ответа
+	c.mu.Lock()
+	c.closed = true
+	for id, reply := range c.pending {
+		close(reply)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+}
+
+func (c *RPCClient) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func (c *RPCClient) Close() error {
+	return c.channel.Close()
+}
+
+// Call выполняет RPC-запрос через клиент соединения, создавая его при первом вызове
+func (r *RabbitMQ) Call(ctx context.Context, queueName string, body []byte) ([]byte, error) {
+	r.rpcMu.Lock()
+	if r.rpc == nil || r.rpc.isClosed() {
+		conn, err := r.connection()
+		if err != nil {
+			r.rpcMu.Unlock()
+			return nil, err
+		}
+		client, err := NewRPCClient(conn)
+		if err != nil {
+			r.rpcMu.Unlock()
+			return nil, err
+		}
+		r.rpc = client
+	}
+	client := r.rpc
+	r.rpcMu.Unlock()
+
+	return client.Call(ctx, queueName, body)
+}
+
+// ServeRPC обрабатывает запросы из очереди queue и отправляет ответ в ReplyTo запроса.
+// Ошибка обработчика или отправки ответа приводит к повтору по правилам Consume.
+func (r *RabbitMQ) ServeRPC(queueName string, opts broker.ConsumerOptions, handler broker.RPCHandler) error {
+	return r.Consume(queueName, opts, func(msg broker.Message) error {
+		response, err := handler(msg.Body)
+		if err != nil {
+			return err
+		}
+		if msg.ReplyTo == "" {
+			return nil
+		}
+		err = r.publish(msg.ReplyTo, amqp.Publishing{
+			ContentType:   "application/json",
+			CorrelationId: msg.CorrelationID,
+			Body:          response,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish rpc reply to %s: %w", msg.ReplyTo, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,121 @@
+package rabbitmq
+
+import (
+	"fmt"
+
+	amqp "github.com/streadway/amqp"
+	"github.com/wileytor/go-market/common/models"
+)
+
+// declaration - часть топологии, которую нужно объявить заново после переподключения
+type declaration interface {
+	declare(ch *amqp.Channel) error
+}
+
+// Queue описывает очередь, которую нужно объявить заново после переподключения
+type Queue struct {
+	Name       string
+	Durable    bool
+	AutoDelete bool
+	Exclusive  bool
+	Args       amqp.Table
+}
+
+// Exchange описывает точку обмена
+type Exchange struct {
+	Name    string
+	Kind    string
+	Durable bool
+}
+
+// Binding привязывает очередь к точке обмена по ключу маршрутизации
+type Binding struct {
+	Queue    string
+	Exchange string
+	Key      string
+}
+
+// UserCheckQueue - очередь запросов проверки токена в сервис auth
+var UserCheckQueue = Queue{
+	Name:    models.UserCheckQueue,
+	Durable: true,
+	Args: amqp.Table{
+		"x-max-length": 500,
+	},
+}
+
+// EventsExchange - точка обмена доменных событий, ключ маршрутизации - тип события
+var EventsExchange = Exchange{
+	Name:    "events",
+	Kind:    amqp.ExchangeTopic,
+	Durable: true,
+}
+
+// DeclareQueue объявляет очередь и запоминает ее для повторного объявления
+func (r *RabbitMQ) DeclareQueue(q Queue) error {
+	return r.declare(q)
+}
+
+// DeclareExchange объявляет точку обмена и запоминает ее для повторного объявления
+func (r *RabbitMQ) DeclareExchange(e Exchange) error {
+	return r.declare(e)
+}
+
+// BindQueue привязывает очередь и запоминает привязку для повторного объявления
+func (r *RabbitMQ) BindQueue(b Binding) error {
+	return r.declare(b)
+}
+
+func (r *RabbitMQ) declare(d declaration) error {
+	if err := r.withChannel(d.declare); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.topology = append(r.topology, d)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *RabbitMQ) declareTopology(conn *amqp.Connection) error {
+	r.mu.Lock()
+	topology := append([]declaration(nil), r.topology...)
+	r.mu.Unlock()
+	if len(topology) == 0 {
+		return nil
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer ch.Close()
+	for _, d := range topology {
+		if err := d.declare(ch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q Queue) declare(ch *amqp.Channel) error {
+	_, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, q.Args)
+	if err != nil {
+		return fmt.Errorf("failed to declare queue %s: %w", q.Name, err)
+	}
+	return nil
+}
+
+func (e Exchange) declare(ch *amqp.Channel) error {
+	if err := ch.ExchangeDeclare(e.Name, e.Kind, e.Durable, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", e.Name, err)
+	}
+	return nil
+}
+
+func (b Binding) declare(ch *amqp.Channel) error {
+	if err := ch.QueueBind(b.Queue, b.Key, b.Exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue %s to %s: %w", b.Queue, b.Exchange, err)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	models "github.com/wileytor/go-market/common/models"
@@ -34,23 +35,1283 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddCartItem mocks base method.
+func (m *MockRepository) AddCartItem(arg0 int, arg1 models.OrderLine) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCartItem", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCartItem indicates an expected call of AddCartItem.
+func (mr *MockRepositoryMockRecorder) AddCartItem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCartItem", reflect.TypeOf((*MockRepository)(nil).AddCartItem), arg0, arg1)
+}
+
+// AddCategory mocks base method.
+func (m *MockRepository) AddCategory(arg0 models.Category) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCategory", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddCategory indicates an expected call of AddCategory.
+func (mr *MockRepositoryMockRecorder) AddCategory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategory", reflect.TypeOf((*MockRepository)(nil).AddCategory), arg0)
+}
+
+// AddProduct mocks base method.
+func (m *MockRepository) AddProduct(arg0 int, arg1 models.Product) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddProduct", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddProduct indicates an expected call of AddProduct.
+func (mr *MockRepositoryMockRecorder) AddProduct(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProduct", reflect.TypeOf((*MockRepository)(nil).AddProduct), arg0, arg1)
+}
+
+// AddVariant mocks base method.
+func (m *MockRepository) AddVariant(arg0 int, arg1 models.ProductVariant) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddVariant", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddVariant indicates an expected call of AddVariant.
+func (mr *MockRepositoryMockRecorder) AddVariant(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVariant", reflect.TypeOf((*MockRepository)(nil).AddVariant), arg0, arg1)
+}
+
+// AddWarehouse mocks base method.
+func (m *MockRepository) AddWarehouse(arg0 models.Warehouse) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWarehouse", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddWarehouse indicates an expected call of AddWarehouse.
+func (mr *MockRepositoryMockRecorder) AddWarehouse(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWarehouse", reflect.TypeOf((*MockRepository)(nil).AddWarehouse), arg0)
+}
+
+// AdjustStock mocks base method.
+func (m *MockRepository) AdjustStock(arg0, arg1 int, arg2 models.StockAdjustment) (models.StockMovement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustStock", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.StockMovement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustStock indicates an expected call of AdjustStock.
+func (mr *MockRepositoryMockRecorder) AdjustStock(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustStock", reflect.TypeOf((*MockRepository)(nil).AdjustStock), arg0, arg1, arg2)
+}
+
+// ChangeOrderStatus mocks base method.
+func (m *MockRepository) ChangeOrderStatus(arg0, arg1 int, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeOrderStatus", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeOrderStatus indicates an expected call of ChangeOrderStatus.
+func (mr *MockRepositoryMockRecorder) ChangeOrderStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeOrderStatus", reflect.TypeOf((*MockRepository)(nil).ChangeOrderStatus), arg0, arg1, arg2)
+}
+
+// CheckoutCart mocks base method.
+func (m *MockRepository) CheckoutCart(arg0 int, arg1 string) (models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckoutCart", arg0, arg1)
+	ret0, _ := ret[0].(models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckoutCart indicates an expected call of CheckoutCart.
+func (mr *MockRepositoryMockRecorder) CheckoutCart(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckoutCart", reflect.TypeOf((*MockRepository)(nil).CheckoutCart), arg0, arg1)
+}
+
+// ClearCart mocks base method.
+func (m *MockRepository) ClearCart(arg0 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearCart", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearCart indicates an expected call of ClearCart.
+func (mr *MockRepositoryMockRecorder) ClearCart(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearCart", reflect.TypeOf((*MockRepository)(nil).ClearCart), arg0)
+}
+
+// CreateOrder mocks base method.
+func (m *MockRepository) CreateOrder(arg0 int, arg1 models.OrderRequest) (models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrder", arg0, arg1)
+	ret0, _ := ret[0].(models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrder indicates an expected call of CreateOrder.
+func (mr *MockRepositoryMockRecorder) CreateOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockRepository)(nil).CreateOrder), arg0, arg1)
+}
+
+// CreatePayment mocks base method.
+func (m *MockRepository) CreatePayment(arg0 int, arg1 string) (models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayment", arg0, arg1)
+	ret0, _ := ret[0].(models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayment indicates an expected call of CreatePayment.
+func (mr *MockRepositoryMockRecorder) CreatePayment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockRepository)(nil).CreatePayment), arg0, arg1)
+}
+
+// DeleteCategory mocks base method.
+func (m *MockRepository) DeleteCategory(arg0 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategory", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCategory indicates an expected call of DeleteCategory.
+func (mr *MockRepositoryMockRecorder) DeleteCategory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockRepository)(nil).DeleteCategory), arg0)
+}
+
+// DeleteExpiredCarts mocks base method.
+func (m *MockRepository) DeleteExpiredCarts() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredCarts")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredCarts indicates an expected call of DeleteExpiredCarts.
+func (mr *MockRepositoryMockRecorder) DeleteExpiredCarts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredCarts", reflect.TypeOf((*MockRepository)(nil).DeleteExpiredCarts))
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockRepository) DeleteExpiredIdempotencyKeys() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockRepositoryMockRecorder) DeleteExpiredIdempotencyKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockRepository)(nil).DeleteExpiredIdempotencyKeys))
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockRepository) DeleteIdempotencyKey(arg0 models.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockRepositoryMockRecorder) DeleteIdempotencyKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockRepository)(nil).DeleteIdempotencyKey), arg0)
+}
+
+// DeleteProducts mocks base method.
+func (m *MockRepository) DeleteProducts() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProducts")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProducts indicates an expected call of DeleteProducts.
+func (mr *MockRepositoryMockRecorder) DeleteProducts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProducts", reflect.TypeOf((*MockRepository)(nil).DeleteProducts))
+}
+
+// DeleteVariant mocks base method.
+func (m *MockRepository) DeleteVariant(arg0, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVariant", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVariant indicates an expected call of DeleteVariant.
+func (mr *MockRepositoryMockRecorder) DeleteVariant(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVariant", reflect.TypeOf((*MockRepository)(nil).DeleteVariant), arg0, arg1)
+}
+
+// GetAllProducts mocks base method.
+func (m *MockRepository) GetAllProducts(arg0 models.ProductFilter) (models.ProductPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllProducts", arg0)
+	ret0, _ := ret[0].(models.ProductPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllProducts indicates an expected call of GetAllProducts.
+func (mr *MockRepositoryMockRecorder) GetAllProducts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllProducts", reflect.TypeOf((*MockRepository)(nil).GetAllProducts), arg0)
+}
+
+// GetCart mocks base method.
+func (m *MockRepository) GetCart(arg0 int) (models.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCart", arg0)
+	ret0, _ := ret[0].(models.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCart indicates an expected call of GetCart.
+func (mr *MockRepositoryMockRecorder) GetCart(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCart", reflect.TypeOf((*MockRepository)(nil).GetCart), arg0)
+}
+
+// GetCategories mocks base method.
+func (m *MockRepository) GetCategories() ([]models.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategories")
+	ret0, _ := ret[0].([]models.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategories indicates an expected call of GetCategories.
+func (mr *MockRepositoryMockRecorder) GetCategories() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockRepository)(nil).GetCategories))
+}
+
+// GetCategoryByID mocks base method.
+func (m *MockRepository) GetCategoryByID(arg0 int) (models.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryByID", arg0)
+	ret0, _ := ret[0].(models.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryByID indicates an expected call of GetCategoryByID.
+func (mr *MockRepositoryMockRecorder) GetCategoryByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryByID", reflect.TypeOf((*MockRepository)(nil).GetCategoryByID), arg0)
+}
+
+// GetExpiredPayments mocks base method.
+func (m *MockRepository) GetExpiredPayments() ([]models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredPayments")
+	ret0, _ := ret[0].([]models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredPayments indicates an expected call of GetExpiredPayments.
+func (mr *MockRepositoryMockRecorder) GetExpiredPayments() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredPayments", reflect.TypeOf((*MockRepository)(nil).GetExpiredPayments))
+}
+
+// GetLowStock mocks base method.
+func (m *MockRepository) GetLowStock() ([]models.LowStockItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLowStock")
+	ret0, _ := ret[0].([]models.LowStockItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLowStock indicates an expected call of GetLowStock.
+func (mr *MockRepositoryMockRecorder) GetLowStock() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLowStock", reflect.TypeOf((*MockRepository)(nil).GetLowStock))
+}
+
+// GetOrderByID mocks base method.
+func (m *MockRepository) GetOrderByID(arg0 int) (models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByID", arg0)
+	ret0, _ := ret[0].(models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByID indicates an expected call of GetOrderByID.
+func (mr *MockRepositoryMockRecorder) GetOrderByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockRepository)(nil).GetOrderByID), arg0)
+}
+
+// GetOrderPayments mocks base method.
+func (m *MockRepository) GetOrderPayments(arg0 int) ([]models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderPayments", arg0)
+	ret0, _ := ret[0].([]models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderPayments indicates an expected call of GetOrderPayments.
+func (mr *MockRepositoryMockRecorder) GetOrderPayments(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderPayments", reflect.TypeOf((*MockRepository)(nil).GetOrderPayments), arg0)
+}
+
+// GetPaymentByProviderID mocks base method.
+func (m *MockRepository) GetPaymentByProviderID(arg0, arg1 string) (models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentByProviderID", arg0, arg1)
+	ret0, _ := ret[0].(models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentByProviderID indicates an expected call of GetPaymentByProviderID.
+func (mr *MockRepositoryMockRecorder) GetPaymentByProviderID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentByProviderID", reflect.TypeOf((*MockRepository)(nil).GetPaymentByProviderID), arg0, arg1)
+}
+
+// GetProductAvailability mocks base method.
+func (m *MockRepository) GetProductAvailability(arg0 int) (models.Availability, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductAvailability", arg0)
+	ret0, _ := ret[0].(models.Availability)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductAvailability indicates an expected call of GetProductAvailability.
+func (mr *MockRepositoryMockRecorder) GetProductAvailability(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductAvailability", reflect.TypeOf((*MockRepository)(nil).GetProductAvailability), arg0)
+}
+
+// GetProductBreadcrumbs mocks base method.
+func (m *MockRepository) GetProductBreadcrumbs(arg0 int) ([][]models.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductBreadcrumbs", arg0)
+	ret0, _ := ret[0].([][]models.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductBreadcrumbs indicates an expected call of GetProductBreadcrumbs.
+func (mr *MockRepositoryMockRecorder) GetProductBreadcrumbs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductBreadcrumbs", reflect.TypeOf((*MockRepository)(nil).GetProductBreadcrumbs), arg0)
+}
+
+// GetProductByID mocks base method.
+func (m *MockRepository) GetProductByID(arg0 int) (models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductByID", arg0)
+	ret0, _ := ret[0].(models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductByID indicates an expected call of GetProductByID.
+func (mr *MockRepositoryMockRecorder) GetProductByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductByID", reflect.TypeOf((*MockRepository)(nil).GetProductByID), arg0)
+}
+
+// GetProductOrders mocks base method.
+func (m *MockRepository) GetProductOrders(arg0 int) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductOrders", arg0)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductOrders indicates an expected call of GetProductOrders.
+func (mr *MockRepositoryMockRecorder) GetProductOrders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductOrders", reflect.TypeOf((*MockRepository)(nil).GetProductOrders), arg0)
+}
+
+// GetProductVariants mocks base method.
+func (m *MockRepository) GetProductVariants(arg0 int) ([]models.ProductVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductVariants", arg0)
+	ret0, _ := ret[0].([]models.ProductVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductVariants indicates an expected call of GetProductVariants.
+func (mr *MockRepositoryMockRecorder) GetProductVariants(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductVariants", reflect.TypeOf((*MockRepository)(nil).GetProductVariants), arg0)
+}
+
+// GetRestockRequests mocks base method.
+func (m *MockRepository) GetRestockRequests(arg0 string) ([]models.RestockRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRestockRequests", arg0)
+	ret0, _ := ret[0].([]models.RestockRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRestockRequests indicates an expected call of GetRestockRequests.
+func (mr *MockRepositoryMockRecorder) GetRestockRequests(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRestockRequests", reflect.TypeOf((*MockRepository)(nil).GetRestockRequests), arg0)
+}
+
+// GetStockMovements mocks base method.
+func (m *MockRepository) GetStockMovements(arg0 int, arg1, arg2 time.Time) ([]models.StockMovement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStockMovements", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.StockMovement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStockMovements indicates an expected call of GetStockMovements.
+func (mr *MockRepositoryMockRecorder) GetStockMovements(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockMovements", reflect.TypeOf((*MockRepository)(nil).GetStockMovements), arg0, arg1, arg2)
+}
+
+// GetStockReport mocks base method.
+func (m *MockRepository) GetStockReport(arg0 int, arg1 time.Time) (models.StockReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStockReport", arg0, arg1)
+	ret0, _ := ret[0].(models.StockReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStockReport indicates an expected call of GetStockReport.
+func (mr *MockRepositoryMockRecorder) GetStockReport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockReport", reflect.TypeOf((*MockRepository)(nil).GetStockReport), arg0, arg1)
+}
+
+// GetStockTransfers mocks base method.
+func (m *MockRepository) GetStockTransfers(arg0 int) ([]models.StockTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStockTransfers", arg0)
+	ret0, _ := ret[0].([]models.StockTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStockTransfers indicates an expected call of GetStockTransfers.
+func (mr *MockRepositoryMockRecorder) GetStockTransfers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockTransfers", reflect.TypeOf((*MockRepository)(nil).GetStockTransfers), arg0)
+}
+
+// GetUserOrders mocks base method.
+func (m *MockRepository) GetUserOrders(arg0 int) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrders", arg0)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrders indicates an expected call of GetUserOrders.
+func (mr *MockRepositoryMockRecorder) GetUserOrders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockRepository)(nil).GetUserOrders), arg0)
+}
+
+// GetWarehouses mocks base method.
+func (m *MockRepository) GetWarehouses() ([]models.Warehouse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWarehouses")
+	ret0, _ := ret[0].([]models.Warehouse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWarehouses indicates an expected call of GetWarehouses.
+func (mr *MockRepositoryMockRecorder) GetWarehouses() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWarehouses", reflect.TypeOf((*MockRepository)(nil).GetWarehouses))
+}
+
+// IsProductUnique mocks base method.
+func (m *MockRepository) IsProductUnique(arg0 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsProductUnique", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsProductUnique indicates an expected call of IsProductUnique.
+func (mr *MockRepositoryMockRecorder) IsProductUnique(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsProductUnique", reflect.TypeOf((*MockRepository)(nil).IsProductUnique), arg0)
+}
+
+// ProcessOutbox mocks base method.
+func (m *MockRepository) ProcessOutbox(arg0 int, arg1 func(models.OutboxMessage) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessOutbox", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessOutbox indicates an expected call of ProcessOutbox.
+func (mr *MockRepositoryMockRecorder) ProcessOutbox(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessOutbox", reflect.TypeOf((*MockRepository)(nil).ProcessOutbox), arg0, arg1)
+}
+
+// ReleaseExpiredReservations mocks base method.
+func (m *MockRepository) ReleaseExpiredReservations() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseExpiredReservations")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseExpiredReservations indicates an expected call of ReleaseExpiredReservations.
+func (mr *MockRepositoryMockRecorder) ReleaseExpiredReservations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseExpiredReservations", reflect.TypeOf((*MockRepository)(nil).ReleaseExpiredReservations))
+}
+
+// RemoveCartItem mocks base method.
+func (m *MockRepository) RemoveCartItem(arg0, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveCartItem", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveCartItem indicates an expected call of RemoveCartItem.
+func (mr *MockRepositoryMockRecorder) RemoveCartItem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveCartItem", reflect.TypeOf((*MockRepository)(nil).RemoveCartItem), arg0, arg1)
+}
+
+// SaveIdempotentResponse mocks base method.
+func (m *MockRepository) SaveIdempotentResponse(arg0 models.IdempotencyKey, arg1 models.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotentResponse", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotentResponse indicates an expected call of SaveIdempotentResponse.
+func (mr *MockRepositoryMockRecorder) SaveIdempotentResponse(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotentResponse", reflect.TypeOf((*MockRepository)(nil).SaveIdempotentResponse), arg0, arg1)
+}
+
+// SearchProducts mocks base method.
+func (m *MockRepository) SearchProducts(arg0 models.ProductSearch) (models.ProductSearchPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchProducts", arg0)
+	ret0, _ := ret[0].(models.ProductSearchPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchProducts indicates an expected call of SearchProducts.
+func (mr *MockRepositoryMockRecorder) SearchProducts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchProducts", reflect.TypeOf((*MockRepository)(nil).SearchProducts), arg0)
+}
+
+// SetDeleteStatus mocks base method.
+func (m *MockRepository) SetDeleteStatus(arg0 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDeleteStatus", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDeleteStatus indicates an expected call of SetDeleteStatus.
+func (mr *MockRepositoryMockRecorder) SetDeleteStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleteStatus", reflect.TypeOf((*MockRepository)(nil).SetDeleteStatus), arg0)
+}
+
+// SetProductCategories mocks base method.
+func (m *MockRepository) SetProductCategories(arg0 int, arg1 []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductCategories", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductCategories indicates an expected call of SetProductCategories.
+func (mr *MockRepositoryMockRecorder) SetProductCategories(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductCategories", reflect.TypeOf((*MockRepository)(nil).SetProductCategories), arg0, arg1)
+}
+
+// SetReorderPolicy mocks base method.
+func (m *MockRepository) SetReorderPolicy(arg0 int, arg1 models.ReorderPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReorderPolicy", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReorderPolicy indicates an expected call of SetReorderPolicy.
+func (mr *MockRepositoryMockRecorder) SetReorderPolicy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReorderPolicy", reflect.TypeOf((*MockRepository)(nil).SetReorderPolicy), arg0, arg1)
+}
+
+// StartIdempotentRequest mocks base method.
+func (m *MockRepository) StartIdempotentRequest(arg0 models.IdempotencyKey) (*models.IdempotentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartIdempotentRequest", arg0)
+	ret0, _ := ret[0].(*models.IdempotentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartIdempotentRequest indicates an expected call of StartIdempotentRequest.
+func (mr *MockRepositoryMockRecorder) StartIdempotentRequest(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartIdempotentRequest", reflect.TypeOf((*MockRepository)(nil).StartIdempotentRequest), arg0)
+}
+
+// StartRefund mocks base method.
+func (m *MockRepository) StartRefund(arg0 int) (models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartRefund", arg0)
+	ret0, _ := ret[0].(models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartRefund indicates an expected call of StartRefund.
+func (mr *MockRepositoryMockRecorder) StartRefund(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartRefund", reflect.TypeOf((*MockRepository)(nil).StartRefund), arg0)
+}
+
+// TransferStock mocks base method.
+func (m *MockRepository) TransferStock(arg0 int, arg1 models.StockTransferRequest) (models.StockTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferStock", arg0, arg1)
+	ret0, _ := ret[0].(models.StockTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferStock indicates an expected call of TransferStock.
+func (mr *MockRepositoryMockRecorder) TransferStock(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferStock", reflect.TypeOf((*MockRepository)(nil).TransferStock), arg0, arg1)
+}
+
+// UpdateCartItem mocks base method.
+func (m *MockRepository) UpdateCartItem(arg0, arg1, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCartItem", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCartItem indicates an expected call of UpdateCartItem.
+func (mr *MockRepositoryMockRecorder) UpdateCartItem(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCartItem", reflect.TypeOf((*MockRepository)(nil).UpdateCartItem), arg0, arg1, arg2)
+}
+
+// UpdateCategory mocks base method.
+func (m *MockRepository) UpdateCategory(arg0 int, arg1 models.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategory", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategory indicates an expected call of UpdateCategory.
+func (mr *MockRepositoryMockRecorder) UpdateCategory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockRepository)(nil).UpdateCategory), arg0, arg1)
+}
+
+// UpdatePayment mocks base method.
+func (m *MockRepository) UpdatePayment(arg0 models.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePayment", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePayment indicates an expected call of UpdatePayment.
+func (mr *MockRepositoryMockRecorder) UpdatePayment(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayment", reflect.TypeOf((*MockRepository)(nil).UpdatePayment), arg0)
+}
+
+// UpdateProduct mocks base method.
+func (m *MockRepository) UpdateProduct(arg0, arg1 int, arg2 models.Product) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProduct", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProduct indicates an expected call of UpdateProduct.
+func (mr *MockRepositoryMockRecorder) UpdateProduct(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockRepository)(nil).UpdateProduct), arg0, arg1, arg2)
+}
+
+// UpdateVariant mocks base method.
+func (m *MockRepository) UpdateVariant(arg0 int, arg1 models.ProductVariant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVariant", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVariant indicates an expected call of UpdateVariant.
+func (mr *MockRepositoryMockRecorder) UpdateVariant(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVariant", reflect.TypeOf((*MockRepository)(nil).UpdateVariant), arg0, arg1)
+}
+
+// UpdateWarehouse mocks base method.
+func (m *MockRepository) UpdateWarehouse(arg0 models.Warehouse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWarehouse", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWarehouse indicates an expected call of UpdateWarehouse.
+func (mr *MockRepositoryMockRecorder) UpdateWarehouse(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWarehouse", reflect.TypeOf((*MockRepository)(nil).UpdateWarehouse), arg0)
+}
+
+// MockOrderRepository is a mock of OrderRepository interface.
+type MockOrderRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderRepositoryMockRecorder
+}
+
+// MockOrderRepositoryMockRecorder is the mock recorder for MockOrderRepository.
+type MockOrderRepositoryMockRecorder struct {
+	mock *MockOrderRepository
+}
+
+// NewMockOrderRepository creates a new mock instance.
+func NewMockOrderRepository(ctrl *gomock.Controller) *MockOrderRepository {
+	mock := &MockOrderRepository{ctrl: ctrl}
+	mock.recorder = &MockOrderRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ChangeOrderStatus mocks base method.
+func (m *MockOrderRepository) ChangeOrderStatus(arg0, arg1 int, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeOrderStatus", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeOrderStatus indicates an expected call of ChangeOrderStatus.
+func (mr *MockOrderRepositoryMockRecorder) ChangeOrderStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeOrderStatus", reflect.TypeOf((*MockOrderRepository)(nil).ChangeOrderStatus), arg0, arg1, arg2)
+}
+
+// CreateOrder mocks base method.
+func (m *MockOrderRepository) CreateOrder(arg0 int, arg1 models.OrderRequest) (models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrder", arg0, arg1)
+	ret0, _ := ret[0].(models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrder indicates an expected call of CreateOrder.
+func (mr *MockOrderRepositoryMockRecorder) CreateOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrder), arg0, arg1)
+}
+
+// GetOrderByID mocks base method.
+func (m *MockOrderRepository) GetOrderByID(arg0 int) (models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderByID", arg0)
+	ret0, _ := ret[0].(models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderByID indicates an expected call of GetOrderByID.
+func (mr *MockOrderRepositoryMockRecorder) GetOrderByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderByID), arg0)
+}
+
+// GetProductOrders mocks base method.
+func (m *MockOrderRepository) GetProductOrders(arg0 int) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductOrders", arg0)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductOrders indicates an expected call of GetProductOrders.
+func (mr *MockOrderRepositoryMockRecorder) GetProductOrders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetProductOrders), arg0)
+}
+
+// GetUserOrders mocks base method.
+func (m *MockOrderRepository) GetUserOrders(arg0 int) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrders", arg0)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrders indicates an expected call of GetUserOrders.
+func (mr *MockOrderRepositoryMockRecorder) GetUserOrders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetUserOrders), arg0)
+}
+
+// ReleaseExpiredReservations mocks base method.
+func (m *MockOrderRepository) ReleaseExpiredReservations() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseExpiredReservations")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseExpiredReservations indicates an expected call of ReleaseExpiredReservations.
+func (mr *MockOrderRepositoryMockRecorder) ReleaseExpiredReservations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseExpiredReservations", reflect.TypeOf((*MockOrderRepository)(nil).ReleaseExpiredReservations))
+}
+
+// MockCartRepository is a mock of CartRepository interface.
+type MockCartRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCartRepositoryMockRecorder
+}
+
+// MockCartRepositoryMockRecorder is the mock recorder for MockCartRepository.
+type MockCartRepositoryMockRecorder struct {
+	mock *MockCartRepository
+}
+
+// NewMockCartRepository creates a new mock instance.
+func NewMockCartRepository(ctrl *gomock.Controller) *MockCartRepository {
+	mock := &MockCartRepository{ctrl: ctrl}
+	mock.recorder = &MockCartRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCartRepository) EXPECT() *MockCartRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddCartItem mocks base method.
+func (m *MockCartRepository) AddCartItem(arg0 int, arg1 models.OrderLine) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCartItem", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCartItem indicates an expected call of AddCartItem.
+func (mr *MockCartRepositoryMockRecorder) AddCartItem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCartItem", reflect.TypeOf((*MockCartRepository)(nil).AddCartItem), arg0, arg1)
+}
+
+// CheckoutCart mocks base method.
+func (m *MockCartRepository) CheckoutCart(arg0 int, arg1 string) (models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckoutCart", arg0, arg1)
+	ret0, _ := ret[0].(models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckoutCart indicates an expected call of CheckoutCart.
+func (mr *MockCartRepositoryMockRecorder) CheckoutCart(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckoutCart", reflect.TypeOf((*MockCartRepository)(nil).CheckoutCart), arg0, arg1)
+}
+
+// ClearCart mocks base method.
+func (m *MockCartRepository) ClearCart(arg0 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearCart", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearCart indicates an expected call of ClearCart.
+func (mr *MockCartRepositoryMockRecorder) ClearCart(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearCart", reflect.TypeOf((*MockCartRepository)(nil).ClearCart), arg0)
+}
+
+// DeleteExpiredCarts mocks base method.
+func (m *MockCartRepository) DeleteExpiredCarts() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredCarts")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredCarts indicates an expected call of DeleteExpiredCarts.
+func (mr *MockCartRepositoryMockRecorder) DeleteExpiredCarts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredCarts", reflect.TypeOf((*MockCartRepository)(nil).DeleteExpiredCarts))
+}
+
+// GetCart mocks base method.
+func (m *MockCartRepository) GetCart(arg0 int) (models.Cart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCart", arg0)
+	ret0, _ := ret[0].(models.Cart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCart indicates an expected call of GetCart.
+func (mr *MockCartRepositoryMockRecorder) GetCart(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCart", reflect.TypeOf((*MockCartRepository)(nil).GetCart), arg0)
+}
+
+// RemoveCartItem mocks base method.
+func (m *MockCartRepository) RemoveCartItem(arg0, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveCartItem", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveCartItem indicates an expected call of RemoveCartItem.
+func (mr *MockCartRepositoryMockRecorder) RemoveCartItem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveCartItem", reflect.TypeOf((*MockCartRepository)(nil).RemoveCartItem), arg0, arg1)
+}
+
+// UpdateCartItem mocks base method.
+func (m *MockCartRepository) UpdateCartItem(arg0, arg1, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCartItem", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCartItem indicates an expected call of UpdateCartItem.
+func (mr *MockCartRepositoryMockRecorder) UpdateCartItem(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCartItem", reflect.TypeOf((*MockCartRepository)(nil).UpdateCartItem), arg0, arg1, arg2)
+}
+
+// MockPaymentRepository is a mock of PaymentRepository interface.
+type MockPaymentRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentRepositoryMockRecorder
+}
+
+// MockPaymentRepositoryMockRecorder is the mock recorder for MockPaymentRepository.
+type MockPaymentRepositoryMockRecorder struct {
+	mock *MockPaymentRepository
+}
+
+// NewMockPaymentRepository creates a new mock instance.
+func NewMockPaymentRepository(ctrl *gomock.Controller) *MockPaymentRepository {
+	mock := &MockPaymentRepository{ctrl: ctrl}
+	mock.recorder = &MockPaymentRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentRepository) EXPECT() *MockPaymentRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreatePayment mocks base method.
+func (m *MockPaymentRepository) CreatePayment(arg0 int, arg1 string) (models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePayment", arg0, arg1)
+	ret0, _ := ret[0].(models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePayment indicates an expected call of CreatePayment.
+func (mr *MockPaymentRepositoryMockRecorder) CreatePayment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockPaymentRepository)(nil).CreatePayment), arg0, arg1)
+}
+
+// GetExpiredPayments mocks base method.
+func (m *MockPaymentRepository) GetExpiredPayments() ([]models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredPayments")
+	ret0, _ := ret[0].([]models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredPayments indicates an expected call of GetExpiredPayments.
+func (mr *MockPaymentRepositoryMockRecorder) GetExpiredPayments() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredPayments", reflect.TypeOf((*MockPaymentRepository)(nil).GetExpiredPayments))
+}
+
+// GetOrderPayments mocks base method.
+func (m *MockPaymentRepository) GetOrderPayments(arg0 int) ([]models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderPayments", arg0)
+	ret0, _ := ret[0].([]models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderPayments indicates an expected call of GetOrderPayments.
+func (mr *MockPaymentRepositoryMockRecorder) GetOrderPayments(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderPayments", reflect.TypeOf((*MockPaymentRepository)(nil).GetOrderPayments), arg0)
+}
+
+// GetPaymentByProviderID mocks base method.
+func (m *MockPaymentRepository) GetPaymentByProviderID(arg0, arg1 string) (models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentByProviderID", arg0, arg1)
+	ret0, _ := ret[0].(models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentByProviderID indicates an expected call of GetPaymentByProviderID.
+func (mr *MockPaymentRepositoryMockRecorder) GetPaymentByProviderID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentByProviderID", reflect.TypeOf((*MockPaymentRepository)(nil).GetPaymentByProviderID), arg0, arg1)
+}
+
+// StartRefund mocks base method.
+func (m *MockPaymentRepository) StartRefund(arg0 int) (models.Payment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartRefund", arg0)
+	ret0, _ := ret[0].(models.Payment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartRefund indicates an expected call of StartRefund.
+func (mr *MockPaymentRepositoryMockRecorder) StartRefund(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartRefund", reflect.TypeOf((*MockPaymentRepository)(nil).StartRefund), arg0)
+}
+
+// UpdatePayment mocks base method.
+func (m *MockPaymentRepository) UpdatePayment(arg0 models.Payment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePayment", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePayment indicates an expected call of UpdatePayment.
+func (mr *MockPaymentRepositoryMockRecorder) UpdatePayment(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayment", reflect.TypeOf((*MockPaymentRepository)(nil).UpdatePayment), arg0)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface.
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository.
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance.
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockIdempotencyRepository) DeleteExpiredIdempotencyKeys() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockIdempotencyRepositoryMockRecorder) DeleteExpiredIdempotencyKeys() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockIdempotencyRepository)(nil).DeleteExpiredIdempotencyKeys))
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockIdempotencyRepository) DeleteIdempotencyKey(arg0 models.IdempotencyKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) DeleteIdempotencyKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).DeleteIdempotencyKey), arg0)
+}
+
+// SaveIdempotentResponse mocks base method.
+func (m *MockIdempotencyRepository) SaveIdempotentResponse(arg0 models.IdempotencyKey, arg1 models.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotentResponse", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotentResponse indicates an expected call of SaveIdempotentResponse.
+func (mr *MockIdempotencyRepositoryMockRecorder) SaveIdempotentResponse(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotentResponse", reflect.TypeOf((*MockIdempotencyRepository)(nil).SaveIdempotentResponse), arg0, arg1)
+}
+
+// StartIdempotentRequest mocks base method.
+func (m *MockIdempotencyRepository) StartIdempotentRequest(arg0 models.IdempotencyKey) (*models.IdempotentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartIdempotentRequest", arg0)
+	ret0, _ := ret[0].(*models.IdempotentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartIdempotentRequest indicates an expected call of StartIdempotentRequest.
+func (mr *MockIdempotencyRepositoryMockRecorder) StartIdempotentRequest(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartIdempotentRequest", reflect.TypeOf((*MockIdempotencyRepository)(nil).StartIdempotentRequest), arg0)
+}
+
+// MockProductRepository is a mock of ProductRepository interface.
+type MockProductRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockProductRepositoryMockRecorder
+}
+
+// MockProductRepositoryMockRecorder is the mock recorder for MockProductRepository.
+type MockProductRepositoryMockRecorder struct {
+	mock *MockProductRepository
+}
+
+// NewMockProductRepository creates a new mock instance.
+func NewMockProductRepository(ctrl *gomock.Controller) *MockProductRepository {
+	mock := &MockProductRepository{ctrl: ctrl}
+	mock.recorder = &MockProductRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProductRepository) EXPECT() *MockProductRepositoryMockRecorder {
+	return m.recorder
+}
+
 // AddProduct mocks base method.
-func (m *MockRepository) AddProduct(arg0 models.Product) (int, error) {
+func (m *MockProductRepository) AddProduct(arg0 int, arg1 models.Product) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddProduct", arg0)
+	ret := m.ctrl.Call(m, "AddProduct", arg0, arg1)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddProduct indicates an expected call of AddProduct.
-func (mr *MockRepositoryMockRecorder) AddProduct(arg0 interface{}) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) AddProduct(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProduct", reflect.TypeOf((*MockRepository)(nil).AddProduct), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProduct", reflect.TypeOf((*MockProductRepository)(nil).AddProduct), arg0, arg1)
 }
 
 // DeleteProducts mocks base method.
-func (m *MockRepository) DeleteProducts() error {
+func (m *MockProductRepository) DeleteProducts() error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteProducts")
 	ret0, _ := ret[0].(error)
@@ -58,28 +1319,28 @@ func (m *MockRepository) DeleteProducts() error {
 }
 
 // DeleteProducts indicates an expected call of DeleteProducts.
-func (mr *MockRepositoryMockRecorder) DeleteProducts() *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) DeleteProducts() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProducts", reflect.TypeOf((*MockRepository)(nil).DeleteProducts))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProducts", reflect.TypeOf((*MockProductRepository)(nil).DeleteProducts))
 }
 
 // GetAllProducts mocks base method.
-func (m *MockRepository) GetAllProducts() ([]models.Product, error) {
+func (m *MockProductRepository) GetAllProducts(arg0 models.ProductFilter) (models.ProductPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllProducts")
-	ret0, _ := ret[0].([]models.Product)
+	ret := m.ctrl.Call(m, "GetAllProducts", arg0)
+	ret0, _ := ret[0].(models.ProductPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllProducts indicates an expected call of GetAllProducts.
-func (mr *MockRepositoryMockRecorder) GetAllProducts() *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) GetAllProducts(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllProducts", reflect.TypeOf((*MockRepository)(nil).GetAllProducts))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllProducts", reflect.TypeOf((*MockProductRepository)(nil).GetAllProducts), arg0)
 }
 
 // GetProductByID mocks base method.
-func (m *MockRepository) GetProductByID(arg0 int) (models.Product, error) {
+func (m *MockProductRepository) GetProductByID(arg0 int) (models.Product, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetProductByID", arg0)
 	ret0, _ := ret[0].(models.Product)
@@ -88,290 +1349,557 @@ func (m *MockRepository) GetProductByID(arg0 int) (models.Product, error) {
 }
 
 // GetProductByID indicates an expected call of GetProductByID.
-func (mr *MockRepositoryMockRecorder) GetProductByID(arg0 interface{}) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) GetProductByID(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductByID", reflect.TypeOf((*MockRepository)(nil).GetProductByID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductByID", reflect.TypeOf((*MockProductRepository)(nil).GetProductByID), arg0)
 }
 
-// GetProductPurchases mocks base method.
-func (m *MockRepository) GetProductPurchases(arg0 int) ([]models.Purchase, error) {
+// IsProductUnique mocks base method.
+func (m *MockProductRepository) IsProductUnique(arg0 string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProductPurchases", arg0)
-	ret0, _ := ret[0].([]models.Purchase)
+	ret := m.ctrl.Call(m, "IsProductUnique", arg0)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetProductPurchases indicates an expected call of GetProductPurchases.
-func (mr *MockRepositoryMockRecorder) GetProductPurchases(arg0 interface{}) *gomock.Call {
+// IsProductUnique indicates an expected call of IsProductUnique.
+func (mr *MockProductRepositoryMockRecorder) IsProductUnique(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductPurchases", reflect.TypeOf((*MockRepository)(nil).GetProductPurchases), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsProductUnique", reflect.TypeOf((*MockProductRepository)(nil).IsProductUnique), arg0)
 }
 
-// GetUserPurchases mocks base method.
-func (m *MockRepository) GetUserPurchases(arg0 int) ([]models.Purchase, error) {
+// SearchProducts mocks base method.
+func (m *MockProductRepository) SearchProducts(arg0 models.ProductSearch) (models.ProductSearchPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserPurchases", arg0)
-	ret0, _ := ret[0].([]models.Purchase)
+	ret := m.ctrl.Call(m, "SearchProducts", arg0)
+	ret0, _ := ret[0].(models.ProductSearchPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserPurchases indicates an expected call of GetUserPurchases.
-func (mr *MockRepositoryMockRecorder) GetUserPurchases(arg0 interface{}) *gomock.Call {
+// SearchProducts indicates an expected call of SearchProducts.
+func (mr *MockProductRepositoryMockRecorder) SearchProducts(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPurchases", reflect.TypeOf((*MockRepository)(nil).GetUserPurchases), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchProducts", reflect.TypeOf((*MockProductRepository)(nil).SearchProducts), arg0)
 }
 
-// IsProductUnique mocks base method.
-func (m *MockRepository) IsProductUnique(arg0 string) (bool, error) {
+// SetDeleteStatus mocks base method.
+func (m *MockProductRepository) SetDeleteStatus(arg0 int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsProductUnique", arg0)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "SetDeleteStatus", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetDeleteStatus indicates an expected call of SetDeleteStatus.
+func (mr *MockProductRepositoryMockRecorder) SetDeleteStatus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleteStatus", reflect.TypeOf((*MockProductRepository)(nil).SetDeleteStatus), arg0)
+}
+
+// UpdateProduct mocks base method.
+func (m *MockProductRepository) UpdateProduct(arg0, arg1 int, arg2 models.Product) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProduct", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IsProductUnique indicates an expected call of IsProductUnique.
-func (mr *MockRepositoryMockRecorder) IsProductUnique(arg0 interface{}) *gomock.Call {
+// UpdateProduct indicates an expected call of UpdateProduct.
+func (mr *MockProductRepositoryMockRecorder) UpdateProduct(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsProductUnique", reflect.TypeOf((*MockRepository)(nil).IsProductUnique), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockProductRepository)(nil).UpdateProduct), arg0, arg1, arg2)
 }
 
-// MakePurchase mocks base method.
-func (m *MockRepository) MakePurchase(arg0 models.Purchase) (int, error) {
+// MockCategoryRepository is a mock of CategoryRepository interface.
+type MockCategoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCategoryRepositoryMockRecorder
+}
+
+// MockCategoryRepositoryMockRecorder is the mock recorder for MockCategoryRepository.
+type MockCategoryRepositoryMockRecorder struct {
+	mock *MockCategoryRepository
+}
+
+// NewMockCategoryRepository creates a new mock instance.
+func NewMockCategoryRepository(ctrl *gomock.Controller) *MockCategoryRepository {
+	mock := &MockCategoryRepository{ctrl: ctrl}
+	mock.recorder = &MockCategoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCategoryRepository) EXPECT() *MockCategoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddCategory mocks base method.
+func (m *MockCategoryRepository) AddCategory(arg0 models.Category) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MakePurchase", arg0)
+	ret := m.ctrl.Call(m, "AddCategory", arg0)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// MakePurchase indicates an expected call of MakePurchase.
-func (mr *MockRepositoryMockRecorder) MakePurchase(arg0 interface{}) *gomock.Call {
+// AddCategory indicates an expected call of AddCategory.
+func (mr *MockCategoryRepositoryMockRecorder) AddCategory(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MakePurchase", reflect.TypeOf((*MockRepository)(nil).MakePurchase), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCategory", reflect.TypeOf((*MockCategoryRepository)(nil).AddCategory), arg0)
 }
 
-// SetDeleteStatus mocks base method.
-func (m *MockRepository) SetDeleteStatus(arg0 int) error {
+// DeleteCategory mocks base method.
+func (m *MockCategoryRepository) DeleteCategory(arg0 int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetDeleteStatus", arg0)
+	ret := m.ctrl.Call(m, "DeleteCategory", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetDeleteStatus indicates an expected call of SetDeleteStatus.
-func (mr *MockRepositoryMockRecorder) SetDeleteStatus(arg0 interface{}) *gomock.Call {
+// DeleteCategory indicates an expected call of DeleteCategory.
+func (mr *MockCategoryRepositoryMockRecorder) DeleteCategory(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleteStatus", reflect.TypeOf((*MockRepository)(nil).SetDeleteStatus), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockCategoryRepository)(nil).DeleteCategory), arg0)
 }
 
-// UpdateProduct mocks base method.
-func (m *MockRepository) UpdateProduct(arg0 int, arg1 models.Product) (int, error) {
+// GetCategories mocks base method.
+func (m *MockCategoryRepository) GetCategories() ([]models.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategories")
+	ret0, _ := ret[0].([]models.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategories indicates an expected call of GetCategories.
+func (mr *MockCategoryRepositoryMockRecorder) GetCategories() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategories", reflect.TypeOf((*MockCategoryRepository)(nil).GetCategories))
+}
+
+// GetCategoryByID mocks base method.
+func (m *MockCategoryRepository) GetCategoryByID(arg0 int) (models.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryByID", arg0)
+	ret0, _ := ret[0].(models.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryByID indicates an expected call of GetCategoryByID.
+func (mr *MockCategoryRepositoryMockRecorder) GetCategoryByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryByID", reflect.TypeOf((*MockCategoryRepository)(nil).GetCategoryByID), arg0)
+}
+
+// GetProductBreadcrumbs mocks base method.
+func (m *MockCategoryRepository) GetProductBreadcrumbs(arg0 int) ([][]models.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductBreadcrumbs", arg0)
+	ret0, _ := ret[0].([][]models.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductBreadcrumbs indicates an expected call of GetProductBreadcrumbs.
+func (mr *MockCategoryRepositoryMockRecorder) GetProductBreadcrumbs(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductBreadcrumbs", reflect.TypeOf((*MockCategoryRepository)(nil).GetProductBreadcrumbs), arg0)
+}
+
+// SetProductCategories mocks base method.
+func (m *MockCategoryRepository) SetProductCategories(arg0 int, arg1 []int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProductCategories", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProductCategories indicates an expected call of SetProductCategories.
+func (mr *MockCategoryRepositoryMockRecorder) SetProductCategories(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProductCategories", reflect.TypeOf((*MockCategoryRepository)(nil).SetProductCategories), arg0, arg1)
+}
+
+// UpdateCategory mocks base method.
+func (m *MockCategoryRepository) UpdateCategory(arg0 int, arg1 models.Category) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCategory", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCategory indicates an expected call of UpdateCategory.
+func (mr *MockCategoryRepositoryMockRecorder) UpdateCategory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockCategoryRepository)(nil).UpdateCategory), arg0, arg1)
+}
+
+// MockVariantRepository is a mock of VariantRepository interface.
+type MockVariantRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockVariantRepositoryMockRecorder
+}
+
+// MockVariantRepositoryMockRecorder is the mock recorder for MockVariantRepository.
+type MockVariantRepositoryMockRecorder struct {
+	mock *MockVariantRepository
+}
+
+// NewMockVariantRepository creates a new mock instance.
+func NewMockVariantRepository(ctrl *gomock.Controller) *MockVariantRepository {
+	mock := &MockVariantRepository{ctrl: ctrl}
+	mock.recorder = &MockVariantRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVariantRepository) EXPECT() *MockVariantRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddVariant mocks base method.
+func (m *MockVariantRepository) AddVariant(arg0 int, arg1 models.ProductVariant) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateProduct", arg0, arg1)
+	ret := m.ctrl.Call(m, "AddVariant", arg0, arg1)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateProduct indicates an expected call of UpdateProduct.
-func (mr *MockRepositoryMockRecorder) UpdateProduct(arg0, arg1 interface{}) *gomock.Call {
+// AddVariant indicates an expected call of AddVariant.
+func (mr *MockVariantRepositoryMockRecorder) AddVariant(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVariant", reflect.TypeOf((*MockVariantRepository)(nil).AddVariant), arg0, arg1)
+}
+
+// DeleteVariant mocks base method.
+func (m *MockVariantRepository) DeleteVariant(arg0, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteVariant", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteVariant indicates an expected call of DeleteVariant.
+func (mr *MockVariantRepositoryMockRecorder) DeleteVariant(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteVariant", reflect.TypeOf((*MockVariantRepository)(nil).DeleteVariant), arg0, arg1)
+}
+
+// GetProductVariants mocks base method.
+func (m *MockVariantRepository) GetProductVariants(arg0 int) ([]models.ProductVariant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProductVariants", arg0)
+	ret0, _ := ret[0].([]models.ProductVariant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProductVariants indicates an expected call of GetProductVariants.
+func (mr *MockVariantRepositoryMockRecorder) GetProductVariants(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductVariants", reflect.TypeOf((*MockVariantRepository)(nil).GetProductVariants), arg0)
+}
+
+// UpdateVariant mocks base method.
+func (m *MockVariantRepository) UpdateVariant(arg0 int, arg1 models.ProductVariant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateVariant", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateVariant indicates an expected call of UpdateVariant.
+func (mr *MockVariantRepositoryMockRecorder) UpdateVariant(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockRepository)(nil).UpdateProduct), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateVariant", reflect.TypeOf((*MockVariantRepository)(nil).UpdateVariant), arg0, arg1)
 }
 
-// MockPurchaseRepository is a mock of PurchaseRepository interface.
-type MockPurchaseRepository struct {
+// MockStockRepository is a mock of StockRepository interface.
+type MockStockRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockPurchaseRepositoryMockRecorder
+	recorder *MockStockRepositoryMockRecorder
 }
 
-// MockPurchaseRepositoryMockRecorder is the mock recorder for MockPurchaseRepository.
-type MockPurchaseRepositoryMockRecorder struct {
-	mock *MockPurchaseRepository
+// MockStockRepositoryMockRecorder is the mock recorder for MockStockRepository.
+type MockStockRepositoryMockRecorder struct {
+	mock *MockStockRepository
 }
 
-// NewMockPurchaseRepository creates a new mock instance.
-func NewMockPurchaseRepository(ctrl *gomock.Controller) *MockPurchaseRepository {
-	mock := &MockPurchaseRepository{ctrl: ctrl}
-	mock.recorder = &MockPurchaseRepositoryMockRecorder{mock}
+// NewMockStockRepository creates a new mock instance.
+func NewMockStockRepository(ctrl *gomock.Controller) *MockStockRepository {
+	mock := &MockStockRepository{ctrl: ctrl}
+	mock.recorder = &MockStockRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockPurchaseRepository) EXPECT() *MockPurchaseRepositoryMockRecorder {
+func (m *MockStockRepository) EXPECT() *MockStockRepositoryMockRecorder {
 	return m.recorder
 }
 
-// GetProductPurchases mocks base method.
-func (m *MockPurchaseRepository) GetProductPurchases(arg0 int) ([]models.Purchase, error) {
+// AdjustStock mocks base method.
+func (m *MockStockRepository) AdjustStock(arg0, arg1 int, arg2 models.StockAdjustment) (models.StockMovement, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProductPurchases", arg0)
-	ret0, _ := ret[0].([]models.Purchase)
+	ret := m.ctrl.Call(m, "AdjustStock", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.StockMovement)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetProductPurchases indicates an expected call of GetProductPurchases.
-func (mr *MockPurchaseRepositoryMockRecorder) GetProductPurchases(arg0 interface{}) *gomock.Call {
+// AdjustStock indicates an expected call of AdjustStock.
+func (mr *MockStockRepositoryMockRecorder) AdjustStock(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductPurchases", reflect.TypeOf((*MockPurchaseRepository)(nil).GetProductPurchases), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustStock", reflect.TypeOf((*MockStockRepository)(nil).AdjustStock), arg0, arg1, arg2)
 }
 
-// GetUserPurchases mocks base method.
-func (m *MockPurchaseRepository) GetUserPurchases(arg0 int) ([]models.Purchase, error) {
+// GetStockMovements mocks base method.
+func (m *MockStockRepository) GetStockMovements(arg0 int, arg1, arg2 time.Time) ([]models.StockMovement, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserPurchases", arg0)
-	ret0, _ := ret[0].([]models.Purchase)
+	ret := m.ctrl.Call(m, "GetStockMovements", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.StockMovement)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserPurchases indicates an expected call of GetUserPurchases.
-func (mr *MockPurchaseRepositoryMockRecorder) GetUserPurchases(arg0 interface{}) *gomock.Call {
+// GetStockMovements indicates an expected call of GetStockMovements.
+func (mr *MockStockRepositoryMockRecorder) GetStockMovements(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPurchases", reflect.TypeOf((*MockPurchaseRepository)(nil).GetUserPurchases), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockMovements", reflect.TypeOf((*MockStockRepository)(nil).GetStockMovements), arg0, arg1, arg2)
 }
 
-// MakePurchase mocks base method.
-func (m *MockPurchaseRepository) MakePurchase(arg0 models.Purchase) (int, error) {
+// GetStockReport mocks base method.
+func (m *MockStockRepository) GetStockReport(arg0 int, arg1 time.Time) (models.StockReport, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MakePurchase", arg0)
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "GetStockReport", arg0, arg1)
+	ret0, _ := ret[0].(models.StockReport)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// MakePurchase indicates an expected call of MakePurchase.
-func (mr *MockPurchaseRepositoryMockRecorder) MakePurchase(arg0 interface{}) *gomock.Call {
+// GetStockReport indicates an expected call of GetStockReport.
+func (mr *MockStockRepositoryMockRecorder) GetStockReport(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MakePurchase", reflect.TypeOf((*MockPurchaseRepository)(nil).MakePurchase), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockReport", reflect.TypeOf((*MockStockRepository)(nil).GetStockReport), arg0, arg1)
 }
 
-// MockProductRepository is a mock of ProductRepository interface.
-type MockProductRepository struct {
+// MockWarehouseRepository is a mock of WarehouseRepository interface.
+type MockWarehouseRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockProductRepositoryMockRecorder
+	recorder *MockWarehouseRepositoryMockRecorder
 }
 
-// MockProductRepositoryMockRecorder is the mock recorder for MockProductRepository.
-type MockProductRepositoryMockRecorder struct {
-	mock *MockProductRepository
+// MockWarehouseRepositoryMockRecorder is the mock recorder for MockWarehouseRepository.
+type MockWarehouseRepositoryMockRecorder struct {
+	mock *MockWarehouseRepository
 }
 
-// NewMockProductRepository creates a new mock instance.
-func NewMockProductRepository(ctrl *gomock.Controller) *MockProductRepository {
-	mock := &MockProductRepository{ctrl: ctrl}
-	mock.recorder = &MockProductRepositoryMockRecorder{mock}
+// NewMockWarehouseRepository creates a new mock instance.
+func NewMockWarehouseRepository(ctrl *gomock.Controller) *MockWarehouseRepository {
+	mock := &MockWarehouseRepository{ctrl: ctrl}
+	mock.recorder = &MockWarehouseRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockProductRepository) EXPECT() *MockProductRepositoryMockRecorder {
+func (m *MockWarehouseRepository) EXPECT() *MockWarehouseRepositoryMockRecorder {
 	return m.recorder
 }
 
-// AddProduct mocks base method.
-func (m *MockProductRepository) AddProduct(arg0 models.Product) (int, error) {
+// AddWarehouse mocks base method.
+func (m *MockWarehouseRepository) AddWarehouse(arg0 models.Warehouse) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddProduct", arg0)
+	ret := m.ctrl.Call(m, "AddWarehouse", arg0)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AddProduct indicates an expected call of AddProduct.
-func (mr *MockProductRepositoryMockRecorder) AddProduct(arg0 interface{}) *gomock.Call {
+// AddWarehouse indicates an expected call of AddWarehouse.
+func (mr *MockWarehouseRepositoryMockRecorder) AddWarehouse(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProduct", reflect.TypeOf((*MockProductRepository)(nil).AddProduct), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWarehouse", reflect.TypeOf((*MockWarehouseRepository)(nil).AddWarehouse), arg0)
 }
 
-// DeleteProducts mocks base method.
-func (m *MockProductRepository) DeleteProducts() error {
+// GetProductAvailability mocks base method.
+func (m *MockWarehouseRepository) GetProductAvailability(arg0 int) (models.Availability, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteProducts")
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetProductAvailability", arg0)
+	ret0, _ := ret[0].(models.Availability)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteProducts indicates an expected call of DeleteProducts.
-func (mr *MockProductRepositoryMockRecorder) DeleteProducts() *gomock.Call {
+// GetProductAvailability indicates an expected call of GetProductAvailability.
+func (mr *MockWarehouseRepositoryMockRecorder) GetProductAvailability(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProducts", reflect.TypeOf((*MockProductRepository)(nil).DeleteProducts))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductAvailability", reflect.TypeOf((*MockWarehouseRepository)(nil).GetProductAvailability), arg0)
 }
 
-// GetAllProducts mocks base method.
-func (m *MockProductRepository) GetAllProducts() ([]models.Product, error) {
+// GetStockTransfers mocks base method.
+func (m *MockWarehouseRepository) GetStockTransfers(arg0 int) ([]models.StockTransfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllProducts")
-	ret0, _ := ret[0].([]models.Product)
+	ret := m.ctrl.Call(m, "GetStockTransfers", arg0)
+	ret0, _ := ret[0].([]models.StockTransfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllProducts indicates an expected call of GetAllProducts.
-func (mr *MockProductRepositoryMockRecorder) GetAllProducts() *gomock.Call {
+// GetStockTransfers indicates an expected call of GetStockTransfers.
+func (mr *MockWarehouseRepositoryMockRecorder) GetStockTransfers(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllProducts", reflect.TypeOf((*MockProductRepository)(nil).GetAllProducts))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockTransfers", reflect.TypeOf((*MockWarehouseRepository)(nil).GetStockTransfers), arg0)
 }
 
-// GetProductByID mocks base method.
-func (m *MockProductRepository) GetProductByID(arg0 int) (models.Product, error) {
+// GetWarehouses mocks base method.
+func (m *MockWarehouseRepository) GetWarehouses() ([]models.Warehouse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetProductByID", arg0)
-	ret0, _ := ret[0].(models.Product)
+	ret := m.ctrl.Call(m, "GetWarehouses")
+	ret0, _ := ret[0].([]models.Warehouse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetProductByID indicates an expected call of GetProductByID.
-func (mr *MockProductRepositoryMockRecorder) GetProductByID(arg0 interface{}) *gomock.Call {
+// GetWarehouses indicates an expected call of GetWarehouses.
+func (mr *MockWarehouseRepositoryMockRecorder) GetWarehouses() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProductByID", reflect.TypeOf((*MockProductRepository)(nil).GetProductByID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWarehouses", reflect.TypeOf((*MockWarehouseRepository)(nil).GetWarehouses))
 }
 
-// IsProductUnique mocks base method.
-func (m *MockProductRepository) IsProductUnique(arg0 string) (bool, error) {
+// TransferStock mocks base method.
+func (m *MockWarehouseRepository) TransferStock(arg0 int, arg1 models.StockTransferRequest) (models.StockTransfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsProductUnique", arg0)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "TransferStock", arg0, arg1)
+	ret0, _ := ret[0].(models.StockTransfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IsProductUnique indicates an expected call of IsProductUnique.
-func (mr *MockProductRepositoryMockRecorder) IsProductUnique(arg0 interface{}) *gomock.Call {
+// TransferStock indicates an expected call of TransferStock.
+func (mr *MockWarehouseRepositoryMockRecorder) TransferStock(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsProductUnique", reflect.TypeOf((*MockProductRepository)(nil).IsProductUnique), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferStock", reflect.TypeOf((*MockWarehouseRepository)(nil).TransferStock), arg0, arg1)
 }
 
-// SetDeleteStatus mocks base method.
-func (m *MockProductRepository) SetDeleteStatus(arg0 int) error {
+// UpdateWarehouse mocks base method.
+func (m *MockWarehouseRepository) UpdateWarehouse(arg0 models.Warehouse) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetDeleteStatus", arg0)
+	ret := m.ctrl.Call(m, "UpdateWarehouse", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SetDeleteStatus indicates an expected call of SetDeleteStatus.
-func (mr *MockProductRepositoryMockRecorder) SetDeleteStatus(arg0 interface{}) *gomock.Call {
+// UpdateWarehouse indicates an expected call of UpdateWarehouse.
+func (mr *MockWarehouseRepositoryMockRecorder) UpdateWarehouse(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDeleteStatus", reflect.TypeOf((*MockProductRepository)(nil).SetDeleteStatus), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWarehouse", reflect.TypeOf((*MockWarehouseRepository)(nil).UpdateWarehouse), arg0)
 }
 
-// UpdateProduct mocks base method.
-func (m *MockProductRepository) UpdateProduct(arg0 int, arg1 models.Product) (int, error) {
+// MockReorderRepository is a mock of ReorderRepository interface.
+type MockReorderRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReorderRepositoryMockRecorder
+}
+
+// MockReorderRepositoryMockRecorder is the mock recorder for MockReorderRepository.
+type MockReorderRepositoryMockRecorder struct {
+	mock *MockReorderRepository
+}
+
+// NewMockReorderRepository creates a new mock instance.
+func NewMockReorderRepository(ctrl *gomock.Controller) *MockReorderRepository {
+	mock := &MockReorderRepository{ctrl: ctrl}
+	mock.recorder = &MockReorderRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReorderRepository) EXPECT() *MockReorderRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetLowStock mocks base method.
+func (m *MockReorderRepository) GetLowStock() ([]models.LowStockItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLowStock")
+	ret0, _ := ret[0].([]models.LowStockItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLowStock indicates an expected call of GetLowStock.
+func (mr *MockReorderRepositoryMockRecorder) GetLowStock() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLowStock", reflect.TypeOf((*MockReorderRepository)(nil).GetLowStock))
+}
+
+// GetRestockRequests mocks base method.
+func (m *MockReorderRepository) GetRestockRequests(arg0 string) ([]models.RestockRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRestockRequests", arg0)
+	ret0, _ := ret[0].([]models.RestockRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRestockRequests indicates an expected call of GetRestockRequests.
+func (mr *MockReorderRepositoryMockRecorder) GetRestockRequests(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRestockRequests", reflect.TypeOf((*MockReorderRepository)(nil).GetRestockRequests), arg0)
+}
+
+// SetReorderPolicy mocks base method.
+func (m *MockReorderRepository) SetReorderPolicy(arg0 int, arg1 models.ReorderPolicy) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReorderPolicy", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReorderPolicy indicates an expected call of SetReorderPolicy.
+func (mr *MockReorderRepositoryMockRecorder) SetReorderPolicy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReorderPolicy", reflect.TypeOf((*MockReorderRepository)(nil).SetReorderPolicy), arg0, arg1)
+}
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ProcessOutbox mocks base method.
+func (m *MockOutboxRepository) ProcessOutbox(arg0 int, arg1 func(models.OutboxMessage) error) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateProduct", arg0, arg1)
+	ret := m.ctrl.Call(m, "ProcessOutbox", arg0, arg1)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateProduct indicates an expected call of UpdateProduct.
-func (mr *MockProductRepositoryMockRecorder) UpdateProduct(arg0, arg1 interface{}) *gomock.Call {
+// ProcessOutbox indicates an expected call of ProcessOutbox.
+func (mr *MockOutboxRepositoryMockRecorder) ProcessOutbox(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProduct", reflect.TypeOf((*MockProductRepository)(nil).UpdateProduct), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessOutbox", reflect.TypeOf((*MockOutboxRepository)(nil).ProcessOutbox), arg0, arg1)
 }
@@ -2,19 +2,24 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/streadway/amqp"
 
+	"github.com/wileytor/go-market/common/broker"
+	"github.com/wileytor/go-market/common/jwt"
+	"github.com/wileytor/go-market/common/models"
 	"github.com/wileytor/go-market/common/rabbitmq"
 	"github.com/wileytor/go-market/products/internal/config"
 	"github.com/wileytor/go-market/products/internal/logger"
+	"github.com/wileytor/go-market/products/internal/payments"
 	"github.com/wileytor/go-market/products/internal/repository"
 	"github.com/wileytor/go-market/products/internal/server"
 	"github.com/wileytor/go-market/products/internal/server/routes"
@@ -41,28 +46,11 @@ func main() {
 	zlog := logger.SetupLogger(cfg.DebugFlag)
 	zlog.Debug().Any("config", cfg).Msg("Check cfg value")
 
-	rabbitURL := os.Getenv("RABBITMQ_URL")
-	rabbit, err := rabbitmq.InitRabbit(rabbitURL)
+	messageBroker, err := initBroker(cfg.Broker)
 	if err != nil {
-		zlog.Fatal().Err(err).Msg("Failed to connect to RabbitMQ")
-	}
-	defer rabbit.CloseRabbit()
-
-	queueName := "user_check_queue"
-	args := amqp.Table{
-		"x-max-length": 500,
-	}
-	_, err = rabbit.Channel.QueueDeclare(
-		queueName, // имя очереди
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		args,      // arguments
-	)
-	if err != nil {
-		return
+		zlog.Fatal().Err(err).Msg("Failed to init message broker")
 	}
+	defer messageBroker.Close()
 
 	err = repository.EnsureMarketDatabaseExists(cfg.DBAddr)
 	if err != nil {
@@ -86,9 +74,46 @@ func main() {
 		panic(err)
 	}
 	defer dbStorage.Close()
+	dbStorage.CartTTL = cfg.CartTTL
+	dbStorage.IdempotencyTTL = cfg.IdempotencyTTL
+	dbStorage.ReservationTTL = cfg.ReservationTTL
+	dbStorage.PaymentGracePeriod = cfg.PaymentGracePeriod
+	if !models.IsAllocationStrategy(cfg.AllocationStrategy) {
+		zlog.Fatal().Str("strategy", cfg.AllocationStrategy).Msg("Unknown warehouse allocation strategy")
+	}
+	dbStorage.AllocationStrategy = cfg.AllocationStrategy
+
+	jwksClient := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			// сертификаты сервисов самоподписанные, как и для проксирования в nginx
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.JWKSInsecure}, //nolint:gosec // opt-in for self-signed certs
+		},
+	}
+	tokens := jwt.NewJWKSClient(cfg.JWKSURL, jwksClient)
 
 	group, gCtx := errgroup.WithContext(ctx)
-	srv := server.NewServer(gCtx, dbStorage, zlog, rabbit)
+	srv := server.NewServer(gCtx, dbStorage, zlog, messageBroker, tokens)
+	if cfg.RevocationCheck {
+		if cfg.Broker == "memory" {
+			// сервис auth недоступен через брокер в памяти другого процесса
+			zlog.Warn().Msg("Revocation check is disabled for in-memory broker")
+		} else {
+			srv.EnableRevocationCheck(cfg.RevocationCacheTTL)
+		}
+	}
+	srv.Payments, err = initPayments(cfg)
+	if err != nil {
+		zlog.Fatal().Err(err).Msg("Failed to init payment provider")
+	}
+	if fake, ok := srv.Payments.(*payments.Fake); ok {
+		// фейковый провайдер доставляет вебхуки прямо в сервер, минуя сеть
+		fake.OnWebhook = func(header http.Header, payload []byte) {
+			if err := srv.ProcessPaymentWebhook(gCtx, header, payload); err != nil {
+				zlog.Error().Err(err).Msg("Failed to process payment webhook")
+			}
+		}
+	}
 	group.Go(func() error {
 		r := routes.SetupMarketRoutes(srv)
 		zlog.Info().Msg("Server was started")
@@ -99,6 +124,21 @@ func main() {
 		return nil
 	})
 
+	group.Go(func() error {
+		srv.RelayOutbox(gCtx, cfg.OutboxInterval)
+		return nil
+	})
+
+	group.Go(func() error {
+		srv.DeleteExpired(gCtx, server.CleanupInterval)
+		return nil
+	})
+
+	group.Go(func() error {
+		srv.SweepReservations(gCtx, server.ReservationSweepInterval)
+		return nil
+	})
+
 	group.Go(func() error {
 		err := <-srv.ErrorChan
 		return err
@@ -108,13 +148,45 @@ func main() {
 		return gCtx.Err()
 	})
 
-	if err := group.Wait(); err != nil {
+	err = group.Wait()
+	srv.Wait()
+	if err != nil {
 		zlog.Fatal().Err(err).Msg("Error during server shutdown")
 	} else {
 		zlog.Info().Msg("Server excited gracefully")
 	}
 }
 
+// initBroker подключается к RabbitMQ или создает брокер в памяти для локального запуска
+func initBroker(kind string) (broker.Broker, error) {
+	if kind == "memory" {
+		return broker.NewMemory(), nil
+	}
+	rabbit, err := rabbitmq.InitRabbit(os.Getenv("RABBITMQ_URL"))
+	if err != nil {
+		return nil, err
+	}
+	if err := rabbit.DeclareQueue(rabbitmq.UserCheckQueue); err != nil {
+		rabbit.Close()
+		return nil, err
+	}
+	if err := rabbit.DeclareExchange(rabbitmq.EventsExchange); err != nil {
+		rabbit.Close()
+		return nil, err
+	}
+	return rabbit, nil
+}
+
+// initPayments создает платежного провайдера
+func initPayments(cfg config.Config) (payments.Provider, error) {
+	switch cfg.PaymentProvider {
+	case payments.FakeProviderName:
+		return payments.NewFake(cfg.PaymentWebhookSecret, cfg.FakeWebhookDelay), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.PaymentProvider)
+	}
+}
+
 func initDB(ctx context.Context, addr string) (*pgxpool.Pool, error) {
 	var pool *pgxpool.Pool
 	var err error
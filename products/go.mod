@@ -51,7 +51,6 @@ require (
 
 require (
 	github.com/gin-gonic/gin v1.10.0
-	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/golang-migrate/migrate/v4 v4.18.1
 	github.com/huandu/go-sqlbuilder v1.30.1
 	github.com/jackc/pgpassfile v1.0.0 // indirect
@@ -3,6 +3,8 @@ package config
 import (
 	"flag"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -11,6 +13,22 @@ type Config struct {
 	MPath        string
 	DebugFlag    bool
 	RabbitMQHost string
+	Broker       string
+	JWKSURL      string
+	JWKSInsecure bool
+
+	RevocationCheck    bool
+	RevocationCacheTTL time.Duration
+	OutboxInterval     time.Duration
+	CartTTL            time.Duration
+	IdempotencyTTL     time.Duration
+	ReservationTTL     time.Duration
+	AllocationStrategy string
+
+	PaymentProvider      string
+	PaymentWebhookSecret string
+	PaymentGracePeriod   time.Duration
+	FakeWebhookDelay     time.Duration
 }
 
 const (
@@ -18,6 +36,20 @@ const (
 	defaultDbDSN        = "postgres://nastya:pgspgs@db:5432/postgres?sslmode=disable"
 	defaultMigratePath  = "migrations"
 	defaultRabbitMQHost = "rabbitmq"
+	defaultBroker       = "amqp"
+	defaultJWKSURL      = "https://auth:8082/.well-known/jwks.json"
+
+	defaultRevocationCacheTTL = 30 * time.Second
+	defaultOutboxInterval     = time.Second
+	defaultCartTTL            = 72 * time.Hour
+	defaultIdempotencyTTL     = 24 * time.Hour
+	defaultReservationTTL     = 15 * time.Minute
+	defaultAllocationStrategy = "priority"
+
+	defaultPaymentProvider      = "fake"
+	defaultPaymentWebhookSecret = "fake-webhook-secret"
+	defaultPaymentGracePeriod   = 15 * time.Minute
+	defaultFakeWebhookDelay     = 2 * time.Second
 )
 
 func ReadConfig() Config {
@@ -25,12 +57,31 @@ func ReadConfig() Config {
 	var dbAddr string
 	var migratePath string
 	var rabbitMQHost string
+	var brokerKind string
+	var jwksURL string
+	var allocationStrategy string
+	var paymentProvider string
+	var paymentWebhookSecret string
 	debug := flag.Bool("debug", false, "enable debug logger level")
+	jwksInsecure := flag.Bool("jwks-insecure", false, "skip TLS verification when fetching JWKS")
+	revocationCheck := flag.Bool("revocation-check", false, "check token revocation in auth service")
+	revocationCacheTTL := flag.Duration("revocation-ttl", defaultRevocationCacheTTL, "cache ttl of token revocation checks")
+	outboxInterval := flag.Duration("outbox-interval", defaultOutboxInterval, "how often pending outbox events are published")
+	cartTTL := flag.Duration("cart-ttl", defaultCartTTL, "how long an unchanged cart is kept")
+	idempotencyTTL := flag.Duration("idempotency-ttl", defaultIdempotencyTTL, "how long responses to requests with Idempotency-Key are kept")
+	reservationTTL := flag.Duration("reservation-ttl", defaultReservationTTL, "how long stock is held for an unpaid order")
+	paymentGracePeriod := flag.Duration("payment-grace-period", defaultPaymentGracePeriod, "how long an unfinished payment may outlive the order reservation")
+	fakeWebhookDelay := flag.Duration("fake-webhook-delay", defaultFakeWebhookDelay, "delay of webhooks sent by the fake payment provider")
 
 	flag.StringVar(&addr, "addr", defaultAddr, "Server address") // mani.exe -help
 	flag.StringVar(&dbAddr, "db", defaultDbDSN, "database connection addres")
 	flag.StringVar(&migratePath, "m", defaultMigratePath, "path to migrations")
 	flag.StringVar(&rabbitMQHost, "rabbitMQ", defaultRabbitMQHost, "rabbitMQ host to connect")
+	flag.StringVar(&brokerKind, "broker", defaultBroker, "message broker: amqp or memory")
+	flag.StringVar(&jwksURL, "jwks", defaultJWKSURL, "auth service JWKS url")
+	flag.StringVar(&allocationStrategy, "allocation-strategy", defaultAllocationStrategy, "warehouse allocation strategy: priority, region or largest")
+	flag.StringVar(&paymentProvider, "payment-provider", defaultPaymentProvider, "payment provider: fake")
+	flag.StringVar(&paymentWebhookSecret, "payment-webhook-secret", defaultPaymentWebhookSecret, "secret used to sign payment webhooks")
 	flag.Parse()
 
 	if temp := os.Getenv("SERVER_ADDR"); temp != "" {
@@ -45,6 +96,48 @@ func ReadConfig() Config {
 	if temp := os.Getenv("RABBITMQ_HOST"); temp != "" {
 		rabbitMQHost = temp
 	}
+	if temp := os.Getenv("BROKER"); temp != "" {
+		brokerKind = temp
+	}
+	if temp := os.Getenv("JWKS_URL"); temp != "" {
+		jwksURL = temp
+	}
+	if temp, err := strconv.ParseBool(os.Getenv("JWKS_INSECURE")); err == nil {
+		*jwksInsecure = temp
+	}
+	if temp, err := strconv.ParseBool(os.Getenv("REVOCATION_CHECK")); err == nil {
+		*revocationCheck = temp
+	}
+	if temp, err := time.ParseDuration(os.Getenv("REVOCATION_CACHE_TTL")); err == nil {
+		*revocationCacheTTL = temp
+	}
+	if temp, err := time.ParseDuration(os.Getenv("OUTBOX_INTERVAL")); err == nil {
+		*outboxInterval = temp
+	}
+	if temp, err := time.ParseDuration(os.Getenv("CART_TTL")); err == nil {
+		*cartTTL = temp
+	}
+	if temp, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL")); err == nil {
+		*idempotencyTTL = temp
+	}
+	if temp, err := time.ParseDuration(os.Getenv("RESERVATION_TTL")); err == nil {
+		*reservationTTL = temp
+	}
+	if temp := os.Getenv("ALLOCATION_STRATEGY"); temp != "" {
+		allocationStrategy = temp
+	}
+	if temp := os.Getenv("PAYMENT_PROVIDER"); temp != "" {
+		paymentProvider = temp
+	}
+	if temp := os.Getenv("PAYMENT_WEBHOOK_SECRET"); temp != "" {
+		paymentWebhookSecret = temp
+	}
+	if temp, err := time.ParseDuration(os.Getenv("PAYMENT_GRACE_PERIOD")); err == nil {
+		*paymentGracePeriod = temp
+	}
+	if temp, err := time.ParseDuration(os.Getenv("FAKE_WEBHOOK_DELAY")); err == nil {
+		*fakeWebhookDelay = temp
+	}
 
 	return Config{
 		Addr:         addr,
@@ -52,5 +145,21 @@ func ReadConfig() Config {
 		MPath:        migratePath,
 		DebugFlag:    *debug,
 		RabbitMQHost: rabbitMQHost,
+		Broker:       brokerKind,
+		JWKSURL:      jwksURL,
+		JWKSInsecure: *jwksInsecure,
+
+		RevocationCheck:    *revocationCheck,
+		RevocationCacheTTL: *revocationCacheTTL,
+		OutboxInterval:     *outboxInterval,
+		CartTTL:            *cartTTL,
+		IdempotencyTTL:     *idempotencyTTL,
+		ReservationTTL:     *reservationTTL,
+		AllocationStrategy: allocationStrategy,
+
+		PaymentProvider:      paymentProvider,
+		PaymentWebhookSecret: paymentWebhookSecret,
+		PaymentGracePeriod:   *paymentGracePeriod,
+		FakeWebhookDelay:     *fakeWebhookDelay,
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -26,22 +27,52 @@ func TestReadConfig(t *testing.T) {
 			flags: []string{"test", "--addr", "testaddr", "--debug"},
 			want: want{
 				cfg: Config{
-					Addr:      "testaddr",
-					DBAddr:    defaultDbDSN,
-					MPath:     defaultMigratePath,
-					DebugFlag: true,
+					Addr:         "testaddr",
+					DBAddr:       defaultDbDSN,
+					MPath:        defaultMigratePath,
+					DebugFlag:    true,
+					RabbitMQHost: defaultRabbitMQHost,
+					Broker:       defaultBroker,
+					JWKSURL:      defaultJWKSURL,
+
+					RevocationCacheTTL: defaultRevocationCacheTTL,
+					OutboxInterval:     defaultOutboxInterval,
+					CartTTL:            defaultCartTTL,
+					IdempotencyTTL:     defaultIdempotencyTTL,
+					ReservationTTL:     defaultReservationTTL,
+					AllocationStrategy: defaultAllocationStrategy,
+
+					PaymentProvider:      defaultPaymentProvider,
+					PaymentWebhookSecret: defaultPaymentWebhookSecret,
+					PaymentGracePeriod:   defaultPaymentGracePeriod,
+					FakeWebhookDelay:     defaultFakeWebhookDelay,
 				},
 			},
 		},
 		{
 			name:  "TestReadConfig func; Test 2",
-			flags: []string{"test", "--addr", "testaddr", "--db", "dbaddr", "--m", "mPath"},
+			flags: []string{"test", "--addr", "testaddr", "--db", "dbaddr", "--m", "mPath", "--outbox-interval", "5s", "--idempotency-ttl", "1h", "--fake-webhook-delay", "0s", "--allocation-strategy", "largest"},
 			want: want{
 				cfg: Config{
-					Addr:      "testaddr",
-					DBAddr:    "dbaddr",
-					MPath:     "mPath",
-					DebugFlag: false,
+					Addr:         "testaddr",
+					DBAddr:       "dbaddr",
+					MPath:        "mPath",
+					DebugFlag:    false,
+					RabbitMQHost: defaultRabbitMQHost,
+					Broker:       defaultBroker,
+					JWKSURL:      defaultJWKSURL,
+
+					RevocationCacheTTL: defaultRevocationCacheTTL,
+					OutboxInterval:     5 * time.Second,
+					CartTTL:            defaultCartTTL,
+					IdempotencyTTL:     time.Hour,
+					ReservationTTL:     defaultReservationTTL,
+					AllocationStrategy: "largest",
+
+					PaymentProvider:      defaultPaymentProvider,
+					PaymentWebhookSecret: defaultPaymentWebhookSecret,
+					PaymentGracePeriod:   defaultPaymentGracePeriod,
+					FakeWebhookDelay:     0,
 				},
 			},
 		},
@@ -51,13 +82,33 @@ func TestReadConfig(t *testing.T) {
 			envSetup: func() {
 				t.Setenv("SERVER_ADDR", "envSrvAddr")
 				t.Setenv("DB_DSN", "envDbAddr")
+				t.Setenv("CART_TTL", "24h")
+				t.Setenv("PAYMENT_WEBHOOK_SECRET", "envSecret")
+				t.Setenv("RESERVATION_TTL", "30m")
+				t.Setenv("PAYMENT_GRACE_PERIOD", "1h")
+				t.Setenv("ALLOCATION_STRATEGY", "region")
 			},
 			want: want{
 				cfg: Config{
-					Addr:      "envSrvAddr",
-					DBAddr:    "envDbAddr",
-					MPath:     defaultMigratePath,
-					DebugFlag: true,
+					Addr:         "envSrvAddr",
+					DBAddr:       "envDbAddr",
+					MPath:        defaultMigratePath,
+					DebugFlag:    true,
+					RabbitMQHost: defaultRabbitMQHost,
+					Broker:       defaultBroker,
+					JWKSURL:      defaultJWKSURL,
+
+					RevocationCacheTTL: defaultRevocationCacheTTL,
+					OutboxInterval:     defaultOutboxInterval,
+					CartTTL:            24 * time.Hour,
+					IdempotencyTTL:     defaultIdempotencyTTL,
+					ReservationTTL:     30 * time.Minute,
+					AllocationStrategy: "region",
+
+					PaymentProvider:      defaultPaymentProvider,
+					PaymentWebhookSecret: "envSecret",
+					PaymentGracePeriod:   time.Hour,
+					FakeWebhookDelay:     defaultFakeWebhookDelay,
 				},
 			},
 		},
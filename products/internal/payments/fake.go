@@ -0,0 +1,236 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wileytor/go-market/common/models"
+)
+
+// Тестовые токены фейкового провайдера. Любой другой токен отклоняется как invalid_token.
+const (
+	FakeTokenApproved          = "tok_approved"
+	FakeTokenDeclined          = "tok_declined"
+	FakeTokenInsufficientFunds = "tok_insufficient_funds"
+	FakeToken3DS               = "tok_3ds"
+	FakeTokenDelayed           = "tok_delayed"
+	FakeTokenDelayedDecline    = "tok_delayed_decline"
+)
+
+const (
+	FakeProviderName    = "fake"
+	FakeSignatureHeader = "X-Fake-Signature"
+	// FakeChallengePath - адрес страницы проверки 3-D Secure, к нему добавляется id платежа у провайдера
+	FakeChallengePath = "/payments/fake/challenge/"
+)
+
+var (
+	ErrFakePaymentNotFound = errors.New("fake payment not found")
+	ErrFakeInvalidState    = errors.New("fake payment is in another state")
+	ErrFakeAmountExceeded  = errors.New("amount exceeds authorized amount")
+)
+
+// Fake - детерминированный провайдер для локального запуска и тестов. Результат задается токеном:
+// отказ, проверка 3-D Secure или решение, приходящее вебхуком через delay.
+type Fake struct {
+	secret []byte
+	delay  time.Duration
+	// OnWebhook доставляет вебхук. Если не задан, вебхуки не отправляются.
+	OnWebhook func(header http.Header, payload []byte)
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+}
+
+type fakePayment struct {
+	status string
+	amount models.Money
+	// refundKey - ключ идемпотентности выполненного возврата
+	refundKey string
+}
+
+type fakeWebhook struct {
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+func NewFake(secret string, delay time.Duration) *Fake {
+	return &Fake{
+		secret:   []byte(secret),
+		delay:    delay,
+		payments: make(map[string]*fakePayment),
+	}
+}
+
+func (f *Fake) Name() string {
+	return FakeProviderName
+}
+
+func (f *Fake) Authorize(_ context.Context, req AuthorizeRequest) (Authorization, error) {
+	auth := Authorization{ProviderPaymentID: fmt.Sprintf("fake_%d", req.PaymentID)}
+	payment := &fakePayment{amount: req.Amount}
+
+	switch req.Token {
+	case FakeTokenApproved:
+		payment.status = models.PaymentStatusAuthorized
+		auth.Result = AuthorizationApproved
+	case FakeTokenDeclined:
+		auth.Result, auth.DeclineReason = AuthorizationDeclined, "card_declined"
+	case FakeTokenInsufficientFunds:
+		auth.Result, auth.DeclineReason = AuthorizationDeclined, "insufficient_funds"
+	case FakeToken3DS:
+		payment.status = models.PaymentStatusRequiresAction
+		auth.Result = AuthorizationRequiresAction
+		auth.ActionURL = FakeChallengePath + auth.ProviderPaymentID
+	case FakeTokenDelayed:
+		payment.status = models.PaymentStatusAuthorized
+		auth.Result = AuthorizationPending
+		f.sendWebhook(fakeWebhook{PaymentID: auth.ProviderPaymentID, Status: models.PaymentStatusAuthorized})
+	case FakeTokenDelayedDecline:
+		auth.Result = AuthorizationPending
+		f.sendWebhook(fakeWebhook{PaymentID: auth.ProviderPaymentID, Status: models.PaymentStatusFailed, Reason: "card_declined"})
+	default:
+		auth.Result, auth.DeclineReason = AuthorizationDeclined, "invalid_token"
+	}
+
+	if payment.status != "" {
+		f.mu.Lock()
+		f.payments[auth.ProviderPaymentID] = payment
+		f.mu.Unlock()
+	}
+	return auth, nil
+}
+
+// CompleteChallenge завершает проверку 3-D Secure и отправляет вебхук с ее итогом
+func (f *Fake) CompleteChallenge(providerPaymentID string, success bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	payment, ok := f.payments[providerPaymentID]
+	if !ok {
+		return ErrFakePaymentNotFound
+	}
+	if payment.status != models.PaymentStatusRequiresAction {
+		return ErrFakeInvalidState
+	}
+	if !success {
+		delete(f.payments, providerPaymentID)
+		f.sendWebhook(fakeWebhook{PaymentID: providerPaymentID, Status: models.PaymentStatusFailed, Reason: "authentication_failed"})
+		return nil
+	}
+	payment.status = models.PaymentStatusAuthorized
+	f.sendWebhook(fakeWebhook{PaymentID: providerPaymentID, Status: models.PaymentStatusAuthorized})
+	return nil
+}
+
+func (f *Fake) Capture(_ context.Context, providerPaymentID string, amount models.Money) error {
+	return f.move(providerPaymentID, amount, models.PaymentStatusAuthorized, models.PaymentStatusCaptured)
+}
+
+// Refund возвращает списанный платеж. Повтор с ключом выполненного возврата ничего не меняет.
+func (f *Fake) Refund(_ context.Context, providerPaymentID string, amount models.Money, idempotencyKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if payment, ok := f.payments[providerPaymentID]; ok && payment.status == models.PaymentStatusRefunded && payment.refundKey == idempotencyKey {
+		return nil
+	}
+	if err := f.moveLocked(providerPaymentID, amount, models.PaymentStatusCaptured, models.PaymentStatusRefunded); err != nil {
+		return err
+	}
+	f.payments[providerPaymentID].refundKey = idempotencyKey
+	return nil
+}
+
+func (f *Fake) Void(_ context.Context, providerPaymentID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	payment, ok := f.payments[providerPaymentID]
+	if !ok {
+		return ErrFakePaymentNotFound
+	}
+	switch payment.status {
+	case models.PaymentStatusCaptured:
+		return ErrPaymentCaptured
+	case models.PaymentStatusRefunded:
+		return nil
+	}
+	delete(f.payments, providerPaymentID)
+	return nil
+}
+
+func (f *Fake) move(providerPaymentID string, amount models.Money, from, to string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.moveLocked(providerPaymentID, amount, from, to)
+}
+
+// moveLocked переводит платеж из from в to; вызывается под f.mu
+func (f *Fake) moveLocked(providerPaymentID string, amount models.Money, from, to string) error {
+	payment, ok := f.payments[providerPaymentID]
+	if !ok {
+		return ErrFakePaymentNotFound
+	}
+	if payment.status != from {
+		return fmt.Errorf("%w: %s", ErrFakeInvalidState, payment.status)
+	}
+	cmp, err := amount.Cmp(payment.amount)
+	if err != nil {
+		return err
+	}
+	if cmp > 0 {
+		return ErrFakeAmountExceeded
+	}
+	payment.status = to
+	return nil
+}
+
+func (f *Fake) VerifyWebhook(header http.Header, payload []byte) (WebhookEvent, error) {
+	signature, err := hex.DecodeString(header.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, f.sign(payload)) {
+		return WebhookEvent{}, ErrInvalidSignature
+	}
+	var webhook fakeWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		return WebhookEvent{}, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	if webhook.PaymentID == "" || (webhook.Status != models.PaymentStatusAuthorized && webhook.Status != models.PaymentStatusFailed) {
+		return WebhookEvent{}, ErrInvalidWebhook
+	}
+	return WebhookEvent{ProviderPaymentID: webhook.PaymentID, Status: webhook.Status, Reason: webhook.Reason}, nil
+}
+
+// SignWebhook подписывает вебхук так же, как фейковый провайдер
+func (f *Fake) SignWebhook(payload []byte) http.Header {
+	header := http.Header{}
+	header.Set(FakeSignatureHeader, hex.EncodeToString(f.sign(payload)))
+	return header
+}
+
+func (f *Fake) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// sendWebhook доставляет вебхук через delay, как настоящий провайдер после обработки платежа
+func (f *Fake) sendWebhook(webhook fakeWebhook) {
+	if f.OnWebhook == nil {
+		return
+	}
+	payload, err := json.Marshal(webhook)
+	if err != nil {
+		return
+	}
+	header := f.SignWebhook(payload)
+	time.AfterFunc(f.delay, func() {
+		f.OnWebhook(header, payload)
+	})
+}
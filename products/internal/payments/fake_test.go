@@ -0,0 +1,117 @@
+package payments
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+)
+
+func TestFakeAuthorize(t *testing.T) {
+	amount := models.Money{Amount: 10000, Currency: "RUB"}
+	tests := []struct {
+		token  string
+		result string
+		reason string
+	}{
+		{FakeTokenApproved, AuthorizationApproved, ""},
+		{FakeTokenDeclined, AuthorizationDeclined, "card_declined"},
+		{FakeTokenInsufficientFunds, AuthorizationDeclined, "insufficient_funds"},
+		{FakeToken3DS, AuthorizationRequiresAction, ""},
+		{FakeTokenDelayed, AuthorizationPending, ""},
+		{"tok_unknown", AuthorizationDeclined, "invalid_token"},
+	}
+	fake := NewFake("secret", 0)
+	for i, tc := range tests {
+		auth, err := fake.Authorize(context.Background(), AuthorizeRequest{PaymentID: i + 1, OrderID: 1, Amount: amount, Token: tc.token})
+		require.NoError(t, err, tc.token)
+		assert.Equal(t, tc.result, auth.Result, tc.token)
+		assert.Equal(t, tc.reason, auth.DeclineReason, tc.token)
+		assert.NotEmpty(t, auth.ProviderPaymentID, tc.token)
+	}
+}
+
+func TestFakeCaptureAndRefund(t *testing.T) {
+	ctx := context.Background()
+	amount := models.Money{Amount: 10000, Currency: "RUB"}
+	fake := NewFake("secret", 0)
+
+	auth, err := fake.Authorize(ctx, AuthorizeRequest{PaymentID: 1, Amount: amount, Token: FakeTokenApproved})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, fake.Refund(ctx, auth.ProviderPaymentID, amount, "refund-1"), ErrFakeInvalidState)
+	assert.ErrorIs(t, fake.Capture(ctx, auth.ProviderPaymentID, models.Money{Amount: 10001, Currency: "RUB"}), ErrFakeAmountExceeded)
+	assert.NoError(t, fake.Capture(ctx, auth.ProviderPaymentID, amount))
+	assert.ErrorIs(t, fake.Capture(ctx, auth.ProviderPaymentID, amount), ErrFakeInvalidState)
+	assert.NoError(t, fake.Refund(ctx, auth.ProviderPaymentID, amount, "refund-1"))
+	// Повтор с тем же ключом не считается вторым возвратом
+	assert.NoError(t, fake.Refund(ctx, auth.ProviderPaymentID, amount, "refund-1"))
+	assert.ErrorIs(t, fake.Refund(ctx, auth.ProviderPaymentID, amount, "refund-2"), ErrFakeInvalidState)
+	assert.ErrorIs(t, fake.Capture(ctx, "fake_404", amount), ErrFakePaymentNotFound)
+}
+
+func TestFakeVoid(t *testing.T) {
+	ctx := context.Background()
+	amount := models.Money{Amount: 10000, Currency: "RUB"}
+	fake := NewFake("secret", 0)
+
+	authorized, err := fake.Authorize(ctx, AuthorizeRequest{PaymentID: 1, Amount: amount, Token: FakeTokenApproved})
+	require.NoError(t, err)
+	assert.NoError(t, fake.Void(ctx, authorized.ProviderPaymentID))
+	assert.ErrorIs(t, fake.Capture(ctx, authorized.ProviderPaymentID, amount), ErrFakePaymentNotFound)
+
+	captured, err := fake.Authorize(ctx, AuthorizeRequest{PaymentID: 2, Amount: amount, Token: FakeTokenApproved})
+	require.NoError(t, err)
+	require.NoError(t, fake.Capture(ctx, captured.ProviderPaymentID, amount))
+	assert.ErrorIs(t, fake.Void(ctx, captured.ProviderPaymentID), ErrPaymentCaptured)
+	assert.NoError(t, fake.Refund(ctx, captured.ProviderPaymentID, amount, "refund-2"))
+	assert.NoError(t, fake.Void(ctx, captured.ProviderPaymentID))
+
+	assert.ErrorIs(t, fake.Void(ctx, "fake_404"), ErrFakePaymentNotFound)
+}
+
+func TestFakeChallengeWebhook(t *testing.T) {
+	fake := NewFake("secret", 0)
+	webhooks := make(chan WebhookEvent, 1)
+	fake.OnWebhook = func(header http.Header, payload []byte) {
+		event, err := fake.VerifyWebhook(header, payload)
+		assert.NoError(t, err)
+		webhooks <- event
+	}
+
+	auth, err := fake.Authorize(context.Background(), AuthorizeRequest{PaymentID: 1, Amount: models.Money{Amount: 100, Currency: "RUB"}, Token: FakeToken3DS})
+	require.NoError(t, err)
+	assert.Equal(t, FakeChallengePath+auth.ProviderPaymentID, auth.ActionURL)
+
+	require.NoError(t, fake.CompleteChallenge(auth.ProviderPaymentID, true))
+	select {
+	case event := <-webhooks:
+		assert.Equal(t, WebhookEvent{ProviderPaymentID: auth.ProviderPaymentID, Status: models.PaymentStatusAuthorized}, event)
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not sent")
+	}
+	assert.ErrorIs(t, fake.CompleteChallenge(auth.ProviderPaymentID, true), ErrFakeInvalidState)
+}
+
+func TestFakeVerifyWebhook(t *testing.T) {
+	fake := NewFake("secret", 0)
+	payload := []byte(`{"payment_id":"fake_1","status":"failed","reason":"card_declined"}`)
+
+	event, err := fake.VerifyWebhook(fake.SignWebhook(payload), payload)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookEvent{ProviderPaymentID: "fake_1", Status: models.PaymentStatusFailed, Reason: "card_declined"}, event)
+
+	_, err = NewFake("other", 0).VerifyWebhook(fake.SignWebhook(payload), payload)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = fake.VerifyWebhook(http.Header{}, payload)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	bad := []byte(`{"payment_id":"fake_1","status":"captured"}`)
+	_, err = fake.VerifyWebhook(fake.SignWebhook(bad), bad)
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+}
@@ -0,0 +1,61 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/wileytor/go-market/common/models"
+)
+
+// Результаты авторизации платежа
+const (
+	AuthorizationApproved       = "approved"
+	AuthorizationDeclined       = "declined"
+	AuthorizationRequiresAction = "requires_action"
+	// AuthorizationPending - решение придет вебхуком
+	AuthorizationPending = "pending"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidWebhook   = errors.New("invalid webhook payload")
+	// ErrPaymentCaptured - платеж уже списан, отменить его можно только возвратом
+	ErrPaymentCaptured = errors.New("payment is already captured")
+)
+
+type AuthorizeRequest struct {
+	PaymentID int
+	OrderID   int
+	Amount    models.Money
+	Token     string
+}
+
+type Authorization struct {
+	ProviderPaymentID string
+	Result            string
+	DeclineReason     string
+	// ActionURL заполняется для проверки 3-D Secure
+	ActionURL string
+}
+
+// WebhookEvent - итог платежа, о котором провайдер сообщил вебхуком.
+// Status - models.PaymentStatusAuthorized или models.PaymentStatusFailed.
+type WebhookEvent struct {
+	ProviderPaymentID string
+	Status            string
+	Reason            string
+}
+
+// Provider - платежный провайдер. Авторизация резервирует деньги покупателя,
+// списание и возврат выполняются по идентификатору платежа у провайдера.
+// Повторный возврат с тем же idempotencyKey не возвращает деньги второй раз.
+type Provider interface {
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (Authorization, error)
+	Capture(ctx context.Context, providerPaymentID string, amount models.Money) error
+	Refund(ctx context.Context, providerPaymentID string, amount models.Money, idempotencyKey string) error
+	// Void отменяет несписанный платеж и снимает блокировку денег покупателя
+	Void(ctx context.Context, providerPaymentID string) error
+	VerifyWebhook(header http.Header, payload []byte) (WebhookEvent, error)
+}
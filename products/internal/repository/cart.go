@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/wileytor/go-market/common/models"
+)
+
+var (
+	ErrCartItemNotFound = errors.New("cart item not found")
+	ErrCartEmpty        = errors.New("cart is empty")
+)
+
+// GetCart возвращает корзину пользователя с текущими ценами и доступными остатками:
+// зарезервированное под заказы в остаток не входит.
+// Просроченная корзина считается пустой.
+func (db *DBstorage) GetCart(userID int) (models.Cart, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT c.uid, c.product_id, c.variant_id, p.name, c.quantity,
+			COALESCE(v.price_amount, p.price_amount), p.currency,
+			COALESCE(v.quantity, p.quantity) - (SELECT COALESCE(SUM(r.quantity), 0) FROM stock_reservations r
+				WHERE r.product_id = c.product_id AND r.variant_id IS NOT DISTINCT FROM c.variant_id),
+			p.delete OR COALESCE(v.deleted, false), ct.expires_at
+		FROM cart_items c
+		JOIN carts ct ON ct.user_id = c.user_id
+		JOIN products p ON p.uid = c.product_id
+		LEFT JOIN product_variants v ON v.uid = c.variant_id
+		WHERE c.user_id = $1 AND ct.expires_at > NOW()
+		ORDER BY c.uid`
+	rows, err := db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+	defer rows.Close()
+
+	cart := models.Cart{UserID: userID, Items: []models.CartItem{}}
+	for rows.Next() {
+		var item models.CartItem
+		var deleted bool
+		var expiresAt time.Time
+		err := rows.Scan(&item.UID, &item.ProductID, &item.VariantID, &item.Name, &item.Quantity,
+			&item.Price.Amount, &item.Price.Currency, &item.InStock, &deleted, &expiresAt)
+		if err != nil {
+			return models.Cart{}, err
+		}
+		if item.Total, err = item.Price.Mul(int64(item.Quantity)); err != nil {
+			return models.Cart{}, err
+		}
+		if deleted {
+			item.InStock = 0
+		}
+		item.Available = item.Quantity <= item.InStock
+		cart.ExpiresAt = &expiresAt
+		cart.Items = append(cart.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Cart{}, err
+	}
+	if cart.Total, err = cartTotal(cart.Items); err != nil {
+		return models.Cart{}, err
+	}
+	return cart, nil
+}
+
+// cartTotal складывает суммы строк корзины. У корзины с товарами в разных валютах итога нет.
+func cartTotal(items []models.CartItem) (*models.Money, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	total := items[0].Total
+	for _, item := range items[1:] {
+		var err error
+		total, err = total.Add(item.Total)
+		if errors.Is(err, models.ErrCurrencyMismatch) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &total, nil
+}
+
+// AddCartItem кладет товар в корзину. Если товар уже в корзине, количество складывается.
+func (db *DBstorage) AddCartItem(userID int, line models.OrderLine) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkCartLine(ctx, tx, line); err != nil {
+		return err
+	}
+	if err := db.touchCart(ctx, tx, userID); err != nil {
+		return err
+	}
+	query := `INSERT INTO cart_items (user_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, product_id, (COALESCE(variant_id, 0)))
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity`
+	if _, err := tx.Exec(ctx, query, userID, line.ProductID, line.VariantID, line.Quantity); err != nil {
+		return fmt.Errorf("failed to add cart item: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// checkCartLine проверяет, что товар можно положить в корзину
+func checkCartLine(ctx context.Context, tx pgx.Tx, line models.OrderLine) error {
+	var deleted, hasVariants bool
+	query := `SELECT delete, EXISTS (SELECT 1 FROM product_variants WHERE product_id = uid) FROM products WHERE uid = $1`
+	err := tx.QueryRow(ctx, query, line.ProductID).Scan(&deleted, &hasVariants)
+	if errors.Is(err, pgx.ErrNoRows) || deleted {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if line.VariantID == nil {
+		if hasVariants {
+			return ErrVariantRequired
+		}
+		return nil
+	}
+	var exists bool
+	query = `SELECT EXISTS (SELECT 1 FROM product_variants WHERE uid = $1 AND product_id = $2 AND NOT deleted)`
+	if err := tx.QueryRow(ctx, query, *line.VariantID, line.ProductID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrVariantNotFound
+	}
+	return nil
+}
+
+// touchCart создает корзину или продлевает ее срок. Строки просроченной корзины удаляются.
+func (db *DBstorage) touchCart(ctx context.Context, tx pgx.Tx, userID int) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM carts WHERE user_id = $1 AND expires_at <= NOW()`, userID); err != nil {
+		return fmt.Errorf("failed to drop expired cart: %w", err)
+	}
+	query := `INSERT INTO carts (user_id, expires_at) VALUES ($1, NOW() + make_interval(secs => $2))
+		ON CONFLICT (user_id) DO UPDATE SET expires_at = EXCLUDED.expires_at`
+	if _, err := tx.Exec(ctx, query, userID, db.CartTTL.Seconds()); err != nil {
+		return fmt.Errorf("failed to touch cart: %w", err)
+	}
+	return nil
+}
+
+// UpdateCartItem меняет количество в строке корзины пользователя
+func (db *DBstorage) UpdateCartItem(userID, itemID, quantity int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE cart_items c SET quantity = $1
+		FROM carts ct
+		WHERE c.uid = $2 AND c.user_id = $3 AND ct.user_id = c.user_id AND ct.expires_at > NOW()`
+	result, err := tx.Exec(ctx, query, quantity, itemID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update cart item: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrCartItemNotFound
+	}
+	if err := db.touchCart(ctx, tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RemoveCartItem удаляет строку из корзины пользователя
+func (db *DBstorage) RemoveCartItem(userID, itemID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `DELETE FROM cart_items c
+		USING carts ct
+		WHERE c.uid = $1 AND c.user_id = $2 AND ct.user_id = c.user_id AND ct.expires_at > NOW()`
+	result, err := tx.Exec(ctx, query, itemID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove cart item: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrCartItemNotFound
+	}
+	if err := db.touchCart(ctx, tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ClearCart удаляет корзину пользователя со всеми строками
+func (db *DBstorage) ClearCart(userID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM carts WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear cart: %w", err)
+	}
+	return nil
+}
+
+// CheckoutCart оформляет заказ из корзины и очищает ее в одной транзакции.
+// region - регион доставки для стратегии выбора склада region.
+func (db *DBstorage) CheckoutCart(userID int, region string) (models.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return models.Order{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокировка корзины не дает оформить ее дважды параллельными запросами
+	query := `SELECT c.product_id, c.variant_id, c.quantity
+		FROM carts ct
+		JOIN cart_items c ON c.user_id = ct.user_id
+		WHERE ct.user_id = $1 AND ct.expires_at > NOW()
+		ORDER BY c.uid
+		FOR UPDATE OF ct`
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		return models.Order{}, err
+	}
+	var lines []models.OrderLine
+	for rows.Next() {
+		var line models.OrderLine
+		if err := rows.Scan(&line.ProductID, &line.VariantID, &line.Quantity); err != nil {
+			rows.Close()
+			return models.Order{}, err
+		}
+		lines = append(lines, line)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.Order{}, err
+	}
+	if len(lines) == 0 {
+		return models.Order{}, ErrCartEmpty
+	}
+
+	order, err := db.createOrder(ctx, tx, userID, models.OrderRequest{Items: lines, Region: region})
+	if err != nil {
+		return models.Order{}, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM carts WHERE user_id = $1`, userID); err != nil {
+		return models.Order{}, fmt.Errorf("failed to clear cart: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Order{}, err
+	}
+	return order, nil
+}
+
+// DeleteExpiredCarts удаляет просроченные корзины и возвращает их число
+func (db *DBstorage) DeleteExpiredCarts() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Pool.Exec(ctx, `DELETE FROM carts WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired carts: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/wileytor/go-market/common/models"
+)
+
+var (
+	ErrProductNotFound     = errors.New("product not found")
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrParentNotFound      = errors.New("parent category not found")
+	ErrCategoryCycle       = errors.New("category cannot be moved under itself or its descendant")
+	ErrCategoryExists      = errors.New("category with this name already exists under the parent")
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+)
+
+// pgUniqueViolation - код ошибки нарушения уникальности в PostgreSQL
+const pgUniqueViolation = "23505"
+
+// categoryTree выбирает категорию и всех ее потомков
+const categoryTree = `WITH RECURSIVE tree AS (
+		SELECT uid FROM categories WHERE uid = %s
+		UNION ALL
+		SELECT c.uid FROM categories c JOIN tree t ON c.parent_id = t.uid
+	) SELECT uid FROM tree`
+
+func (db *DBstorage) GetCategories() ([]models.Category, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	query, args := sb.Select("uid", "name", "parent_id").From("categories").
+		OrderBy("parent_id NULLS FIRST", "name").
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	categories := []models.Category{}
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.UID, &category.Name, &category.ParentID); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (db *DBstorage) GetCategoryByID(uid int) (models.Category, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	query, args := sb.Select("uid", "name", "parent_id").From("categories").
+		Where(sb.Equal("uid", uid)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	var category models.Category
+	err := db.Pool.QueryRow(ctx, query, args...).Scan(&category.UID, &category.Name, &category.ParentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Category{}, ErrCategoryNotFound
+	}
+	if err != nil {
+		return models.Category{}, err
+	}
+	return category, nil
+}
+
+func (db *DBstorage) AddCategory(category models.Category) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("create transaction failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if category.ParentID != nil {
+		if err := lockCategory(ctx, tx, *category.ParentID); err != nil {
+			return -1, err
+		}
+	}
+
+	sb := sqlbuilder.NewInsertBuilder()
+	query, args := sb.InsertInto("categories").Cols("name", "parent_id").
+		Values(category.Name, category.ParentID).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+	query += " RETURNING uid"
+
+	var UID int
+	if err := tx.QueryRow(ctx, query, args...).Scan(&UID); err != nil {
+		return -1, categoryError(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return -1, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return UID, nil
+}
+
+// UpdateCategory переименовывает категорию и переносит ее к другому родителю.
+// Перенос внутрь собственного поддерева запрещен, чтобы дерево не превратилось в цикл.
+func (db *DBstorage) UpdateCategory(uid int, category models.Category) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create transaction failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if category.ParentID != nil {
+		if err := lockCategory(ctx, tx, *category.ParentID); err != nil {
+			return err
+		}
+		var cycle bool
+		query := fmt.Sprintf("SELECT EXISTS (%s WHERE uid = $2)", fmt.Sprintf(categoryTree, "$1"))
+		if err := tx.QueryRow(ctx, query, uid, *category.ParentID).Scan(&cycle); err != nil {
+			return fmt.Errorf("failed to check category tree: %w", err)
+		}
+		if cycle {
+			return ErrCategoryCycle
+		}
+	}
+
+	sb := sqlbuilder.NewUpdateBuilder()
+	query, args := sb.Update("categories").
+		Set(
+			sb.Assign("name", category.Name),
+			sb.Assign("parent_id", category.ParentID),
+		).
+		Where(sb.Equal("uid", uid)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return categoryError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrCategoryNotFound
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteCategory удаляет категорию без подкатегорий вместе с привязками товаров
+func (db *DBstorage) DeleteCategory(uid int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create transaction failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCategory(ctx, tx, uid); err != nil {
+		if errors.Is(err, ErrParentNotFound) {
+			return ErrCategoryNotFound
+		}
+		return err
+	}
+	var hasChildren bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)`, uid).Scan(&hasChildren); err != nil {
+		return fmt.Errorf("failed to check subcategories: %w", err)
+	}
+	if hasChildren {
+		return ErrCategoryHasChildren
+	}
+
+	sb := sqlbuilder.NewDeleteBuilder()
+	query, args := sb.DeleteFrom("categories").Where(sb.Equal("uid", uid)).BuildWithFlavor(sqlbuilder.PostgreSQL)
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetProductCategories заменяет набор категорий товара
+func (db *DBstorage) SetProductCategories(productID int, categoryIDs []int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create transaction failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM products WHERE uid = $1 AND delete = false)`, productID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check product: %w", err)
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+
+	unique := make(map[int]struct{}, len(categoryIDs))
+	ids := make([]interface{}, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if _, ok := unique[id]; !ok {
+			unique[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		sb := sqlbuilder.NewSelectBuilder()
+		query, args := sb.Select("COUNT(*)").From("categories").Where(sb.In("uid", ids...)).BuildWithFlavor(sqlbuilder.PostgreSQL)
+		var found int
+		if err := tx.QueryRow(ctx, query, args...).Scan(&found); err != nil {
+			return fmt.Errorf("failed to check categories: %w", err)
+		}
+		if found != len(ids) {
+			return ErrCategoryNotFound
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM product_categories WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to clear product categories: %w", err)
+	}
+	if len(ids) > 0 {
+		ib := sqlbuilder.NewInsertBuilder()
+		ib.InsertInto("product_categories").Cols("product_id", "category_id")
+		for _, id := range ids {
+			ib.Values(productID, id)
+		}
+		query, args := ib.BuildWithFlavor(sqlbuilder.PostgreSQL)
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to assign product categories: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetProductBreadcrumbs возвращает для каждой категории товара путь от корня дерева
+func (db *DBstorage) GetProductBreadcrumbs(productID int) ([][]models.Category, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := db.Pool.Query(ctx, `WITH RECURSIVE path AS (
+			SELECT pc.category_id AS leaf, c.uid, c.name, c.parent_id, 0 AS depth
+			FROM product_categories pc JOIN categories c ON c.uid = pc.category_id
+			WHERE pc.product_id = $1
+			UNION ALL
+			SELECT p.leaf, c.uid, c.name, c.parent_id, p.depth + 1
+			FROM path p JOIN categories c ON c.uid = p.parent_id
+		)
+		SELECT leaf, uid, name, parent_id FROM path ORDER BY leaf, depth DESC`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var breadcrumbs [][]models.Category
+	lastLeaf := 0
+	for rows.Next() {
+		var leaf int
+		var category models.Category
+		if err := rows.Scan(&leaf, &category.UID, &category.Name, &category.ParentID); err != nil {
+			return nil, err
+		}
+		if leaf != lastLeaf {
+			breadcrumbs = append(breadcrumbs, nil)
+			lastLeaf = leaf
+		}
+		breadcrumbs[len(breadcrumbs)-1] = append(breadcrumbs[len(breadcrumbs)-1], category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return breadcrumbs, nil
+}
+
+// lockCategory проверяет, что категория существует, и блокирует ее до конца транзакции,
+// чтобы ее не удалили одновременно с добавлением потомка
+func lockCategory(ctx context.Context, tx pgx.Tx, uid int) error {
+	var locked int
+	err := tx.QueryRow(ctx, `SELECT uid FROM categories WHERE uid = $1 FOR UPDATE`, uid).Scan(&locked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrParentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock category: %w", err)
+	}
+	return nil
+}
+
+func categoryError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrCategoryExists
+	}
+	return fmt.Errorf("failed to save category: %w", err)
+}
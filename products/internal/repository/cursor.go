@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/wileytor/go-market/common/models"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+// productCursor - позиция последнего товара страницы в порядке сортировки Sort
+type productCursor struct {
+	Sort  string `json:"s"`
+	UID   int    `json:"id"`
+	Price int64  `json:"p,omitempty"`
+	Name  string `json:"n,omitempty"`
+}
+
+func encodeCursor(c productCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s, sort string) (productCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return productCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var c productCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return productCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	// курсор действителен только для той сортировки, в которой он выдан
+	if c.Sort != sort || c.UID <= 0 {
+		return productCursor{}, fmt.Errorf("%w: cursor does not match sort order", ErrInvalidCursor)
+	}
+	return c, nil
+}
+
+// productOrder возвращает сортировку и условие, выбирающее товары после курсора.
+// uid добавляется во все сортировки, чтобы порядок был однозначным.
+func productOrder(sb *sqlbuilder.SelectBuilder, sort string, c *productCursor) []string {
+	switch sort {
+	case models.SortPriceAsc:
+		if c != nil {
+			sb.Where(fmt.Sprintf("(price_amount, uid) > (%s, %s)", sb.Var(c.Price), sb.Var(c.UID)))
+		}
+		return []string{"price_amount ASC", "uid ASC"}
+	case models.SortPriceDesc:
+		if c != nil {
+			sb.Where(fmt.Sprintf("(price_amount, uid) < (%s, %s)", sb.Var(c.Price), sb.Var(c.UID)))
+		}
+		return []string{"price_amount DESC", "uid DESC"}
+	case models.SortNameAsc:
+		if c != nil {
+			sb.Where(fmt.Sprintf("(name, uid) > (%s, %s)", sb.Var(c.Name), sb.Var(c.UID)))
+		}
+		return []string{"name ASC", "uid ASC"}
+	case models.SortNameDesc:
+		if c != nil {
+			sb.Where(fmt.Sprintf("(name, uid) < (%s, %s)", sb.Var(c.Name), sb.Var(c.UID)))
+		}
+		return []string{"name DESC", "uid DESC"}
+	default:
+		if c != nil {
+			sb.Where(sb.LessThan("uid", c.UID))
+		}
+		return []string{"uid DESC"}
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wileytor/go-market/common/models"
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was used with another request")
+	ErrIdempotencyInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+// idempotencyLease - сколько ключ занят выполняющимся запросом. Запрос, не сохранивший ответ
+// за это время, считается прерванным, и ключ можно занять снова.
+const idempotencyLease = time.Minute
+
+// StartIdempotentRequest занимает ключ под новый запрос. Если запрос с этим ключом уже выполнен,
+// возвращает сохраненный ответ; ключ, занятый другим запросом, дает ErrIdempotencyKeyReused.
+// Ключ прерванного запроса освобождается по истечении аренды.
+func (db *DBstorage) StartIdempotentRequest(key models.IdempotencyKey) (*models.IdempotentResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	deleteQuery := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2
+		AND (expires_at <= NOW() OR (status_code IS NULL AND locked_until <= NOW()))`
+	if _, err := db.Pool.Exec(ctx, deleteQuery, key.UserID, key.Key); err != nil {
+		return nil, fmt.Errorf("failed to drop expired idempotency key: %w", err)
+	}
+	insertQuery := `INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at, locked_until)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4), NOW() + make_interval(secs => $5))
+		ON CONFLICT (user_id, key) DO NOTHING`
+	result, err := db.Pool.Exec(ctx, insertQuery, key.UserID, key.Key, key.RequestHash,
+		db.IdempotencyTTL.Seconds(), idempotencyLease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+	if result.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	var requestHash string
+	var statusCode *int
+	var body []byte
+	err = db.Pool.QueryRow(ctx, `SELECT request_hash, status_code, response FROM idempotency_keys WHERE user_id = $1 AND key = $2`,
+		key.UserID, key.Key).Scan(&requestHash, &statusCode, &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	if requestHash != key.RequestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if statusCode == nil {
+		return nil, ErrIdempotencyInProgress
+	}
+	return &models.IdempotentResponse{StatusCode: *statusCode, Body: body}, nil
+}
+
+// SaveIdempotentResponse сохраняет ответ на запрос, занявший ключ
+func (db *DBstorage) SaveIdempotentResponse(key models.IdempotencyKey, response models.IdempotentResponse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `UPDATE idempotency_keys SET status_code = $1, response = $2, locked_until = NULL
+		WHERE user_id = $3 AND key = $4 AND request_hash = $5`
+	_, err := db.Pool.Exec(ctx, query, response.StatusCode, response.Body, key.UserID, key.Key, key.RequestHash)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey освобождает ключ, чтобы запрос можно было повторить
+func (db *DBstorage) DeleteIdempotencyKey(key models.IdempotencyKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND request_hash = $3`
+	if _, err := db.Pool.Exec(ctx, query, key.UserID, key.Key, key.RequestHash); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys удаляет просроченные ключи и возвращает их число
+func (db *DBstorage) DeleteExpiredIdempotencyKeys() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := db.Pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
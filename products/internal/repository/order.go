@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"github.com/wileytor/go-market/common/models"
+)
+
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrInsufficientStock = errors.New("not enough stock")
+)
+
+// CreateOrder оформляет заказ: резервирует остатки по всем строкам на ReservationTTL и фиксирует цены.
+// Склады для строк выбираются стратегией AllocationStrategy. Если хотя бы одной строки не хватает, не резервируется ничего.
+func (db *DBstorage) CreateOrder(userID int, request models.OrderRequest) (models.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return models.Order{}, err
+	}
+	// Откат после Commit ничего не делает, поэтому вызывается безусловно
+	defer tx.Rollback(ctx)
+
+	order, err := db.createOrder(ctx, tx, userID, request)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Order{}, err
+	}
+	return order, nil
+}
+
+// createOrder оформляет заказ в транзакции tx, резервирует остатки на складах на ReservationTTL
+// и записывает событие о заказе в outbox. Позиции, опустившиеся до порога дозаказа, получают заявку поставщику и событие stock.low.
+func (db *DBstorage) createOrder(ctx context.Context, tx pgx.Tx, userID int, request models.OrderRequest) (models.Order, error) {
+	order := models.Order{UserID: userID}
+	var allocations []stockAllocation
+	var alerts []lowStockAlert
+	for i, line := range mergeOrderLines(request.Items) {
+		price, err := lockOrderLine(ctx, tx, line)
+		if err != nil {
+			return models.Order{}, err
+		}
+		alert, err := checkLowStock(ctx, tx, line)
+		if err != nil {
+			return models.Order{}, err
+		}
+		if alert != nil {
+			alerts = append(alerts, *alert)
+		}
+		lineAllocations, err := db.allocateOrderLine(ctx, tx, line, request.Region)
+		if err != nil {
+			return models.Order{}, err
+		}
+		allocations = append(allocations, lineAllocations...)
+		total, err := price.Mul(int64(line.Quantity))
+		if err != nil {
+			return models.Order{}, err
+		}
+		if i == 0 {
+			order.Total = models.Money{Currency: price.Currency}
+		}
+		if order.Total, err = order.Total.Add(total); err != nil {
+			return models.Order{}, fmt.Errorf("order lines must share one currency: %w", err)
+		}
+		order.Items = append(order.Items, models.OrderItem{
+			ProductID: line.ProductID,
+			VariantID: line.VariantID,
+			Quantity:  line.Quantity,
+			Price:     price,
+			Total:     total,
+		})
+	}
+
+	insertQuery := `INSERT INTO orders (user_id, total_amount, currency) VALUES ($1, $2, $3) RETURNING uid, status, created_at, updated_at`
+	err := tx.QueryRow(ctx, insertQuery, userID, order.Total.Amount, order.Total.Currency).
+		Scan(&order.UID, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return models.Order{}, err
+	}
+	historyQuery := `INSERT INTO order_status_history (order_id, to_status, changed_at) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(ctx, historyQuery, order.UID, order.Status, order.CreatedAt); err != nil {
+		return models.Order{}, err
+	}
+	order.History = []models.OrderStatusChange{{To: order.Status, ChangedAt: order.CreatedAt}}
+	reservedUntil := order.CreatedAt.Add(db.ReservationTTL)
+	order.ReservedUntil = &reservedUntil
+	event := models.OrderCreatedEvent{
+		OrderID:   order.UID,
+		UserID:    userID,
+		Total:     order.Total,
+		CreatedAt: order.CreatedAt,
+	}
+	for i, item := range order.Items {
+		itemQuery := `INSERT INTO order_items (order_id, product_id, variant_id, quantity, price_amount, currency) VALUES ($1, $2, $3, $4, $5, $6) RETURNING uid`
+		err = tx.QueryRow(ctx, itemQuery, order.UID, item.ProductID, item.VariantID, item.Quantity, item.Price.Amount, item.Price.Currency).
+			Scan(&order.Items[i].UID)
+		if err != nil {
+			return models.Order{}, err
+		}
+		event.Items = append(event.Items, models.OrderEventItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+		})
+	}
+
+	for _, allocation := range allocations {
+		if err := reserveOrderItem(ctx, tx, userID, order.UID, allocation, reservedUntil); err != nil {
+			return models.Order{}, err
+		}
+	}
+
+	if err := addOutbox(ctx, tx, models.EventOrderCreated, event); err != nil {
+		return models.Order{}, err
+	}
+	for _, alert := range alerts {
+		if err := raiseLowStock(ctx, tx, order.UID, alert); err != nil {
+			return models.Order{}, err
+		}
+	}
+	return order, nil
+}
+
+// mergeOrderLines объединяет строки с одним товаром и вариантом и сортирует их,
+// чтобы параллельные заказы блокировали остатки в одном порядке
+func mergeOrderLines(lines []models.OrderLine) []models.OrderLine {
+	type lineKey struct{ product, variant int }
+	merged := make([]models.OrderLine, 0, len(lines))
+	index := make(map[lineKey]int, len(lines))
+	for _, line := range lines {
+		key := lineKey{product: line.ProductID}
+		if line.VariantID != nil {
+			key.variant = *line.VariantID
+		}
+		if i, ok := index[key]; ok {
+			merged[i].Quantity += line.Quantity
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, line)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].ProductID != merged[j].ProductID {
+			return merged[i].ProductID < merged[j].ProductID
+		}
+		return variantKey(merged[i].VariantID) < variantKey(merged[j].VariantID)
+	})
+	return merged
+}
+
+func variantKey(variantID *int) int {
+	if variantID == nil {
+		return 0
+	}
+	return *variantID
+}
+
+// ChangeOrderStatus переводит заказ в новый статус по правилам models.CheckOrderTransition.
+// Оплата превращает резервы в продажу, отмена снимает резервы, возврат возвращает остатки на склад.
+// Движения остатков записываются в журнал от имени actorID.
+func (db *DBstorage) ChangeOrderStatus(actorID, orderID int, status string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := changeOrderStatus(ctx, tx, actorID, orderID, status); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// changeOrderStatus переводит заказ в новый статус в транзакции tx. Нулевой actorID означает сам сервис.
+func changeOrderStatus(ctx context.Context, tx pgx.Tx, actorID, orderID int, status string) error {
+	var userID int
+	var current string
+	err := tx.QueryRow(ctx, `SELECT user_id, status FROM orders WHERE uid = $1 FOR UPDATE`, orderID).Scan(&userID, &current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrOrderNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := models.CheckOrderTransition(current, status); err != nil {
+		return err
+	}
+	if status == models.OrderStatusCancelled {
+		// Иначе провайдер может списать деньги за уже отмененный заказ.
+		// Отказ по платежу отменяет заказ после того, как платеж помечен failed.
+		active, err := hasActivePayment(ctx, tx, orderID)
+		if err != nil {
+			return err
+		}
+		if active {
+			return ErrPaymentInProgress
+		}
+	}
+
+	var changedAt time.Time
+	updQuery := `UPDATE orders SET status = $1, updated_at = NOW() WHERE uid = $2 RETURNING updated_at`
+	if err := tx.QueryRow(ctx, updQuery, status, orderID).Scan(&changedAt); err != nil {
+		return err
+	}
+	historyQuery := `INSERT INTO order_status_history (order_id, from_status, to_status, changed_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, historyQuery, orderID, current, status, changedAt); err != nil {
+		return err
+	}
+	switch {
+	case status == models.OrderStatusPaid:
+		err = sellReservations(ctx, tx, actorID, orderID)
+	case current == models.OrderStatusPending:
+		// Неоплаченный заказ держал остатки только в резерве
+		err = releaseReservations(ctx, tx, actorID, orderID)
+	case models.RestocksOrder(status):
+		err = restockOrder(ctx, tx, actorID, orderID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return addOutbox(ctx, tx, models.EventOrderStatus, models.OrderStatusChangedEvent{
+		OrderID:   orderID,
+		UserID:    userID,
+		From:      current,
+		To:        status,
+		ChangedAt: changedAt,
+	})
+}
+
+// restockOrder возвращает проданные остатки заказа на склады, с которых они были списаны.
+// Заказ, оплаченный до появления журнала, возвращается целиком на склад по умолчанию.
+func restockOrder(ctx context.Context, tx pgx.Tx, actorID, orderID int) error {
+	query := `SELECT warehouse_id, product_id, variant_id, -SUM(delta) FROM stock_movements
+		WHERE order_id = $1 AND reason = $2
+		GROUP BY warehouse_id, product_id, variant_id
+		ORDER BY product_id, variant_id NULLS FIRST, warehouse_id`
+	allocations, err := queryAllocations(ctx, tx, query, orderID, models.StockReasonSale)
+	if err != nil {
+		return err
+	}
+	if len(allocations) == 0 {
+		warehouseID, err := defaultWarehouse(ctx, tx)
+		if err != nil {
+			return err
+		}
+		query := `SELECT $2::int, product_id, variant_id, SUM(quantity) FROM order_items
+			WHERE order_id = $1
+			GROUP BY product_id, variant_id
+			ORDER BY product_id, variant_id NULLS FIRST`
+		if allocations, err = queryAllocations(ctx, tx, query, orderID, warehouseID); err != nil {
+			return err
+		}
+	}
+
+	for _, allocation := range allocations {
+		line := allocation.line
+		if line.Quantity <= 0 {
+			continue
+		}
+		err := moveStock(ctx, tx, &models.StockMovement{
+			WarehouseID: allocation.warehouseID,
+			ProductID:   line.ProductID,
+			VariantID:   line.VariantID,
+			Delta:       line.Quantity,
+			Reason:      models.StockReasonReturn,
+			ActorID:     optionalID(actorID),
+			OrderID:     &orderID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to restock order: %w", err)
+		}
+	}
+	return nil
+}
+
+func (db *DBstorage) GetOrderByID(orderID int) (models.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.Where(sb.Equal("o.uid", orderID))
+	orders, err := db.selectOrders(ctx, sb)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if len(orders) == 0 {
+		return models.Order{}, ErrOrderNotFound
+	}
+	return orders[0], nil
+}
+
+func (db *DBstorage) GetUserOrders(userID int) ([]models.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.Where(sb.Equal("o.user_id", userID))
+	return db.selectOrders(ctx, sb)
+}
+
+// GetProductOrders возвращает заказы, в которых есть товар
+func (db *DBstorage) GetProductOrders(productID int) ([]models.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	sub := sqlbuilder.NewSelectBuilder()
+	sub.Select("order_id").From("order_items").Where(sub.Equal("product_id", productID))
+	sb.Where(sb.In("o.uid", sub))
+	return db.selectOrders(ctx, sb)
+}
+
+// selectOrders загружает заказы по условиям sb вместе со строками, историей статусов и сроком резерва, новые первыми
+func (db *DBstorage) selectOrders(ctx context.Context, sb *sqlbuilder.SelectBuilder) ([]models.Order, error) {
+	query, args := sb.Select("o.uid", "o.user_id", "o.status", "o.total_amount", "o.currency", "o.created_at", "o.updated_at",
+		"(SELECT MIN(r.expires_at) FROM stock_reservations r WHERE r.order_id = o.uid)").
+		From("orders o").
+		OrderBy("o.created_at DESC", "o.uid DESC").
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	orders := []models.Order{}
+	index := make(map[int]int)
+	for rows.Next() {
+		var order models.Order
+		if err := rows.Scan(&order.UID, &order.UserID, &order.Status, &order.Total.Amount, &order.Total.Currency, &order.CreatedAt, &order.UpdatedAt, &order.ReservedUntil); err != nil {
+			return nil, err
+		}
+		order.Items = []models.OrderItem{}
+		order.History = []models.OrderStatusChange{}
+		index[order.UID] = len(orders)
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	orderIDs := make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.UID)
+	}
+	itemsSb := sqlbuilder.NewSelectBuilder()
+	itemsQuery, itemsArgs := itemsSb.Select("uid", "order_id", "product_id", "variant_id", "quantity", "price_amount", "currency").
+		From("order_items").
+		Where(itemsSb.In("order_id", orderIDs...)).
+		OrderBy("uid").
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	itemRows, err := db.Pool.Query(ctx, itemsQuery, itemsArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+	for itemRows.Next() {
+		var item models.OrderItem
+		var orderID int
+		if err := itemRows.Scan(&item.UID, &orderID, &item.ProductID, &item.VariantID, &item.Quantity, &item.Price.Amount, &item.Price.Currency); err != nil {
+			return nil, err
+		}
+		if item.Total, err = item.Price.Mul(int64(item.Quantity)); err != nil {
+			return nil, err
+		}
+		i := index[orderID]
+		orders[i].Items = append(orders[i].Items, item)
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, err
+	}
+
+	historySb := sqlbuilder.NewSelectBuilder()
+	historyQuery, historyArgs := historySb.Select("order_id", "COALESCE(from_status, '')", "to_status", "changed_at").
+		From("order_status_history").
+		Where(historySb.In("order_id", orderIDs...)).
+		OrderBy("uid").
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	historyRows, err := db.Pool.Query(ctx, historyQuery, historyArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer historyRows.Close()
+	for historyRows.Next() {
+		var change models.OrderStatusChange
+		var orderID int
+		if err := historyRows.Scan(&orderID, &change.From, &change.To, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		i := index[orderID]
+		orders[i].History = append(orders[i].History, change)
+	}
+	if err := historyRows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
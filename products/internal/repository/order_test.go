@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+)
+
+func TestCreateOrderShortLine(t *testing.T) {
+	db := testDB(t)
+	apple := addTestProduct(t, db, "apple", 5)
+	pear := addTestProduct(t, db, "pear", 1)
+
+	_, err := db.CreateOrder(7, models.OrderRequest{Items: []models.OrderLine{
+		{ProductID: apple, Quantity: 2},
+		{ProductID: pear, Quantity: 3},
+	}})
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+
+	// Строка, которой хватило остатка, тоже не зарезервирована
+	assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM orders`))
+	assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM stock_reservations`))
+	assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM stock_movements WHERE reason = $1`, models.StockReasonReservation))
+	availability, err := db.GetProductAvailability(apple)
+	require.NoError(t, err)
+	assert.Equal(t, 5, availability.Available)
+
+	order, err := db.CreateOrder(7, models.OrderRequest{Items: []models.OrderLine{
+		{ProductID: apple, Quantity: 2},
+		{ProductID: pear, Quantity: 1},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPending, order.Status)
+	assert.Equal(t, 3, countRows(t, db, `SELECT SUM(quantity) FROM stock_reservations WHERE order_id = $1`, order.UID))
+}
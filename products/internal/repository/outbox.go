@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"github.com/wileytor/go-market/common/models"
+)
+
+// addOutbox записывает событие в outbox в рамках транзакции tx,
+// поэтому событие появится только вместе с изменением, которое его вызвало
+func addOutbox(ctx context.Context, tx pgx.Tx, eventType string, payload interface{}) error {
+	message, err := models.EncodeMessage(eventType, payload, models.TraceContext{})
+	if err != nil {
+		return err
+	}
+
+	sb := sqlbuilder.NewInsertBuilder()
+	query, args := sb.InsertInto("outbox").Cols("event_type", "message").
+		Values(eventType, string(message)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to write %s to outbox: %w", eventType, err)
+	}
+	return nil
+}
+
+// ProcessOutbox передает в publish до limit неотправленных событий, начиная с самых старых.
+// Каждое событие блокируется и помечается отправленным в своей транзакции, поэтому таймаут
+// на одном событии не откатывает отметки уже опубликованных. Несколько ретрансляторов
+// пропускают чужие события (SKIP LOCKED) и могут публиковать их не по порядку.
+// На первой ошибке обработка останавливается, ошибка сохраняется в last_error.
+// Возвращает число отправленных событий.
+func (db *DBstorage) ProcessOutbox(limit int, publish func(models.OutboxMessage) error) (int, error) {
+	sent := 0
+	for sent < limit {
+		ok, err := db.processOutboxMessage(publish)
+		if err != nil {
+			return sent, err
+		}
+		if !ok {
+			break
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// processOutboxMessage публикует самое старое свободное событие outbox и помечает его отправленным.
+// Возвращает false, если неотправленных событий нет.
+func (db *DBstorage) processOutboxMessage(publish func(models.OutboxMessage) error) (bool, error) {
+	// С запасом на публикацию, которую ретранслятор ограничивает своим таймаутом
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("create transaction failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var msg models.OutboxMessage
+	err = tx.QueryRow(ctx, `SELECT id, event_type, message, attempts, created_at FROM outbox
+		WHERE sent_at IS NULL ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED`).
+		Scan(&msg.ID, &msg.EventType, &msg.Message, &msg.Attempts, &msg.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to select outbox: %w", err)
+	}
+
+	if publishErr := publish(msg); publishErr != nil {
+		_, err := tx.Exec(ctx, `UPDATE outbox SET attempts = attempts + 1, last_error = $1 WHERE id = $2`,
+			publishErr.Error(), msg.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to update outbox: %w", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return false, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return false, fmt.Errorf("failed to publish outbox event: %w", publishErr)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE outbox SET sent_at = NOW() WHERE id = $1`, msg.ID); err != nil {
+		return false, fmt.Errorf("failed to mark outbox as sent: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
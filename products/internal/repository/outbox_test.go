@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+)
+
+func TestProcessOutbox(t *testing.T) {
+	db := testDB(t)
+	addTestProduct(t, db, "apple", 1)
+	addTestProduct(t, db, "pear", 1)
+	addTestProduct(t, db, "plum", 1)
+
+	// Ошибка на втором событии не откатывает отметку первого
+	var published []int64
+	sent, err := db.ProcessOutbox(10, func(msg models.OutboxMessage) error {
+		if len(published) == 1 {
+			return errors.New("broker is down")
+		}
+		published = append(published, msg.ID)
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM outbox WHERE sent_at IS NOT NULL`))
+	assert.Equal(t, 1, countRows(t, db, `SELECT COUNT(*) FROM outbox WHERE last_error = 'broker is down'`))
+
+	sent, err = db.ProcessOutbox(10, func(msg models.OutboxMessage) error {
+		published = append(published, msg.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Len(t, published, 3)
+	assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM outbox WHERE sent_at IS NULL`))
+}
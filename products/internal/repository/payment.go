@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/wileytor/go-market/common/models"
+)
+
+var (
+	ErrPaymentNotFound = errors.New("payment not found")
+	ErrPaymentExists   = errors.New("order already has an active payment")
+	ErrOrderNotPayable = errors.New("only pending orders can be paid")
+	// ErrPaymentInProgress - заказ нельзя отменить, пока провайдер не ответил по его платежу
+	ErrPaymentInProgress = errors.New("order has a payment in progress")
+	ErrNoCapturedPayment = errors.New("order has no captured payment")
+	ErrRefundInProgress  = errors.New("order refund is already in progress")
+)
+
+var paymentColumns = []string{
+	"uid", "order_id", "provider", "COALESCE(provider_payment_id, '')", "status",
+	"amount", "currency", "failure_reason", "action_url", "created_at", "updated_at",
+}
+
+func paymentFields(payment *models.Payment) []interface{} {
+	return []interface{}{
+		&payment.UID, &payment.OrderID, &payment.Provider, &payment.ProviderPaymentID, &payment.Status,
+		&payment.Amount.Amount, &payment.Amount.Currency, &payment.FailureReason, &payment.ActionURL,
+		&payment.CreatedAt, &payment.UpdatedAt,
+	}
+}
+
+// CreatePayment заводит платеж на полную сумму заказа. Платить можно только заказ в статусе pending
+// и только если по нему нет другого незавершенного или успешного платежа.
+func (db *DBstorage) CreatePayment(orderID int, provider string) (models.Payment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	var total models.Money
+	err = tx.QueryRow(ctx, `SELECT status, total_amount, currency FROM orders WHERE uid = $1 FOR UPDATE`, orderID).
+		Scan(&status, &total.Amount, &total.Currency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Payment{}, ErrOrderNotFound
+	}
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if status != models.OrderStatusPending {
+		return models.Payment{}, ErrOrderNotPayable
+	}
+
+	payment := models.Payment{OrderID: orderID, Provider: provider, Amount: total}
+	insertQuery := `INSERT INTO payments (order_id, provider, amount, currency) VALUES ($1, $2, $3, $4)
+		RETURNING uid, status, created_at, updated_at`
+	err = tx.QueryRow(ctx, insertQuery, orderID, provider, total.Amount, total.Currency).
+		Scan(&payment.UID, &payment.Status, &payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return models.Payment{}, ErrPaymentExists
+		}
+		return models.Payment{}, fmt.Errorf("failed to create payment: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Payment{}, err
+	}
+	return payment, nil
+}
+
+// StartRefund переводит списанный платеж заказа в refunding до обращения к провайдеру.
+// Заказ и платеж блокируются, поэтому второй возврат того же заказа получит ErrRefundInProgress.
+func (db *DBstorage) StartRefund(orderID int) (models.Payment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM orders WHERE uid = $1 FOR UPDATE`, orderID).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Payment{}, ErrOrderNotFound
+	}
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if err := models.CheckOrderTransition(status, models.OrderStatusRefunded); err != nil {
+		return models.Payment{}, err
+	}
+
+	sb := sqlbuilder.NewSelectBuilder()
+	query, args := sb.Select(paymentColumns...).From("payments").
+		Where(sb.Equal("order_id", orderID), sb.In("status", models.PaymentStatusCaptured, models.PaymentStatusRefunding)).
+		ForUpdate().
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+	var payment models.Payment
+	err = tx.QueryRow(ctx, query, args...).Scan(paymentFields(&payment)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Payment{}, ErrNoCapturedPayment
+	}
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if payment.Status == models.PaymentStatusRefunding {
+		return models.Payment{}, ErrRefundInProgress
+	}
+
+	payment.Status = models.PaymentStatusRefunding
+	updQuery := `UPDATE payments SET status = $1, updated_at = NOW() WHERE uid = $2 RETURNING updated_at`
+	if err := tx.QueryRow(ctx, updQuery, payment.Status, payment.UID).Scan(&payment.UpdatedAt); err != nil {
+		return models.Payment{}, fmt.Errorf("failed to start refund: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.Payment{}, err
+	}
+	return payment, nil
+}
+
+// UpdatePayment сохраняет ответ провайдера по платежу и переводит заказ в той же транзакции:
+// списание оплачивает заказ, отказ отменяет его и снимает резерв остатков, возврат средств возвращает заказ.
+// Возврат начинается с StartRefund.
+func (db *DBstorage) UpdatePayment(payment models.Payment) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var current string
+	var orderID int
+	err = tx.QueryRow(ctx, `SELECT status, order_id FROM payments WHERE uid = $1 FOR UPDATE`, payment.UID).Scan(&current, &orderID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrPaymentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	// Тот же статус означает только уточнение данных провайдера
+	if current != payment.Status {
+		if err := models.CheckPaymentTransition(current, payment.Status); err != nil {
+			return err
+		}
+	}
+
+	updQuery := `UPDATE payments SET status = $1, provider_payment_id = COALESCE(NULLIF($2, ''), provider_payment_id),
+		failure_reason = $3, action_url = $4, updated_at = NOW()
+		WHERE uid = $5`
+	_, err = tx.Exec(ctx, updQuery, payment.Status, payment.ProviderPaymentID, payment.FailureReason, payment.ActionURL, payment.UID)
+	if err != nil {
+		return fmt.Errorf("failed to update payment: %w", err)
+	}
+
+	if current != payment.Status {
+		switch payment.Status {
+		case models.PaymentStatusCaptured:
+			// Неудачный возврат возвращает платеж в captured, а заказ и так оплачен
+			if current != models.PaymentStatusRefunding {
+				err = changeOrderStatus(ctx, tx, 0, orderID, models.OrderStatusPaid)
+			}
+		case models.PaymentStatusRefunded:
+			err = changeOrderStatus(ctx, tx, 0, orderID, models.OrderStatusRefunded)
+		case models.PaymentStatusFailed:
+			err = changeOrderStatus(ctx, tx, 0, orderID, models.OrderStatusCancelled)
+			// Заказ мог быть отменен покупателем, пока платеж ждал ответа провайдера
+			if errors.Is(err, models.ErrInvalidOrderTransition) {
+				err = nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// hasActivePayment проверяет, есть ли у заказа платеж, по которому провайдер еще не дал окончательного ответа
+func hasActivePayment(ctx context.Context, tx pgx.Tx, orderID int) (bool, error) {
+	var active bool
+	query := `SELECT EXISTS (SELECT 1 FROM payments WHERE order_id = $1 AND status IN ($2, $3, $4))`
+	err := tx.QueryRow(ctx, query, orderID,
+		models.PaymentStatusPending, models.PaymentStatusRequiresAction, models.PaymentStatusAuthorized).Scan(&active)
+	return active, err
+}
+
+// GetExpiredPayments возвращает незавершенные платежи, резерв заказа которых истек больше PaymentGracePeriod назад.
+// Провайдер по ним так и не дал ответа, и заказ иначе держал бы остатки вечно.
+func (db *DBstorage) GetExpiredPayments() ([]models.Payment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	query, args := sb.Select(paymentColumns...).From("payments").
+		Where(
+			sb.In("status", models.PaymentStatusPending, models.PaymentStatusRequiresAction, models.PaymentStatusAuthorized),
+			sb.Exists(sqlbuilder.Buildf(`SELECT 1 FROM stock_reservations r
+				WHERE r.order_id = payments.order_id AND r.expires_at <= NOW() - make_interval(secs => %v)`,
+				db.PaymentGracePeriod.Seconds())),
+		).
+		OrderBy("uid").
+		Limit(expiredReservationsBatch).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	payments := []models.Payment{}
+	for rows.Next() {
+		var payment models.Payment
+		if err := rows.Scan(paymentFields(&payment)...); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (db *DBstorage) GetPaymentByProviderID(provider, providerPaymentID string) (models.Payment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	query, args := sb.Select(paymentColumns...).From("payments").
+		Where(sb.Equal("provider", provider), sb.Equal("provider_payment_id", providerPaymentID)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	var payment models.Payment
+	err := db.Pool.QueryRow(ctx, query, args...).Scan(paymentFields(&payment)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Payment{}, ErrPaymentNotFound
+	}
+	if err != nil {
+		return models.Payment{}, err
+	}
+	return payment, nil
+}
+
+// GetOrderPayments возвращает все попытки оплаты заказа, начиная с первой
+func (db *DBstorage) GetOrderPayments(orderID int) ([]models.Payment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	query, args := sb.Select(paymentColumns...).From("payments").
+		Where(sb.Equal("order_id", orderID)).
+		OrderBy("uid").
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	payments := []models.Payment{}
+	for rows.Next() {
+		var payment models.Payment
+		if err := rows.Scan(paymentFields(&payment)...); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+)
+
+func TestStartRefund(t *testing.T) {
+	db := testDB(t)
+	apple := addTestProduct(t, db, "apple", 5)
+	order, err := db.CreateOrder(7, models.OrderRequest{Items: []models.OrderLine{{ProductID: apple, Quantity: 1}}})
+	require.NoError(t, err)
+
+	_, err = db.StartRefund(order.UID)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderTransition)
+
+	payment, err := db.CreatePayment(order.UID, "fake")
+	require.NoError(t, err)
+	payment.ProviderPaymentID = "fake_1"
+	for _, status := range []string{models.PaymentStatusAuthorized, models.PaymentStatusCaptured} {
+		payment.Status = status
+		require.NoError(t, db.UpdatePayment(payment))
+	}
+
+	refunding, err := db.StartRefund(order.UID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentStatusRefunding, refunding.Status)
+	_, err = db.StartRefund(order.UID)
+	assert.ErrorIs(t, err, ErrRefundInProgress)
+
+	// Неудачный возврат оставляет заказ оплаченным, и возврат можно начать снова
+	refunding.Status = models.PaymentStatusCaptured
+	require.NoError(t, db.UpdatePayment(refunding))
+	paid, err := db.GetOrderByID(order.UID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPaid, paid.Status)
+
+	refunding, err = db.StartRefund(order.UID)
+	require.NoError(t, err)
+	refunding.Status = models.PaymentStatusRefunded
+	require.NoError(t, db.UpdatePayment(refunding))
+	refunded, err := db.GetOrderByID(order.UID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusRefunded, refunded.Status)
+	_, err = db.StartRefund(order.UID)
+	assert.ErrorIs(t, err, models.ErrInvalidOrderTransition)
+}
@@ -10,29 +10,114 @@ import (
 	"github.com/wileytor/go-market/common/models"
 )
 
-func (db *DBstorage) GetAllProducts() ([]models.Product, error) {
+// productColumns - колонки товара в порядке полей productFields
+var productColumns = []string{"uid", "name", "description", "price_amount", "currency", "delete", "quantity"}
+
+func productFields(product *models.Product) []interface{} {
+	return []interface{}{&product.UID, &product.Name, &product.Description, &product.Price.Amount, &product.Price.Currency, &product.Delete, &product.Quantity}
+}
+
+// GetAllProducts возвращает страницу неудаленных товаров, подходящих под фильтр
+func (db *DBstorage) GetAllProducts(filter models.ProductFilter) (models.ProductPage, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultPageLimit
+	}
+	if filter.Sort == "" {
+		filter.Sort = models.SortNewest
+	}
+	minPrice, maxPrice, err := filter.PriceRange()
+	if err != nil {
+		return models.ProductPage{}, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	var cursor *productCursor
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor, filter.Sort)
+		if err != nil {
+			return models.ProductPage{}, err
+		}
+		cursor = &c
+	}
+
+	countSb := sqlbuilder.NewSelectBuilder()
+	countSb.Select("COUNT(*)").From("products")
+	productConditions(countSb, filter, minPrice, maxPrice)
+	countQuery, countArgs := countSb.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	page := models.ProductPage{Items: []models.Product{}, Limit: filter.Limit, Offset: filter.Offset}
+	if err := db.Pool.QueryRow(ctx, countQuery, countArgs...).Scan(&page.Total); err != nil {
+		return models.ProductPage{}, fmt.Errorf("failed to count products: %w", err)
+	}
+
 	sb := sqlbuilder.NewSelectBuilder()
-	query, args := sb.Select("*").From("products").BuildWithFlavor(sqlbuilder.PostgreSQL)
+	sb.Select(productColumns...).From("products")
+	productConditions(sb, filter, minPrice, maxPrice)
+	sb.OrderBy(productOrder(sb, filter.Sort, cursor)...)
+	// лишняя строка показывает, есть ли следующая страница
+	sb.Limit(filter.Limit + 1)
+	if cursor == nil && filter.Offset > 0 {
+		sb.Offset(filter.Offset)
+	}
+	query, args := sb.BuildWithFlavor(sqlbuilder.PostgreSQL)
 
 	rows, err := db.Pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return models.ProductPage{}, err
 	}
 	defer rows.Close()
-	var products []models.Product
+	var last productCursor
 	for rows.Next() {
 		var product models.Product
-		if err := rows.Scan(&product.UID, &product.Name, &product.Description, &product.Price, &product.Delete, &product.Quantity); err != nil {
-			return nil, err
+		if err := rows.Scan(productFields(&product)...); err != nil {
+			return models.ProductPage{}, err
+		}
+		if len(page.Items) == filter.Limit {
+			page.NextCursor = encodeCursor(last)
+			break
 		}
+		last = productCursor{Sort: filter.Sort, UID: product.UID, Price: product.Price.Amount, Name: product.Name}
 		product.Name = strings.TrimSpace(product.Name)
 		product.Description = strings.TrimSpace(product.Description)
-		products = append(products, product)
+		page.Items = append(page.Items, product)
+	}
+	if err := rows.Err(); err != nil {
+		return models.ProductPage{}, err
+	}
+	return page, nil
+}
+
+// productConditions добавляет в запрос условия фильтра
+func productConditions(sb *sqlbuilder.SelectBuilder, filter models.ProductFilter, minPrice, maxPrice *models.Money) {
+	sb.Where(sb.Equal("delete", false))
+	if filter.Currency != "" {
+		sb.Where(sb.Equal("currency", filter.Currency))
+	}
+	if minPrice != nil {
+		sb.Where(sb.GreaterEqualThan("price_amount", minPrice.Amount))
+	}
+	if maxPrice != nil {
+		sb.Where(sb.LessEqualThan("price_amount", maxPrice.Amount))
+	}
+	if filter.InStock {
+		sb.Where(sb.Or(
+			sb.GreaterThan("quantity", 0),
+			"EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.uid AND NOT v.deleted AND v.quantity > 0)",
+		))
+	}
+	if filter.Name != "" {
+		sb.Where(sb.ILike("name", "%"+escapeLike(filter.Name)+"%"))
+	}
+	if filter.CategoryID > 0 {
+		sb.Where(fmt.Sprintf("uid IN (SELECT product_id FROM product_categories WHERE category_id IN (%s))",
+			fmt.Sprintf(categoryTree, sb.Var(filter.CategoryID))))
 	}
-	return products, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 func (db *DBstorage) GetProductByID(uid int) (models.Product, error) {
@@ -40,11 +125,11 @@ func (db *DBstorage) GetProductByID(uid int) (models.Product, error) {
 	defer cancel()
 
 	sb := sqlbuilder.NewSelectBuilder()
-	query, args := sb.Select("*").From("products").Where(sb.Equal("uid", uid)).BuildWithFlavor(sqlbuilder.PostgreSQL)
+	query, args := sb.Select(productColumns...).From("products").Where(sb.Equal("uid", uid)).BuildWithFlavor(sqlbuilder.PostgreSQL)
 
 	row := db.Pool.QueryRow(ctx, query, args...)
 	var product models.Product
-	if err := row.Scan(&product.UID, &product.Name, &product.Description, &product.Price, &product.Delete, &product.Quantity); err != nil {
+	if err := row.Scan(productFields(&product)...); err != nil {
 		return models.Product{}, err
 	}
 	product.Name = strings.TrimSpace(product.Name)
@@ -52,25 +137,56 @@ func (db *DBstorage) GetProductByID(uid int) (models.Product, error) {
 	return product, nil
 }
 
-func (db *DBstorage) AddProduct(product models.Product) (int, error) {
+// AddProduct добавляет товар и кладет его начальный остаток на склад по умолчанию от имени actorID
+func (db *DBstorage) AddProduct(actorID int, product models.Product) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	sb := sqlbuilder.NewInsertBuilder()
-	query, args := sb.InsertInto("products").Cols("name", "description", "price", "quantity").
-		Values(product.Name, product.Description, product.Price, product.Quantity).
+	// Остаток появляется через moveStock вместе с остатком на складе
+	query, args := sb.InsertInto("products").Cols("name", "description", "price_amount", "currency", "quantity").
+		Values(product.Name, product.Description, product.Price.Amount, product.Price.Currency, 0).
 		BuildWithFlavor(sqlbuilder.PostgreSQL)
 	query += " RETURNING uid"
 
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("create transaction failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	var UID int
-	err := db.Pool.QueryRow(ctx, query, args...).Scan(&UID)
+	err = tx.QueryRow(ctx, query, args...).Scan(&UID)
 	if err != nil {
 		return -1, fmt.Errorf("failed to insert product: %w", err)
 	}
+	product.UID = UID
+	warehouseID, err := defaultWarehouse(ctx, tx)
+	if err != nil {
+		return -1, err
+	}
+	err = moveStock(ctx, tx, &models.StockMovement{
+		WarehouseID: warehouseID,
+		ProductID:   UID,
+		Delta:       product.Quantity,
+		Reason:      models.StockReasonRestock,
+		ActorID:     optionalID(actorID),
+	})
+	if err != nil {
+		return -1, err
+	}
+	if err := addOutbox(ctx, tx, models.EventProductCreated, productChanged(product)); err != nil {
+		return -1, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return -1, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return UID, nil
 }
 
-func (db *DBstorage) UpdateProduct(uid int, product models.Product) (int, error) {
+// UpdateProduct перезаписывает данные товара. Изменение общего остатка ложится на склад по умолчанию
+// и записывается в журнал как adjustment от имени actorID.
+func (db *DBstorage) UpdateProduct(actorID, uid int, product models.Product) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	sb := sqlbuilder.NewUpdateBuilder()
@@ -78,19 +194,35 @@ func (db *DBstorage) UpdateProduct(uid int, product models.Product) (int, error)
 		Set(
 			sb.Assign("name", product.Name),
 			sb.Assign("description", product.Description),
-			sb.Assign("price", product.Price),
-			sb.Assign("quantity", product.Quantity),
+			sb.Assign("price_amount", product.Price.Amount),
+			sb.Assign("currency", product.Price.Currency),
 		).
 		Where(sb.Equal("uid", uid)).
 		BuildWithFlavor(sqlbuilder.PostgreSQL)
 	fmt.Printf("Generated query: %s, args: %v\n", query, args)
 	query += " RETURNING uid"
 
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return -1, fmt.Errorf("create transaction failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setStock(ctx, tx, actorID, uid, nil, product.Quantity); err != nil {
+		return -1, err
+	}
 	var UID int
-	err := db.Pool.QueryRow(ctx, query, args...).Scan(&UID)
+	err = tx.QueryRow(ctx, query, args...).Scan(&UID)
 	if err != nil {
 		return -1, fmt.Errorf("update user failed: %w", err)
 	}
+	product.UID = UID
+	if err := addOutbox(ctx, tx, models.EventProductUpdated, productChanged(product)); err != nil {
+		return -1, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return -1, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return UID, nil
 }
 
@@ -100,12 +232,29 @@ func (db *DBstorage) SetDeleteStatus(uid int) error {
 
 	sb := sqlbuilder.NewUpdateBuilder()
 	query, args := sb.Update("products").
-		Set(sb.Assign("delete", true)).Where(sb.Equal("uid", uid)).BuildWithFlavor(sqlbuilder.PostgreSQL)
+		Set(sb.Assign("delete", true)).
+		Where(sb.Equal("uid", uid), sb.Equal("delete", false)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("create transaction failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	_, err := db.Pool.Exec(ctx, query, args...)
+	result, err := tx.Exec(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to set delete status: %w", err)
 	}
+	// Событие отправляем только при первой пометке товара
+	if result.RowsAffected() > 0 {
+		if err := addOutbox(ctx, tx, models.EventProductDeleted, models.ProductDeletedEvent{ProductID: uid}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
@@ -117,59 +266,16 @@ func (db *DBstorage) DeleteProducts() error {
 	if err != nil {
 		return fmt.Errorf("create transaction failed: %w", err)
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(ctx)
-		}
-	}()
+	defer tx.Rollback(ctx)
 
-	// Получаем id продуктов для удаления
-	sbProductsSelect := sqlbuilder.NewSelectBuilder()
-	productQuery, productArgs := sbProductsSelect.Select("uid").
-		From("products").
-		Where(sbProductsSelect.Equal("delete", true)).
-		BuildWithFlavor(sqlbuilder.PostgreSQL)
-
-	var productIDs []int
-	rows, err := tx.Query(ctx, productQuery, productArgs...)
-	if err != nil {
-		return fmt.Errorf("failed to select products for deletion: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var id int
-		if err := rows.Scan(&id); err != nil {
-			return fmt.Errorf("failed to scan product id: %w", err)
-		}
-		productIDs = append(productIDs, id)
-	}
-
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("error iterating rows: %w", err)
-	}
-
-	var productIDInterfaces []interface{}
-	for _, id := range productIDs {
-		productIDInterfaces = append(productIDInterfaces, id)
-	}
-
-	// Удаляем записи из purchases по полученным productIDs
-	if len(productIDs) > 0 {
-		sbPurchases := sqlbuilder.NewDeleteBuilder()
-		purchaseQuery, purchaseArgs := sbPurchases.DeleteFrom("purchases").
-			Where(sbPurchases.In("product_id", productIDInterfaces...)).
-			BuildWithFlavor(sqlbuilder.PostgreSQL)
-
-		if _, err := tx.Exec(ctx, purchaseQuery, purchaseArgs...); err != nil {
-			return fmt.Errorf("failed to delete purchases: %w", err)
-		}
-	}
-
-	// Удаляем продукты
+	// Удаляем продукты. Товары из заказов и с движением остатков остаются, чтобы не терять историю.
 	sbDeleteProducts := sqlbuilder.NewDeleteBuilder()
 	deleteQuery, deleteArgs := sbDeleteProducts.DeleteFrom("products").
-		Where(sbDeleteProducts.Equal("delete", true)).
+		Where(
+			sbDeleteProducts.Equal("delete", true),
+			"NOT EXISTS (SELECT 1 FROM order_items i WHERE i.product_id = products.uid)",
+			"NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = products.uid)",
+		).
 		BuildWithFlavor(sqlbuilder.PostgreSQL)
 
 	if _, err := tx.Exec(ctx, deleteQuery, deleteArgs...); err != nil {
@@ -198,3 +304,13 @@ func (db *DBstorage) IsProductUnique(name string) (bool, error) {
 	}
 	return count == 0, nil
 }
+
+func productChanged(product models.Product) models.ProductChangedEvent {
+	return models.ProductChangedEvent{
+		ProductID:   product.UID,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Quantity:    product.Quantity,
+	}
+}
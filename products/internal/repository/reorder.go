@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"github.com/wileytor/go-market/common/models"
+)
+
+// lowStockAlert - позиция, которую заказ опустил до порога дозаказа
+type lowStockAlert struct {
+	line      models.OrderLine
+	available int
+	threshold int
+	quantity  int
+}
+
+// availableStockSQL - доступный остаток позиции p (товар) и v (вариант) на активных складах:
+// остаток каждого склада за вычетом его резервов. Остаток неактивных складов купить нельзя.
+const availableStockSQL = `COALESCE((SELECT SUM(ws.quantity - COALESCE((SELECT SUM(r.quantity) FROM stock_reservations r
+			WHERE r.warehouse_id = ws.warehouse_id AND r.product_id = ws.product_id
+				AND r.variant_id IS NOT DISTINCT FROM ws.variant_id), 0))
+		FROM warehouse_stock ws
+		JOIN warehouses w ON w.uid = ws.warehouse_id
+		WHERE w.active AND ws.product_id = p.uid AND ws.variant_id IS NOT DISTINCT FROM v.uid), 0)`
+
+// SetReorderPolicy задает порог дозаказа товара. Пустой порог отключает дозаказ.
+func (db *DBstorage) SetReorderPolicy(productID int, policy models.ReorderPolicy) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `UPDATE products SET reorder_threshold = $1, reorder_quantity = $2 WHERE uid = $3 AND NOT delete`
+	result, err := db.Pool.Exec(ctx, query, policy.Threshold, policy.Quantity, productID)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// reorderState возвращает доступный на активных складах остаток позиции, порог и количество дозаказа товара.
+// ok равен false, если порог у товара не задан.
+func reorderState(ctx context.Context, tx pgx.Tx, line models.OrderLine) (available, threshold, quantity int, ok bool, err error) {
+	var limit *int
+	query := `SELECT ` + availableStockSQL + `, p.reorder_threshold, p.reorder_quantity
+		FROM products p
+		LEFT JOIN product_variants v ON v.uid = $2 AND v.product_id = p.uid
+		WHERE p.uid = $1`
+	err = tx.QueryRow(ctx, query, line.ProductID, line.VariantID).Scan(&available, &limit, &quantity)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, 0, false, ErrProductNotFound
+	}
+	if err != nil || limit == nil {
+		return 0, 0, 0, false, err
+	}
+	return available, *limit, quantity, true, nil
+}
+
+// checkLowStock возвращает оповещение, если заказ строки line опустит доступный остаток
+// с уровня выше порога до порога или ниже. Остаток позиции должен быть заблокирован.
+func checkLowStock(ctx context.Context, tx pgx.Tx, line models.OrderLine) (*lowStockAlert, error) {
+	available, threshold, quantity, ok, err := reorderState(ctx, tx, line)
+	if err != nil || !ok {
+		return nil, err
+	}
+	after := available - line.Quantity
+	if available <= threshold || after > threshold {
+		return nil, nil
+	}
+	return &lowStockAlert{line: line, available: after, threshold: threshold, quantity: quantity}, nil
+}
+
+// raiseLowStock открывает заявку поставщику, если по позиции нет открытой, и записывает событие stock.low в outbox
+func raiseLowStock(ctx context.Context, tx pgx.Tx, orderID int, alert lowStockAlert) error {
+	var requestID int
+	query := `SELECT uid FROM restock_requests
+		WHERE product_id = $1 AND COALESCE(variant_id, 0) = $2 AND status = $3`
+	err := tx.QueryRow(ctx, query, alert.line.ProductID, variantKey(alert.line.VariantID), models.RestockStatusOpen).Scan(&requestID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		insQuery := `INSERT INTO restock_requests (product_id, variant_id, quantity, order_id) VALUES ($1, $2, $3, $4) RETURNING uid`
+		// Без заданного количества заказываем столько, чтобы вернуться к порогу
+		quantity := max(alert.quantity, alert.threshold-alert.available, 1)
+		err = tx.QueryRow(ctx, insQuery, alert.line.ProductID, alert.line.VariantID, quantity, orderID).Scan(&requestID)
+	}
+	if err != nil {
+		return err
+	}
+	return addOutbox(ctx, tx, models.EventStockLow, models.StockLowEvent{
+		ProductID:        alert.line.ProductID,
+		VariantID:        alert.line.VariantID,
+		Available:        alert.available,
+		Threshold:        alert.threshold,
+		RestockRequestID: requestID,
+		OrderID:          orderID,
+		OccurredAt:       time.Now().UTC(),
+	})
+}
+
+// fulfillRestockRequests закрывает открытую заявку по позиции, если приход поднял остаток выше порога
+func fulfillRestockRequests(ctx context.Context, tx pgx.Tx, productID int, variantID *int) error {
+	available, threshold, _, ok, err := reorderState(ctx, tx, models.OrderLine{ProductID: productID, VariantID: variantID})
+	if err != nil {
+		return err
+	}
+	if ok && available <= threshold {
+		return nil
+	}
+	query := `UPDATE restock_requests SET status = $1, fulfilled_at = NOW()
+		WHERE product_id = $2 AND COALESCE(variant_id, 0) = $3 AND status = $4`
+	_, err = tx.Exec(ctx, query, models.RestockStatusFulfilled, productID, variantKey(variantID), models.RestockStatusOpen)
+	return err
+}
+
+// GetLowStock возвращает позиции, доступный на активных складах остаток которых не выше порога дозаказа,
+// самые дефицитные первыми
+func (db *DBstorage) GetLowStock() ([]models.LowStockItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Остаток товара с вариантами хранится у вариантов, поэтому такой товар дает строку на каждый вариант
+	query := `SELECT product_id, variant_id, name, sku, available, reorder_threshold, reorder_quantity, restock_request_id FROM (
+			SELECT p.uid AS product_id, v.uid AS variant_id, p.name, COALESCE(v.sku, '') AS sku,
+				` + availableStockSQL + ` AS available,
+				p.reorder_threshold, p.reorder_quantity,
+				(SELECT rr.uid FROM restock_requests rr
+					WHERE rr.product_id = p.uid AND rr.variant_id IS NOT DISTINCT FROM v.uid AND rr.status = $1) AS restock_request_id
+			FROM products p
+			LEFT JOIN product_variants v ON v.product_id = p.uid AND NOT v.deleted
+			WHERE NOT p.delete AND p.reorder_threshold IS NOT NULL
+		) s
+		WHERE available <= reorder_threshold
+		ORDER BY available, product_id, variant_id NULLS FIRST`
+	rows, err := db.Pool.Query(ctx, query, models.RestockStatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []models.LowStockItem{}
+	for rows.Next() {
+		var item models.LowStockItem
+		err := rows.Scan(&item.ProductID, &item.VariantID, &item.Name, &item.SKU, &item.Available,
+			&item.Threshold, &item.ReorderQuantity, &item.RestockRequestID)
+		if err != nil {
+			return nil, err
+		}
+		item.Name = strings.TrimSpace(item.Name)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetRestockRequests возвращает заявки поставщику, новые первыми. Пустой status не фильтрует заявки.
+func (db *DBstorage) GetRestockRequests(status string) ([]models.RestockRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.Select("uid", "product_id", "variant_id", "quantity", "status", "order_id", "created_at", "fulfilled_at").
+		From("restock_requests")
+	if status != "" {
+		sb.Where(sb.Equal("status", status))
+	}
+	query, args := sb.OrderBy("created_at DESC", "uid DESC").BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	requests := []models.RestockRequest{}
+	for rows.Next() {
+		var request models.RestockRequest
+		err := rows.Scan(&request.UID, &request.ProductID, &request.VariantID, &request.Quantity, &request.Status,
+			&request.OrderID, &request.CreatedAt, &request.FulfilledAt)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
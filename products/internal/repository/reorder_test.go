@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+)
+
+func TestGetLowStockActiveWarehouses(t *testing.T) {
+	db := testDB(t)
+	apple := addTestProduct(t, db, "apple", 5)
+	threshold := 6
+	require.NoError(t, db.SetReorderPolicy(apple, models.ReorderPolicy{Threshold: &threshold, Quantity: 10}))
+
+	// Остаток закрытого склада не поднимает доступный остаток выше порога
+	closed := models.Warehouse{Name: fmt.Sprintf("closed-%d", time.Now().UnixNano())}
+	uid, err := db.AddWarehouse(closed)
+	require.NoError(t, err)
+	closed.UID = uid
+	require.NoError(t, db.UpdateWarehouse(closed))
+	_, err = db.AdjustStock(1, apple, models.StockAdjustment{WarehouseID: &closed.UID, Delta: 10, Reason: models.StockReasonRestock})
+	require.NoError(t, err)
+
+	_, err = db.CreateOrder(7, models.OrderRequest{Items: []models.OrderLine{{ProductID: apple, Quantity: 2}}})
+	require.NoError(t, err)
+
+	items, err := db.GetLowStock()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, apple, items[0].ProductID)
+	assert.Equal(t, 3, items[0].Available)
+}
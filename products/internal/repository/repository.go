@@ -1,24 +1,109 @@
 package repository
 
-import "github.com/wileytor/go-market/common/models"
+import (
+	"time"
+
+	"github.com/wileytor/go-market/common/models"
+)
 
 type Repository interface {
-	PurchaseRepository
+	OrderRepository
+	CartRepository
+	PaymentRepository
+	IdempotencyRepository
 	ProductRepository
+	CategoryRepository
+	VariantRepository
+	StockRepository
+	WarehouseRepository
+	ReorderRepository
+	OutboxRepository
+}
+
+type OrderRepository interface {
+	CreateOrder(int, models.OrderRequest) (models.Order, error)
+	ChangeOrderStatus(int, int, string) error
+	GetOrderByID(int) (models.Order, error)
+	GetUserOrders(int) ([]models.Order, error)
+	GetProductOrders(int) ([]models.Order, error)
+	ReleaseExpiredReservations() (int, error)
+}
+
+type CartRepository interface {
+	GetCart(int) (models.Cart, error)
+	AddCartItem(int, models.OrderLine) error
+	UpdateCartItem(int, int, int) error
+	RemoveCartItem(int, int) error
+	ClearCart(int) error
+	CheckoutCart(int, string) (models.Order, error)
+	DeleteExpiredCarts() (int, error)
 }
 
-type PurchaseRepository interface {
-	MakePurchase(models.Purchase) (int, error)
-	GetUserPurchases(int) ([]models.Purchase, error)
-	GetProductPurchases(int) ([]models.Purchase, error)
+type PaymentRepository interface {
+	CreatePayment(int, string) (models.Payment, error)
+	UpdatePayment(models.Payment) error
+	StartRefund(int) (models.Payment, error)
+	GetPaymentByProviderID(string, string) (models.Payment, error)
+	GetOrderPayments(int) ([]models.Payment, error)
+	GetExpiredPayments() ([]models.Payment, error)
+}
+
+type IdempotencyRepository interface {
+	StartIdempotentRequest(models.IdempotencyKey) (*models.IdempotentResponse, error)
+	SaveIdempotentResponse(models.IdempotencyKey, models.IdempotentResponse) error
+	DeleteIdempotencyKey(models.IdempotencyKey) error
+	DeleteExpiredIdempotencyKeys() (int, error)
 }
 
 type ProductRepository interface {
-	GetAllProducts() ([]models.Product, error)
+	GetAllProducts(models.ProductFilter) (models.ProductPage, error)
+	SearchProducts(models.ProductSearch) (models.ProductSearchPage, error)
 	GetProductByID(int) (models.Product, error)
-	AddProduct(models.Product) (int, error)
-	UpdateProduct(int, models.Product) (int, error)
+	AddProduct(int, models.Product) (int, error)
+	UpdateProduct(int, int, models.Product) (int, error)
 	DeleteProducts() error
 	SetDeleteStatus(int) error
 	IsProductUnique(string) (bool, error)
 }
+
+type CategoryRepository interface {
+	GetCategories() ([]models.Category, error)
+	GetCategoryByID(int) (models.Category, error)
+	AddCategory(models.Category) (int, error)
+	UpdateCategory(int, models.Category) error
+	DeleteCategory(int) error
+	SetProductCategories(int, []int) error
+	GetProductBreadcrumbs(int) ([][]models.Category, error)
+}
+
+type VariantRepository interface {
+	GetProductVariants(int) ([]models.ProductVariant, error)
+	AddVariant(int, models.ProductVariant) (int, error)
+	UpdateVariant(int, models.ProductVariant) error
+	DeleteVariant(int, int) error
+}
+
+type StockRepository interface {
+	AdjustStock(int, int, models.StockAdjustment) (models.StockMovement, error)
+	GetStockMovements(int, time.Time, time.Time) ([]models.StockMovement, error)
+	GetStockReport(int, time.Time) (models.StockReport, error)
+}
+
+type WarehouseRepository interface {
+	GetWarehouses() ([]models.Warehouse, error)
+	AddWarehouse(models.Warehouse) (int, error)
+	UpdateWarehouse(models.Warehouse) error
+	GetProductAvailability(int) (models.Availability, error)
+	TransferStock(int, models.StockTransferRequest) (models.StockTransfer, error)
+	GetStockTransfers(int) ([]models.StockTransfer, error)
+}
+
+type ReorderRepository interface {
+	SetReorderPolicy(int, models.ReorderPolicy) error
+	GetLowStock() ([]models.LowStockItem, error)
+	GetRestockRequests(string) ([]models.RestockRequest, error)
+}
+
+type OutboxRepository interface {
+	ProcessOutbox(int, func(models.OutboxMessage) error) (int, error)
+}
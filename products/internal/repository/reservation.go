@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/wileytor/go-market/common/models"
+)
+
+// expiredReservationsBatch - сколько заказов с просроченными резервами отменяется за один проход
+const expiredReservationsBatch = 100
+
+// lockOrderLine блокирует общий остаток строки заказа и возвращает ее цену.
+// Хватает ли остатка, решает allocateOrderLine. Остаток товара с вариантами хранится у вариантов.
+func lockOrderLine(ctx context.Context, tx pgx.Tx, line models.OrderLine) (models.Money, error) {
+	var price models.Money
+	if line.VariantID != nil {
+		query := `SELECT COALESCE(v.price_amount, p.price_amount), p.currency
+			FROM product_variants v
+			JOIN products p ON p.uid = v.product_id
+			WHERE v.uid = $1 AND v.product_id = $2 AND NOT v.deleted AND NOT p.delete
+			FOR UPDATE OF v`
+		err := tx.QueryRow(ctx, query, *line.VariantID, line.ProductID).Scan(&price.Amount, &price.Currency)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Money{}, fmt.Errorf("%w: product %d", ErrVariantNotFound, line.ProductID)
+		}
+		if err != nil {
+			return models.Money{}, err
+		}
+		return price, nil
+	}
+	var hasVariants bool
+	query := `SELECT price_amount, currency, EXISTS (SELECT 1 FROM product_variants WHERE product_id = products.uid)
+		FROM products
+		WHERE uid = $1 AND NOT delete
+		FOR UPDATE`
+	err := tx.QueryRow(ctx, query, line.ProductID).Scan(&price.Amount, &price.Currency, &hasVariants)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Money{}, fmt.Errorf("%w: product %d", ErrProductNotFound, line.ProductID)
+	}
+	if err != nil {
+		return models.Money{}, err
+	}
+	if hasVariants {
+		return models.Money{}, fmt.Errorf("%w: product %d", ErrVariantRequired, line.ProductID)
+	}
+	return price, nil
+}
+
+// reserveOrderItem удерживает часть строки заказа на складе до expiresAt
+func reserveOrderItem(ctx context.Context, tx pgx.Tx, userID, orderID int, allocation stockAllocation, expiresAt time.Time) error {
+	line := allocation.line
+	query := `INSERT INTO stock_reservations (order_id, warehouse_id, product_id, variant_id, quantity, expires_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	if _, err := tx.Exec(ctx, query, orderID, allocation.warehouseID, line.ProductID, line.VariantID, line.Quantity, expiresAt); err != nil {
+		return fmt.Errorf("failed to reserve stock: %w", err)
+	}
+	return recordStockMovement(ctx, tx, &models.StockMovement{
+		WarehouseID:   allocation.warehouseID,
+		ProductID:     line.ProductID,
+		VariantID:     line.VariantID,
+		ReservedDelta: line.Quantity,
+		Reason:        models.StockReasonReservation,
+		ActorID:       optionalID(userID),
+		OrderID:       &orderID,
+	})
+}
+
+// orderReservations возвращает резервы заказа, сложенные по складам, товарам и вариантам
+func orderReservations(ctx context.Context, tx pgx.Tx, orderID int) ([]stockAllocation, error) {
+	query := `SELECT warehouse_id, product_id, variant_id, SUM(quantity) FROM stock_reservations
+		WHERE order_id = $1
+		GROUP BY warehouse_id, product_id, variant_id
+		ORDER BY product_id, variant_id NULLS FIRST, warehouse_id`
+	return queryAllocations(ctx, tx, query, orderID)
+}
+
+// queryAllocations читает строки (warehouse_id, product_id, variant_id, quantity)
+func queryAllocations(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) ([]stockAllocation, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var allocations []stockAllocation
+	for rows.Next() {
+		var allocation stockAllocation
+		line := &allocation.line
+		if err := rows.Scan(&allocation.warehouseID, &line.ProductID, &line.VariantID, &line.Quantity); err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, allocation)
+	}
+	return allocations, rows.Err()
+}
+
+// sellReservations превращает резервы заказа в продажу: списывает остатки со складов резерва и снимает резервы
+func sellReservations(ctx context.Context, tx pgx.Tx, actorID, orderID int) error {
+	allocations, err := orderReservations(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, allocation := range allocations {
+		line := allocation.line
+		err := moveStock(ctx, tx, &models.StockMovement{
+			WarehouseID:   allocation.warehouseID,
+			ProductID:     line.ProductID,
+			VariantID:     line.VariantID,
+			Delta:         -line.Quantity,
+			ReservedDelta: -line.Quantity,
+			Reason:        models.StockReasonSale,
+			ActorID:       optionalID(actorID),
+			OrderID:       &orderID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sell reserved stock: %w", err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM stock_reservations WHERE order_id = $1`, orderID); err != nil {
+		return fmt.Errorf("failed to sell reserved stock: %w", err)
+	}
+	return nil
+}
+
+// releaseReservations снимает резервы заказа, и остатки снова становятся доступны
+func releaseReservations(ctx context.Context, tx pgx.Tx, actorID, orderID int) error {
+	allocations, err := orderReservations(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	for _, allocation := range allocations {
+		line := allocation.line
+		err := recordStockMovement(ctx, tx, &models.StockMovement{
+			WarehouseID:   allocation.warehouseID,
+			ProductID:     line.ProductID,
+			VariantID:     line.VariantID,
+			ReservedDelta: -line.Quantity,
+			Reason:        models.StockReasonReservation,
+			ActorID:       optionalID(actorID),
+			OrderID:       &orderID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM stock_reservations WHERE order_id = $1`, orderID); err != nil {
+		return fmt.Errorf("failed to release reserved stock: %w", err)
+	}
+	return nil
+}
+
+// ReleaseExpiredReservations отменяет неоплаченные заказы, у которых истек резерв, и возвращает их число.
+// Заказ, по которому идет платеж, ждет его итога: отказ отменит заказ, списание превратит резерв в продажу.
+// Платеж без ответа дольше PaymentGracePeriod отклоняет SweepReservations через GetExpiredPayments.
+func (db *DBstorage) ReleaseExpiredReservations() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Заказы с незавершенным платежом не выбираются, чтобы не занимать пачку и не задерживать остальные
+	query := `SELECT DISTINCT r.order_id FROM stock_reservations r
+		WHERE r.expires_at <= NOW()
+			AND NOT EXISTS (SELECT 1 FROM payments p WHERE p.order_id = r.order_id AND p.status IN ($2, $3, $4))
+		ORDER BY r.order_id LIMIT $1`
+	rows, err := tx.Query(ctx, query, expiredReservationsBatch,
+		models.PaymentStatusPending, models.PaymentStatusRequiresAction, models.PaymentStatusAuthorized)
+	if err != nil {
+		return 0, err
+	}
+	var orderIDs []int
+	for rows.Next() {
+		var orderID int
+		if err := rows.Scan(&orderID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, orderID := range orderIDs {
+		var status string
+		err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE uid = $1 FOR UPDATE`, orderID).Scan(&status)
+		if err != nil {
+			return 0, err
+		}
+		if status != models.OrderStatusPending {
+			if err := releaseReservations(ctx, tx, 0, orderID); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		err = changeOrderStatus(ctx, tx, 0, orderID, models.OrderStatusCancelled)
+		// Заказ начали оплачивать после выборки; changeOrderStatus проверяет платеж под блокировкой заказа
+		if errors.Is(err, ErrPaymentInProgress) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to cancel order %d: %w", orderID, err)
+		}
+		released++
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return released, nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+)
+
+func TestReleaseExpiredReservations(t *testing.T) {
+	db := testDB(t)
+	apple := addTestProduct(t, db, "apple", 5)
+	request := models.OrderRequest{Items: []models.OrderLine{{ProductID: apple, Quantity: 2}}}
+
+	unpaid, err := db.CreateOrder(7, request)
+	require.NoError(t, err)
+	paying, err := db.CreateOrder(8, request)
+	require.NoError(t, err)
+	payment, err := db.CreatePayment(paying.UID, "fake")
+	require.NoError(t, err)
+	_, err = db.Pool.Exec(context.Background(), `UPDATE stock_reservations SET expires_at = NOW() - interval '1 minute'`)
+	require.NoError(t, err)
+
+	// Неоплаченный заказ отменяется, заказ с платежом ждет ответа провайдера
+	released, err := db.ReleaseExpiredReservations()
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+	order, err := db.GetOrderByID(unpaid.UID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusCancelled, order.Status)
+	order, err = db.GetOrderByID(paying.UID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusPending, order.Status)
+	availability, err := db.GetProductAvailability(apple)
+	require.NoError(t, err)
+	assert.Equal(t, 3, availability.Available)
+
+	// Платеж считается просроченным только после отсрочки
+	expired, err := db.GetExpiredPayments()
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	db.PaymentGracePeriod = 0
+	expired, err = db.GetExpiredPayments()
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, payment.UID, expired[0].UID)
+
+	expired[0].Status = models.PaymentStatusFailed
+	expired[0].FailureReason = "payment_expired"
+	require.NoError(t, db.UpdatePayment(expired[0]))
+	order, err = db.GetOrderByID(paying.UID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusCancelled, order.Status)
+	assert.Zero(t, countRows(t, db, `SELECT COUNT(*) FROM stock_reservations`))
+
+	released, err = db.ReleaseExpiredReservations()
+	require.NoError(t, err)
+	assert.Zero(t, released)
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/wileytor/go-market/common/models"
+)
+
+var ErrEmptySearchQuery = errors.New("search query has no words")
+
+const (
+	searchHighlightStart = "<b>"
+	searchHighlightStop  = "</b>"
+	maxSearchTerms       = 10
+
+	escapedSearchText = `replace(replace(replace(name || ' ' || description, '&', '&amp;'), '<', '&lt;'), '>', '&gt;')`
+)
+
+// searchTerms превращает строку поиска в запрос tsquery: слова объединяются по И,
+// последнее слово ищется по префиксу, чтобы поиск работал во время ввода
+func searchTerms(q string) (string, error) {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return "", ErrEmptySearchQuery
+	}
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+	words[len(words)-1] += ":*"
+	return strings.Join(words, " & "), nil
+}
+
+// SearchProducts ищет неудаленные товары по названию и описанию с учетом морфологии
+// русского и английского языков и сортирует их по релевантности
+func (db *DBstorage) SearchProducts(search models.ProductSearch) (models.ProductSearchPage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if search.Limit <= 0 {
+		search.Limit = models.DefaultPageLimit
+	}
+	terms, err := searchTerms(search.Query)
+	if err != nil {
+		return models.ProductSearchPage{}, err
+	}
+
+	// запрос строится в обоих словарях, документ подходит, если совпал хотя бы один
+	tsquery := func(sb *sqlbuilder.SelectBuilder) string {
+		v := sb.Var(terms)
+		return fmt.Sprintf("(to_tsquery('russian', %s) || to_tsquery('english', %s))", v, v)
+	}
+
+	countSb := sqlbuilder.NewSelectBuilder()
+	countSb.Select("COUNT(*)").From("products").Where(
+		countSb.Equal("delete", false),
+		"search_vector @@ "+tsquery(countSb),
+	)
+	countQuery, countArgs := countSb.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	page := models.ProductSearchPage{Items: []models.ProductSearchResult{}, Limit: search.Limit, Offset: search.Offset}
+	if err := db.Pool.QueryRow(ctx, countQuery, countArgs...).Scan(&page.Total); err != nil {
+		return models.ProductSearchPage{}, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	sb := sqlbuilder.NewSelectBuilder()
+	query := tsquery(sb)
+	columns := append([]string(nil), productColumns...)
+	columns = append(columns,
+		fmt.Sprintf("ts_rank_cd(search_vector, %s) AS rank", query),
+		// текст экранируется до подсветки, чтобы в snippet не было разметки, кроме выделения
+		fmt.Sprintf("ts_headline('russian', %s, %s, %s) AS snippet", escapedSearchText,
+			query, sb.Var(fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=20, MinWords=5, MaxFragments=2",
+				searchHighlightStart, searchHighlightStop))),
+	)
+	sb.Select(columns...).From("products").Where(
+		sb.Equal("delete", false),
+		"search_vector @@ "+query,
+	).OrderBy("rank DESC", "uid ASC").Limit(search.Limit).Offset(search.Offset)
+	selectQuery, args := sb.BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	rows, err := db.Pool.Query(ctx, selectQuery, args...)
+	if err != nil {
+		return models.ProductSearchPage{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var result models.ProductSearchResult
+		if err := rows.Scan(append(productFields(&result.Product), &result.Rank, &result.Snippet)...); err != nil {
+			return models.ProductSearchPage{}, err
+		}
+		result.Name = strings.TrimSpace(result.Name)
+		result.Description = strings.TrimSpace(result.Description)
+		page.Items = append(page.Items, result)
+	}
+	if err := rows.Err(); err != nil {
+		return models.ProductSearchPage{}, err
+	}
+	return page, nil
+}
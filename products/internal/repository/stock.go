@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"github.com/wileytor/go-market/common/models"
+)
+
+var ErrNegativeStock = errors.New("stock cannot become negative")
+
+var stockMovementColumns = []string{
+	"uid", "warehouse_id", "product_id", "variant_id", "delta", "reserved_delta", "reason",
+	"actor_id", "order_id", "transfer_id", "comment", "created_at",
+}
+
+func stockMovementFields(movement *models.StockMovement) []interface{} {
+	return []interface{}{
+		&movement.UID, &movement.WarehouseID, &movement.ProductID, &movement.VariantID, &movement.Delta, &movement.ReservedDelta,
+		&movement.Reason, &movement.ActorID, &movement.OrderID, &movement.TransferID, &movement.Comment, &movement.CreatedAt,
+	}
+}
+
+// optionalID превращает нулевой id в NULL
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// recordStockMovement дописывает движение в журнал, не меняя остаток. Пустые движения не записываются.
+func recordStockMovement(ctx context.Context, tx pgx.Tx, movement *models.StockMovement) error {
+	if movement.Delta == 0 && movement.ReservedDelta == 0 {
+		return nil
+	}
+	query := `INSERT INTO stock_movements (warehouse_id, product_id, variant_id, delta, reserved_delta, reason, actor_id, order_id, transfer_id, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING uid, created_at`
+	err := tx.QueryRow(ctx, query, movement.WarehouseID, movement.ProductID, movement.VariantID, movement.Delta, movement.ReservedDelta,
+		movement.Reason, movement.ActorID, movement.OrderID, movement.TransferID, movement.Comment).Scan(&movement.UID, &movement.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record stock movement: %w", err)
+	}
+	return nil
+}
+
+// lockStock блокирует общий остаток товара или варианта и возвращает его.
+// Все изменения остатков позиции проходят через эту блокировку, в том числе по отдельным складам.
+func lockStock(ctx context.Context, tx pgx.Tx, productID int, variantID *int) (int, error) {
+	query := `SELECT quantity FROM products WHERE uid = $1 FOR UPDATE`
+	args := []interface{}{productID}
+	notFound := ErrProductNotFound
+	if variantID != nil {
+		query = `SELECT quantity FROM product_variants WHERE uid = $1 AND product_id = $2 FOR UPDATE`
+		args = []interface{}{*variantID, productID}
+		notFound = ErrVariantNotFound
+	}
+	var quantity int
+	err := tx.QueryRow(ctx, query, args...).Scan(&quantity)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, notFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return quantity, nil
+}
+
+// moveStock меняет остаток товара или варианта на складе movement.WarehouseID на movement.Delta,
+// пересчитывает общий остаток и записывает движение в журнал.
+// Списание не опускает остаток на складе ниже зарезервированного под заказы количества
+// (с учётом movement.ReservedDelta), иначе moveStock возвращает ErrNegativeStock.
+func moveStock(ctx context.Context, tx pgx.Tx, movement *models.StockMovement) error {
+	if movement.Delta != 0 {
+		if _, err := lockStock(ctx, tx, movement.ProductID, movement.VariantID); err != nil {
+			return err
+		}
+		var quantity int
+		query := `SELECT quantity FROM warehouse_stock
+			WHERE warehouse_id = $1 AND product_id = $2 AND COALESCE(variant_id, 0) = $3
+			FOR UPDATE`
+		err := tx.QueryRow(ctx, query, movement.WarehouseID, movement.ProductID, variantKey(movement.VariantID)).Scan(&quantity)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if movement.Delta < 0 {
+			var reserved int
+			resQuery := `SELECT COALESCE(SUM(quantity), 0) FROM stock_reservations
+				WHERE warehouse_id = $1 AND product_id = $2 AND COALESCE(variant_id, 0) = $3`
+			err := tx.QueryRow(ctx, resQuery, movement.WarehouseID, movement.ProductID, variantKey(movement.VariantID)).Scan(&reserved)
+			if err != nil {
+				return err
+			}
+			if quantity+movement.Delta < reserved+movement.ReservedDelta {
+				return ErrNegativeStock
+			}
+		}
+		upsQuery := `INSERT INTO warehouse_stock (warehouse_id, product_id, variant_id, quantity) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (warehouse_id, product_id, (COALESCE(variant_id, 0)))
+			DO UPDATE SET quantity = warehouse_stock.quantity + EXCLUDED.quantity`
+		if _, err := tx.Exec(ctx, upsQuery, movement.WarehouseID, movement.ProductID, movement.VariantID, movement.Delta); err != nil {
+			return fmt.Errorf("failed to update warehouse stock: %w", err)
+		}
+		updQuery := `UPDATE products SET quantity = quantity + $1 WHERE uid = $2`
+		id := movement.ProductID
+		if movement.VariantID != nil {
+			updQuery = `UPDATE product_variants SET quantity = quantity + $1 WHERE uid = $2`
+			id = *movement.VariantID
+		}
+		if _, err := tx.Exec(ctx, updQuery, movement.Delta, id); err != nil {
+			return fmt.Errorf("failed to update stock: %w", err)
+		}
+	}
+	return recordStockMovement(ctx, tx, movement)
+}
+
+// setStock выставляет общий остаток товара или варианта в quantity при изменении товара или варианта.
+// Разница ложится на склад по умолчанию и записывается в журнал как adjustment.
+func setStock(ctx context.Context, tx pgx.Tx, actorID, productID int, variantID *int, quantity int) error {
+	current, err := lockStock(ctx, tx, productID, variantID)
+	if err != nil {
+		return err
+	}
+	if quantity == current {
+		return nil
+	}
+	warehouseID, err := defaultWarehouse(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return moveStock(ctx, tx, &models.StockMovement{
+		WarehouseID: warehouseID,
+		ProductID:   productID,
+		VariantID:   variantID,
+		Delta:       quantity - current,
+		Reason:      models.StockReasonAdjustment,
+		ActorID:     optionalID(actorID),
+	})
+}
+
+// checkStockProduct проверяет, что товар и вариант не удалены, а у товара с вариантами остаток меняется у варианта
+func checkStockProduct(ctx context.Context, tx pgx.Tx, productID int, variantID *int) error {
+	var deleted, hasVariants bool
+	query := `SELECT delete, EXISTS (SELECT 1 FROM product_variants WHERE product_id = uid) FROM products WHERE uid = $1`
+	err := tx.QueryRow(ctx, query, productID).Scan(&deleted, &hasVariants)
+	if errors.Is(err, pgx.ErrNoRows) || deleted {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if variantID == nil {
+		if hasVariants {
+			return ErrVariantRequired
+		}
+		return nil
+	}
+	var exists bool
+	query = `SELECT EXISTS (SELECT 1 FROM product_variants WHERE uid = $1 AND product_id = $2 AND NOT deleted)`
+	if err := tx.QueryRow(ctx, query, *variantID, productID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrVariantNotFound
+	}
+	return nil
+}
+
+// AdjustStock вручную меняет остаток товара или варианта на складе и возвращает запись журнала.
+// Без adjustment.WarehouseID остаток меняется на складе по умолчанию.
+func (db *DBstorage) AdjustStock(actorID, productID int, adjustment models.StockAdjustment) (models.StockMovement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return models.StockMovement{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkStockProduct(ctx, tx, productID, adjustment.VariantID); err != nil {
+		return models.StockMovement{}, err
+	}
+	var warehouseID int
+	if adjustment.WarehouseID != nil {
+		warehouseID = *adjustment.WarehouseID
+		err = checkWarehouse(ctx, tx, warehouseID)
+	} else {
+		warehouseID, err = defaultWarehouse(ctx, tx)
+	}
+	if err != nil {
+		return models.StockMovement{}, err
+	}
+
+	movement := models.StockMovement{
+		WarehouseID: warehouseID,
+		ProductID:   productID,
+		VariantID:   adjustment.VariantID,
+		Delta:       adjustment.Delta,
+		Reason:      adjustment.Reason,
+		ActorID:     optionalID(actorID),
+		Comment:     adjustment.Comment,
+	}
+	if err := moveStock(ctx, tx, &movement); err != nil {
+		return models.StockMovement{}, err
+	}
+	if movement.Delta > 0 {
+		if err := fulfillRestockRequests(ctx, tx, productID, adjustment.VariantID); err != nil {
+			return models.StockMovement{}, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.StockMovement{}, err
+	}
+	return movement, nil
+}
+
+// GetStockMovements возвращает журнал остатков товара за период, старые записи первыми.
+// Нулевые from и to не ограничивают период.
+func (db *DBstorage) GetStockMovements(productID int, from, to time.Time) ([]models.StockMovement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	sb.Select(stockMovementColumns...).From("stock_movements").Where(sb.Equal("product_id", productID))
+	if !from.IsZero() {
+		sb.Where(sb.GreaterEqualThan("created_at", from.UTC()))
+	}
+	if !to.IsZero() {
+		sb.Where(sb.LessEqualThan("created_at", to.UTC()))
+	}
+	query, args := sb.OrderBy("created_at", "uid").BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		var movement models.StockMovement
+		if err := rows.Scan(stockMovementFields(&movement)...); err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// GetStockReport восстанавливает по журналу остатки товара и его вариантов по складам на момент at
+func (db *DBstorage) GetStockReport(productID int, at time.Time) (models.StockReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var exists bool
+	if err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM products WHERE uid = $1)`, productID).Scan(&exists); err != nil {
+		return models.StockReport{}, err
+	}
+	if !exists {
+		return models.StockReport{}, ErrProductNotFound
+	}
+
+	query := `SELECT warehouse_id, variant_id, SUM(delta), SUM(reserved_delta)
+		FROM stock_movements
+		WHERE product_id = $1 AND created_at <= $2
+		GROUP BY warehouse_id, variant_id
+		ORDER BY warehouse_id, variant_id NULLS FIRST`
+	rows, err := db.Pool.Query(ctx, query, productID, at.UTC())
+	if err != nil {
+		return models.StockReport{}, err
+	}
+	defer rows.Close()
+	report := models.StockReport{ProductID: productID, At: at, Levels: []models.StockLevel{}}
+	for rows.Next() {
+		var level models.StockLevel
+		if err := rows.Scan(&level.WarehouseID, &level.VariantID, &level.Quantity, &level.Reserved); err != nil {
+			return models.StockReport{}, err
+		}
+		level.Available = level.Quantity - level.Reserved
+		report.Levels = append(report.Levels, level)
+	}
+	if err := rows.Err(); err != nil {
+		return models.StockReport{}, err
+	}
+	return report, nil
+}
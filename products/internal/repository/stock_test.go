@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+)
+
+func TestAdjustStockBelowReserved(t *testing.T) {
+	db := testDB(t)
+	apple := addTestProduct(t, db, "apple", 5)
+	_, err := db.CreateOrder(7, models.OrderRequest{Items: []models.OrderLine{{ProductID: apple, Quantity: 3}}})
+	require.NoError(t, err)
+
+	// Списать можно только то, что не зарезервировано под заказы
+	_, err = db.AdjustStock(1, apple, models.StockAdjustment{Delta: -3, Reason: models.StockReasonAdjustment})
+	assert.ErrorIs(t, err, ErrNegativeStock)
+	_, err = db.AdjustStock(1, apple, models.StockAdjustment{Delta: -2, Reason: models.StockReasonAdjustment})
+	require.NoError(t, err)
+
+	availability, err := db.GetProductAvailability(apple)
+	require.NoError(t, err)
+	assert.Equal(t, 3, availability.Quantity)
+	assert.Equal(t, 3, availability.Reserved)
+	assert.Zero(t, availability.Available)
+}
@@ -3,12 +3,23 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type DBstorage struct {
 	Pool *pgxpool.Pool
+	// CartTTL - срок жизни корзины с последнего изменения
+	CartTTL time.Duration
+	// IdempotencyTTL - сколько хранится ответ на запрос с ключом идемпотентности
+	IdempotencyTTL time.Duration
+	// ReservationTTL - сколько остатки держатся за неоплаченным заказом
+	ReservationTTL time.Duration
+	// PaymentGracePeriod - сколько платеж может ждать ответа провайдера после истечения резерва заказа
+	PaymentGracePeriod time.Duration
+	// AllocationStrategy - стратегия выбора склада при резервировании заказа, см. models.Allocation*
+	AllocationStrategy string
 }
 
 // Создание нового пула соединений
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+)
+
+// testDB подключается к базе из TEST_DB_ADDR, накатывает миграции и очищает данные прошлых тестов.
+// Без TEST_DB_ADDR тесты с базой пропускаются.
+func testDB(t *testing.T) *DBstorage {
+	dbAddr := os.Getenv("TEST_DB_ADDR")
+	if dbAddr == "" {
+		t.Skip("TEST_DB_ADDR is not set")
+	}
+	zlog := zerolog.Nop()
+	require.NoError(t, Migrations(dbAddr, "../../migrations", &zlog))
+
+	pool, err := pgxpool.New(context.Background(), dbAddr)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	_, err = pool.Exec(context.Background(), `TRUNCATE products, orders, outbox, idempotency_keys, carts RESTART IDENTITY CASCADE`)
+	require.NoError(t, err)
+
+	db, err := NewDB(pool)
+	require.NoError(t, err)
+	db.ReservationTTL = 15 * time.Minute
+	db.PaymentGracePeriod = 15 * time.Minute
+	db.AllocationStrategy = models.AllocationPriority
+	return db
+}
+
+// addTestProduct заводит товар с остатком quantity на складе по умолчанию
+func addTestProduct(t *testing.T, db *DBstorage, name string, quantity int) int {
+	uid, err := db.AddProduct(0, models.Product{
+		Name:     name,
+		Price:    models.Money{Amount: 10000, Currency: "RUB"},
+		Quantity: quantity,
+	})
+	require.NoError(t, err)
+	return uid
+}
+
+// countRows возвращает число строк запроса query
+func countRows(t *testing.T, db *DBstorage, query string, args ...interface{}) int {
+	var count int
+	require.NoError(t, db.Pool.QueryRow(context.Background(), query, args...).Scan(&count))
+	return count
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/wileytor/go-market/common/models"
+)
+
+var (
+	ErrVariantNotFound = errors.New("variant not found")
+	ErrVariantRequired = errors.New("product has variants, variant must be specified")
+	ErrSKUExists       = errors.New("variant with this sku already exists")
+)
+
+// pgForeignKeyViolation - код ошибки нарушения внешнего ключа в PostgreSQL
+const pgForeignKeyViolation = "23503"
+
+func (db *DBstorage) GetProductVariants(productID int) ([]models.ProductVariant, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewSelectBuilder()
+	query, args := sb.Select("v.uid", "v.product_id", "v.sku", "v.attributes", "v.price_amount", "p.currency", "v.quantity").
+		From("product_variants v").
+		Join("products p", "p.uid = v.product_id").
+		Where(sb.Equal("v.product_id", productID), sb.Equal("v.deleted", false)).
+		OrderBy("v.uid").
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	variants := []models.ProductVariant{}
+	for rows.Next() {
+		var variant models.ProductVariant
+		var priceAmount *int64
+		var currency string
+		if err := rows.Scan(&variant.UID, &variant.ProductID, &variant.SKU, &variant.Attributes, &priceAmount, &currency, &variant.Quantity); err != nil {
+			return nil, err
+		}
+		if priceAmount != nil {
+			variant.Price = &models.Money{Amount: *priceAmount, Currency: currency}
+		}
+		variants = append(variants, variant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// AddVariant добавляет вариант и кладет его начальный остаток на склад по умолчанию от имени actorID
+func (db *DBstorage) AddVariant(actorID int, variant models.ProductVariant) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	priceAmount, err := db.variantPrice(ctx, variant)
+	if err != nil {
+		return -1, err
+	}
+	if variant.Attributes == nil {
+		variant.Attributes = map[string]string{}
+	}
+	sb := sqlbuilder.NewInsertBuilder()
+	// Остаток появляется через moveStock вместе с остатком на складе
+	query, args := sb.InsertInto("product_variants").Cols("product_id", "sku", "attributes", "price_amount", "quantity").
+		Values(variant.ProductID, variant.SKU, variant.Attributes, priceAmount, 0).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+	query += " RETURNING uid"
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback(ctx)
+
+	var UID int
+	if err := tx.QueryRow(ctx, query, args...).Scan(&UID); err != nil {
+		return -1, variantError(err)
+	}
+	warehouseID, err := defaultWarehouse(ctx, tx)
+	if err != nil {
+		return -1, err
+	}
+	err = moveStock(ctx, tx, &models.StockMovement{
+		WarehouseID: warehouseID,
+		ProductID:   variant.ProductID,
+		VariantID:   &UID,
+		Delta:       variant.Quantity,
+		Reason:      models.StockReasonRestock,
+		ActorID:     optionalID(actorID),
+	})
+	if err != nil {
+		return -1, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return -1, err
+	}
+	return UID, nil
+}
+
+// UpdateVariant перезаписывает данные варианта. Изменение общего остатка ложится на склад по умолчанию
+// и записывается в журнал как adjustment от имени actorID.
+func (db *DBstorage) UpdateVariant(actorID int, variant models.ProductVariant) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	priceAmount, err := db.variantPrice(ctx, variant)
+	if err != nil {
+		return err
+	}
+	if variant.Attributes == nil {
+		variant.Attributes = map[string]string{}
+	}
+	sb := sqlbuilder.NewUpdateBuilder()
+	query, args := sb.Update("product_variants").
+		Set(
+			sb.Assign("sku", variant.SKU),
+			sb.Assign("attributes", variant.Attributes),
+			sb.Assign("price_amount", priceAmount),
+		).
+		Where(sb.Equal("uid", variant.UID), sb.Equal("product_id", variant.ProductID), sb.Equal("deleted", false)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return variantError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrVariantNotFound
+	}
+	if err := setStock(ctx, tx, actorID, variant.ProductID, &variant.UID, variant.Quantity); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// variantPrice проверяет, что цена варианта задана в валюте товара, и возвращает ее в минимальных единицах
+func (db *DBstorage) variantPrice(ctx context.Context, variant models.ProductVariant) (*int64, error) {
+	var currency string
+	err := db.Pool.QueryRow(ctx, `SELECT currency FROM products WHERE uid = $1`, variant.ProductID).Scan(&currency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product currency: %w", err)
+	}
+	if variant.Price == nil {
+		return nil, nil
+	}
+	if variant.Price.Currency != currency {
+		return nil, fmt.Errorf("%w: variant price in %s, product price in %s", models.ErrCurrencyMismatch, variant.Price.Currency, currency)
+	}
+	return &variant.Price.Amount, nil
+}
+
+// DeleteVariant помечает вариант удаленным. Заказы и журнал остатков продолжают ссылаться на него,
+// но в списке вариантов, корзине и новых заказах его больше нет.
+func (db *DBstorage) DeleteVariant(productID, variantID int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sb := sqlbuilder.NewUpdateBuilder()
+	query, args := sb.Update("product_variants").
+		Set(sb.Assign("deleted", true)).
+		Where(sb.Equal("uid", variantID), sb.Equal("product_id", productID), sb.Equal("deleted", false)).
+		BuildWithFlavor(sqlbuilder.PostgreSQL)
+
+	result, err := db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete variant: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrVariantNotFound
+	}
+	return nil
+}
+
+func variantError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return ErrSKUExists
+		case pgForeignKeyViolation:
+			return ErrProductNotFound
+		}
+	}
+	return fmt.Errorf("failed to save variant: %w", err)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+)
+
+func TestDeleteStockedVariant(t *testing.T) {
+	db := testDB(t)
+	shirt := addTestProduct(t, db, "shirt", 0)
+	variant, err := db.AddVariant(0, models.ProductVariant{ProductID: shirt, SKU: "TS-RED-M", Quantity: 3})
+	require.NoError(t, err)
+
+	// Журнал остатков ссылается на вариант, но удалить его можно
+	require.NoError(t, db.DeleteVariant(shirt, variant))
+	assert.ErrorIs(t, db.DeleteVariant(shirt, variant), ErrVariantNotFound)
+	variants, err := db.GetProductVariants(shirt)
+	require.NoError(t, err)
+	assert.Empty(t, variants)
+
+	_, err = db.CreateOrder(7, models.OrderRequest{Items: []models.OrderLine{{ProductID: shirt, VariantID: &variant, Quantity: 1}}})
+	assert.ErrorIs(t, err, ErrVariantNotFound)
+	assert.ErrorIs(t, db.AddCartItem(7, models.OrderLine{ProductID: shirt, VariantID: &variant, Quantity: 1}), ErrVariantNotFound)
+}
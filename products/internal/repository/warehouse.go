@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/wileytor/go-market/common/models"
+)
+
+var (
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+	ErrWarehouseExists   = errors.New("warehouse with this name already exists")
+	ErrNoWarehouse       = errors.New("no warehouse to keep stock")
+)
+
+// allocationOrders - порядок, в котором стратегии перебирают склады при резервировании.
+// Стратегия region сравнивает регион склада с параметром $3.
+var allocationOrders = map[string]string{
+	models.AllocationPriority: "w.priority DESC, w.uid",
+	models.AllocationRegion:   "w.region = $3 DESC, w.priority DESC, w.uid",
+	models.AllocationLargest:  "available DESC, w.priority DESC, w.uid",
+}
+
+// stockAllocation - часть строки заказа, которую берет на себя один склад
+type stockAllocation struct {
+	warehouseID int
+	line        models.OrderLine
+}
+
+func (db *DBstorage) GetWarehouses() ([]models.Warehouse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT uid, name, region, priority, active, created_at FROM warehouses ORDER BY priority DESC, uid`
+	rows, err := db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	warehouses := []models.Warehouse{}
+	for rows.Next() {
+		var warehouse models.Warehouse
+		if err := rows.Scan(&warehouse.UID, &warehouse.Name, &warehouse.Region, &warehouse.Priority, &warehouse.Active, &warehouse.CreatedAt); err != nil {
+			return nil, err
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return warehouses, nil
+}
+
+// AddWarehouse добавляет активный склад
+func (db *DBstorage) AddWarehouse(warehouse models.Warehouse) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var uid int
+	query := `INSERT INTO warehouses (name, region, priority) VALUES ($1, $2, $3) RETURNING uid`
+	if err := db.Pool.QueryRow(ctx, query, warehouse.Name, warehouse.Region, warehouse.Priority).Scan(&uid); err != nil {
+		return -1, warehouseError(err)
+	}
+	return uid, nil
+}
+
+// UpdateWarehouse перезаписывает данные склада. Остатки и резервы склада не меняются.
+func (db *DBstorage) UpdateWarehouse(warehouse models.Warehouse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `UPDATE warehouses SET name = $1, region = $2, priority = $3, active = $4 WHERE uid = $5`
+	result, err := db.Pool.Exec(ctx, query, warehouse.Name, warehouse.Region, warehouse.Priority, warehouse.Active, warehouse.UID)
+	if err != nil {
+		return warehouseError(err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrWarehouseNotFound
+	}
+	return nil
+}
+
+func warehouseError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrWarehouseExists
+	}
+	return err
+}
+
+// defaultWarehouse возвращает склад, на который ложатся остатки без явно указанного склада:
+// активный склад с наибольшим приоритетом
+func defaultWarehouse(ctx context.Context, tx pgx.Tx) (int, error) {
+	var uid int
+	query := `SELECT uid FROM warehouses ORDER BY active DESC, priority DESC, uid LIMIT 1`
+	err := tx.QueryRow(ctx, query).Scan(&uid)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNoWarehouse
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uid, nil
+}
+
+func checkWarehouse(ctx context.Context, tx pgx.Tx, warehouseID int) error {
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM warehouses WHERE uid = $1)`, warehouseID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrWarehouseNotFound
+	}
+	return nil
+}
+
+// allocateOrderLine делит строку заказа между активными складами в порядке стратегии db.AllocationStrategy:
+// каждый следующий склад берет столько, сколько у него доступно. Остаток позиции должен быть заблокирован.
+func (db *DBstorage) allocateOrderLine(ctx context.Context, tx pgx.Tx, line models.OrderLine, region string) ([]stockAllocation, error) {
+	strategy := db.AllocationStrategy
+	if strategy == "" || strategy == models.AllocationRegion && region == "" {
+		// Без региона покупателя склады не отличаются по удаленности
+		strategy = models.AllocationPriority
+	}
+	order, ok := allocationOrders[strategy]
+	if !ok {
+		return nil, fmt.Errorf("unknown allocation strategy %q", strategy)
+	}
+	query := `SELECT w.uid, ws.quantity - COALESCE((SELECT SUM(r.quantity) FROM stock_reservations r
+				WHERE r.warehouse_id = ws.warehouse_id AND r.product_id = ws.product_id
+					AND r.variant_id IS NOT DISTINCT FROM ws.variant_id), 0) AS available
+		FROM warehouse_stock ws
+		JOIN warehouses w ON w.uid = ws.warehouse_id
+		WHERE w.active AND ws.product_id = $1 AND COALESCE(ws.variant_id, 0) = $2
+		ORDER BY ` + order
+	args := []interface{}{line.ProductID, variantKey(line.VariantID)}
+	if strategy == models.AllocationRegion {
+		args = append(args, region)
+	}
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var allocations []stockAllocation
+	remaining := line.Quantity
+	for rows.Next() && remaining > 0 {
+		var warehouseID, available int
+		if err := rows.Scan(&warehouseID, &available); err != nil {
+			return nil, err
+		}
+		if available <= 0 {
+			continue
+		}
+		part := line
+		part.Quantity = min(available, remaining)
+		allocations = append(allocations, stockAllocation{warehouseID: warehouseID, line: part})
+		remaining -= part.Quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if remaining > 0 {
+		return nil, fmt.Errorf("%w: product %d, %d available", ErrInsufficientStock, line.ProductID, line.Quantity-remaining)
+	}
+	return allocations, nil
+}
+
+// GetProductAvailability возвращает остатки товара и его вариантов по складам
+func (db *DBstorage) GetProductAvailability(productID int) (models.Availability, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT w.uid, w.name, w.region, w.active, ws.variant_id, ws.quantity,
+			COALESCE((SELECT SUM(r.quantity) FROM stock_reservations r
+				WHERE r.warehouse_id = ws.warehouse_id AND r.product_id = ws.product_id
+					AND r.variant_id IS NOT DISTINCT FROM ws.variant_id), 0)
+		FROM warehouse_stock ws
+		JOIN warehouses w ON w.uid = ws.warehouse_id
+		WHERE ws.product_id = $1
+		ORDER BY w.priority DESC, w.uid, ws.variant_id NULLS FIRST`
+	rows, err := db.Pool.Query(ctx, query, productID)
+	if err != nil {
+		return models.Availability{}, err
+	}
+	defer rows.Close()
+	availability := models.Availability{Warehouses: []models.WarehouseAvailability{}}
+	for rows.Next() {
+		var stock models.WarehouseAvailability
+		err := rows.Scan(&stock.WarehouseID, &stock.Name, &stock.Region, &stock.Active, &stock.VariantID, &stock.Quantity, &stock.Reserved)
+		if err != nil {
+			return models.Availability{}, err
+		}
+		stock.Available = stock.Quantity - stock.Reserved
+		availability.Quantity += stock.Quantity
+		availability.Reserved += stock.Reserved
+		if stock.Active {
+			availability.Available += stock.Available
+		}
+		availability.Warehouses = append(availability.Warehouses, stock)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Availability{}, err
+	}
+	return availability, nil
+}
+
+// TransferStock перемещает остаток между складами от имени actorID.
+// Перемещается только доступный на складе-источнике остаток: зарезервированное под заказы остается на месте.
+func (db *DBstorage) TransferStock(actorID int, request models.StockTransferRequest) (models.StockTransfer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkStockProduct(ctx, tx, request.ProductID, request.VariantID); err != nil {
+		return models.StockTransfer{}, err
+	}
+	for _, warehouseID := range []int{request.FromWarehouseID, request.ToWarehouseID} {
+		if err := checkWarehouse(ctx, tx, warehouseID); err != nil {
+			return models.StockTransfer{}, err
+		}
+	}
+	if _, err := lockStock(ctx, tx, request.ProductID, request.VariantID); err != nil {
+		return models.StockTransfer{}, err
+	}
+	var available int
+	query := `SELECT COALESCE((SELECT quantity FROM warehouse_stock
+			WHERE warehouse_id = $1 AND product_id = $2 AND COALESCE(variant_id, 0) = $3), 0)
+		- COALESCE((SELECT SUM(quantity) FROM stock_reservations
+			WHERE warehouse_id = $1 AND product_id = $2 AND COALESCE(variant_id, 0) = $3), 0)`
+	err = tx.QueryRow(ctx, query, request.FromWarehouseID, request.ProductID, variantKey(request.VariantID)).Scan(&available)
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	if available < request.Quantity {
+		return models.StockTransfer{}, fmt.Errorf("%w: %d available at warehouse %d", ErrInsufficientStock, available, request.FromWarehouseID)
+	}
+
+	transfer := models.StockTransfer{
+		FromWarehouseID: request.FromWarehouseID,
+		ToWarehouseID:   request.ToWarehouseID,
+		ProductID:       request.ProductID,
+		VariantID:       request.VariantID,
+		Quantity:        request.Quantity,
+		ActorID:         optionalID(actorID),
+		Comment:         request.Comment,
+	}
+	insQuery := `INSERT INTO stock_transfers (from_warehouse_id, to_warehouse_id, product_id, variant_id, quantity, actor_id, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING uid, created_at`
+	err = tx.QueryRow(ctx, insQuery, transfer.FromWarehouseID, transfer.ToWarehouseID, transfer.ProductID, transfer.VariantID,
+		transfer.Quantity, transfer.ActorID, transfer.Comment).Scan(&transfer.UID, &transfer.CreatedAt)
+	if err != nil {
+		return models.StockTransfer{}, fmt.Errorf("failed to insert stock transfer: %w", err)
+	}
+	moves := []struct{ warehouseID, delta int }{
+		{transfer.FromWarehouseID, -transfer.Quantity},
+		{transfer.ToWarehouseID, transfer.Quantity},
+	}
+	for _, move := range moves {
+		err := moveStock(ctx, tx, &models.StockMovement{
+			WarehouseID: move.warehouseID,
+			ProductID:   transfer.ProductID,
+			VariantID:   transfer.VariantID,
+			Delta:       move.delta,
+			Reason:      models.StockReasonTransfer,
+			ActorID:     transfer.ActorID,
+			TransferID:  &transfer.UID,
+			Comment:     transfer.Comment,
+		})
+		if err != nil {
+			return models.StockTransfer{}, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return models.StockTransfer{}, err
+	}
+	return transfer, nil
+}
+
+// GetStockTransfers возвращает перемещения товара между складами, новые первыми
+func (db *DBstorage) GetStockTransfers(productID int) ([]models.StockTransfer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT uid, from_warehouse_id, to_warehouse_id, product_id, variant_id, quantity, actor_id, comment, created_at
+		FROM stock_transfers
+		WHERE product_id = $1
+		ORDER BY created_at DESC, uid DESC`
+	rows, err := db.Pool.Query(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	transfers := []models.StockTransfer{}
+	for rows.Next() {
+		var transfer models.StockTransfer
+		err := rows.Scan(&transfer.UID, &transfer.FromWarehouseID, &transfer.ToWarehouseID, &transfer.ProductID, &transfer.VariantID,
+			&transfer.Quantity, &transfer.ActorID, &transfer.Comment, &transfer.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
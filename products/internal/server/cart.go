@@ -0,0 +1,200 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/repository"
+	"github.com/wileytor/go-market/products/internal/server/responses"
+)
+
+// GetCartHandler возвращает корзину
+// @Summary Корзина
+// @Description Возвращает корзину владельца токена с текущими ценами и доступными остатками товаров
+// @Tags Корзина
+// @Produce json
+// @Success 200 {object} responses.Success{data=models.Cart}
+// @Failure 401 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /cart/ [get]
+func (s *Server) GetCartHandler(ctx *gin.Context) {
+	s.sendCart(ctx, http.StatusOK, "Cart found")
+}
+
+// AddCartItemHandler кладет товар в корзину
+// @Summary Добавление товара в корзину
+// @Description Кладет товар в корзину. Если товар уже есть в корзине, количество увеличивается.
+// @Description Для товара с вариантами нужно передать variantID.
+// @Tags Корзина
+// @Accept json
+// @Produce json
+// @Param item body models.OrderLine true "Товар и количество"
+// @Success 200 {object} responses.Success{data=models.Cart}
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /cart/items [post]
+func (s *Server) AddCartItemHandler(ctx *gin.Context) {
+	var line models.OrderLine
+	if err := ctx.ShouldBindJSON(&line); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(line); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid cart item", err)
+		return
+	}
+	if err := s.Db.AddCartItem(auth.UserID(ctx), line); err != nil {
+		sendCartError(ctx, err)
+		return
+	}
+	s.sendCart(ctx, http.StatusOK, "Cart item added")
+}
+
+// UpdateCartItemHandler меняет количество товара в корзине
+// @Summary Изменение количества в корзине
+// @Tags Корзина
+// @Accept json
+// @Produce json
+// @Param itemID path int true "ID строки корзины"
+// @Param quantity body models.CartQuantity true "Новое количество"
+// @Success 200 {object} responses.Success{data=models.Cart}
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /cart/items/{itemID} [put]
+func (s *Server) UpdateCartItemHandler(ctx *gin.Context) {
+	itemID, ok := cartItemID(ctx)
+	if !ok {
+		return
+	}
+	var request models.CartQuantity
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid quantity", err)
+		return
+	}
+	if err := s.Db.UpdateCartItem(auth.UserID(ctx), itemID, request.Quantity); err != nil {
+		sendCartError(ctx, err)
+		return
+	}
+	s.sendCart(ctx, http.StatusOK, "Cart item updated")
+}
+
+// RemoveCartItemHandler убирает товар из корзины
+// @Summary Удаление товара из корзины
+// @Tags Корзина
+// @Produce json
+// @Param itemID path int true "ID строки корзины"
+// @Success 200 {object} responses.Success{data=models.Cart}
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /cart/items/{itemID} [delete]
+func (s *Server) RemoveCartItemHandler(ctx *gin.Context) {
+	itemID, ok := cartItemID(ctx)
+	if !ok {
+		return
+	}
+	if err := s.Db.RemoveCartItem(auth.UserID(ctx), itemID); err != nil {
+		sendCartError(ctx, err)
+		return
+	}
+	s.sendCart(ctx, http.StatusOK, "Cart item removed")
+}
+
+// ClearCartHandler очищает корзину
+// @Summary Очистка корзины
+// @Tags Корзина
+// @Produce json
+// @Success 200 {object} responses.Success
+// @Failure 401 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /cart/ [delete]
+func (s *Server) ClearCartHandler(ctx *gin.Context) {
+	if err := s.Db.ClearCart(auth.UserID(ctx)); err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to clear cart", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Cart cleared", nil)
+}
+
+// CheckoutCartHandler оформляет заказ из корзины
+// @Summary Оформление корзины
+// @Description Оформляет заказ из всех строк корзины по текущим ценам и очищает корзину.
+// @Description Если какого-то товара не хватает, заказ не создается и корзина остается прежней.
+// @Description Тело необязательно: region нужен стратегии выбора склада region.
+// @Tags Корзина
+// @Accept json
+// @Produce json
+// @Param checkout body models.CheckoutRequest false "Регион доставки"
+// @Success 201 {object} responses.Success{data=models.Order}
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /cart/checkout [post]
+func (s *Server) CheckoutCartHandler(ctx *gin.Context) {
+	var request models.CheckoutRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid checkout request", err)
+		return
+	}
+	order, err := s.Db.CheckoutCart(auth.UserID(ctx), request.Region)
+	if errors.Is(err, repository.ErrCartEmpty) {
+		responses.SendError(ctx, http.StatusBadRequest, "Cart is empty", err)
+		return
+	}
+	if err != nil {
+		sendOrderError(ctx, err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusCreated, "Order created", order)
+}
+
+func (s *Server) sendCart(ctx *gin.Context, status int, message string) {
+	cart, err := s.Db.GetCart(auth.UserID(ctx))
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get cart", err)
+		return
+	}
+	responses.SendSuccess(ctx, status, message, cart)
+}
+
+func cartItemID(ctx *gin.Context) (int, bool) {
+	itemID, err := strconv.Atoi(ctx.Param("itemID"))
+	if err != nil || itemID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid cart item id", err)
+		return 0, false
+	}
+	return itemID, true
+}
+
+func sendCartError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrCartItemNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Cart item not found", err)
+	case errors.Is(err, repository.ErrProductNotFound), errors.Is(err, repository.ErrVariantNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Product not found", err)
+	case errors.Is(err, repository.ErrVariantRequired):
+		responses.SendError(ctx, http.StatusBadRequest, "Variant is required", err)
+	default:
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to update cart", err)
+	}
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+func TestCartHandlers(t *testing.T) {
+	ts := newTestServer(t)
+
+	token := ts.sign(7, models.RoleBuyer)
+
+	expiresAt := time.Date(2024, 11, 4, 10, 0, 0, 0, time.UTC)
+	total := models.Money{Amount: 20000, Currency: "RUB"}
+	cart := models.Cart{
+		UserID: 7,
+		Items: []models.CartItem{{
+			UID:       1,
+			ProductID: 1,
+			Name:      "apple",
+			Quantity:  2,
+			Price:     models.Money{Amount: 10000, Currency: "RUB"},
+			Total:     total,
+			InStock:   5,
+			Available: true,
+		}},
+		Total:     &total,
+		ExpiresAt: &expiresAt,
+	}
+	cartBody := `{"userID":7,"items":[{"uid":1,"productID":1,"name":"apple","quantity":2,` +
+		`"price":{"amount":"100.00","currency":"RUB"},"total":{"amount":"200.00","currency":"RUB"},"in_stock":5,"available":true}],` +
+		`"total":{"amount":"200.00","currency":"RUB"},"expires_at":"2024-11-04T10:00:00Z"}`
+
+	type test struct {
+		name      string
+		method    string
+		path      string
+		body      string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name:   "Test CartHandlers; Case 1: get cart",
+			method: http.MethodGet,
+			path:   "/cart/",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetCart(7).Return(cart, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Cart found","data":` + cartBody + `}`,
+			},
+		},
+		{
+			name:   "Test CartHandlers; Case 2: add item",
+			method: http.MethodPost,
+			path:   "/cart/items",
+			body:   `{"productID":1,"quantity":2}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().AddCartItem(7, models.OrderLine{ProductID: 1, Quantity: 2}).Return(nil)
+				m.EXPECT().GetCart(7).Return(cart, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Cart item added","data":` + cartBody + `}`,
+			},
+		},
+		{
+			name:   "Test CartHandlers; Case 3: add item without variant",
+			method: http.MethodPost,
+			path:   "/cart/items",
+			body:   `{"productID":2,"quantity":1}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().AddCartItem(7, models.OrderLine{ProductID: 2, Quantity: 1}).Return(repository.ErrVariantRequired)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Variant is required","error":"product has variants, variant must be specified"}`,
+			},
+		},
+		{
+			name:   "Test CartHandlers; Case 4: add item with zero quantity",
+			method: http.MethodPost,
+			path:   "/cart/items",
+			body:   `{"productID":1,"quantity":0}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid cart item","error":"Key: 'OrderLine.Quantity' Error:Field validation for 'Quantity' failed on the 'required' tag"}`,
+			},
+		},
+		{
+			name:   "Test CartHandlers; Case 5: update quantity of a missing item",
+			method: http.MethodPut,
+			path:   "/cart/items/9",
+			body:   `{"quantity":3}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().UpdateCartItem(7, 9, 3).Return(repository.ErrCartItemNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Cart item not found","error":"cart item not found"}`,
+			},
+		},
+		{
+			name:   "Test CartHandlers; Case 6: remove item",
+			method: http.MethodDelete,
+			path:   "/cart/items/1",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().RemoveCartItem(7, 1).Return(nil)
+				m.EXPECT().GetCart(7).Return(models.Cart{UserID: 7, Items: []models.CartItem{}}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Cart item removed","data":{"userID":7,"items":[]}}`,
+			},
+		},
+		{
+			name:   "Test CartHandlers; Case 7: clear cart",
+			method: http.MethodDelete,
+			path:   "/cart/",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().ClearCart(7).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Cart cleared","data":null}`,
+			},
+		},
+		{
+			name:   "Test CartHandlers; Case 8: checkout",
+			method: http.MethodPost,
+			path:   "/cart/checkout",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().CheckoutCart(7, "").Return(models.Order{
+					UID:       10,
+					UserID:    7,
+					Status:    models.OrderStatusPending,
+					Items:     []models.OrderItem{},
+					Total:     total,
+					History:   []models.OrderStatusChange{},
+					CreatedAt: expiresAt,
+					UpdatedAt: expiresAt,
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body: `{"status":201,"message":"Order created","data":{"uid":10,"userID":7,"status":"pending","items":[],` +
+					`"total":{"amount":"200.00","currency":"RUB"},"history":[],"created_at":"2024-11-04T10:00:00Z","updated_at":"2024-11-04T10:00:00Z"}}`,
+			},
+		},
+		{
+			name:   "Test CartHandlers; Case 9: checkout of an empty cart",
+			method: http.MethodPost,
+			path:   "/cart/checkout",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().CheckoutCart(7, "").Return(models.Order{}, repository.ErrCartEmpty)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Cart is empty","error":"cart is empty"}`,
+			},
+		},
+		{
+			name:   "Test CartHandlers; Case 10: checkout when stock ran out",
+			method: http.MethodPost,
+			path:   "/cart/checkout",
+			body:   `{"region":"eu"}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().CheckoutCart(7, "eu").Return(models.Order{}, fmt.Errorf("%w: product 1", repository.ErrInsufficientStock))
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Not enough stock","error":"not enough stock: product 1"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := ts.server(t, tc.setupMock)
+			r := gin.New()
+			cartGroup := r.Group("/cart", srv.Authenticate())
+			cartGroup.GET("/", srv.GetCartHandler)
+			cartGroup.DELETE("/", srv.ClearCartHandler)
+			cartGroup.POST("/items", srv.AddCartItemHandler)
+			cartGroup.PUT("/items/:itemID", srv.UpdateCartItemHandler)
+			cartGroup.DELETE("/items/:itemID", srv.RemoveCartItemHandler)
+			cartGroup.POST("/checkout", srv.CheckoutCartHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			req := resty.New().R().SetHeader("Authorization", "Bearer "+token)
+			if tc.body != "" {
+				req.SetHeader("Content-Type", "application/json").SetBody(tc.body)
+			}
+			resp, err := req.Execute(tc.method, httpSrv.URL+tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
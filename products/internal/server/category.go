@@ -0,0 +1,222 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/repository"
+	"github.com/wileytor/go-market/products/internal/server/responses"
+)
+
+// GetCategoriesHandler возвращает все категории
+// @Summary Список категорий
+// @Description Возвращает все категории плоским списком, иерархия задается parent_id
+// @Tags Категории
+// @Produce json
+// @Success 200 {object} responses.Success{data=[]models.Category}
+// @Failure 500 {object} responses.Error
+// @Router /categories [get]
+func (s *Server) GetCategoriesHandler(ctx *gin.Context) {
+	categories, err := s.Db.GetCategories()
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to retrieve categories", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List of categories", categories)
+}
+
+// GetCategoryByIDHandler возвращает категорию по id
+// @Summary Получение категории
+// @Tags Категории
+// @Param id path int true "Category ID"
+// @Produce json
+// @Success 200 {object} responses.Success{data=models.Category}
+// @Failure 400 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Router /categories/{id} [get]
+func (s *Server) GetCategoryByIDHandler(ctx *gin.Context) {
+	uid, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+	category, err := s.Db.GetCategoryByID(uid)
+	if err != nil {
+		sendCategoryError(ctx, err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Category found", category)
+}
+
+// GetCategoryProductsHandler возвращает товары категории и всех ее подкатегорий
+// @Summary Товары категории
+// @Description Принимает те же параметры фильтрации и постраничного вывода, что и /products
+// @Tags Категории
+// @Param id path int true "Category ID"
+// @Produce json
+// @Success 200 {object} responses.Success{data=models.ProductPage}
+// @Failure 400 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Router /categories/{id}/products [get]
+func (s *Server) GetCategoryProductsHandler(ctx *gin.Context) {
+	uid, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+	filter, ok := s.bindProductFilter(ctx)
+	if !ok {
+		return
+	}
+	if _, err := s.Db.GetCategoryByID(uid); err != nil {
+		sendCategoryError(ctx, err)
+		return
+	}
+	filter.CategoryID = uid
+	s.sendProductPage(ctx, filter)
+}
+
+// AddCategoryHandler создает категорию
+// @Summary Добавление категории
+// @Description Создает корневую категорию или подкатегорию, если задан parent_id
+// @Tags Категории
+// @Accept json
+// @Produce json
+// @Param category body models.Category true "Category data"
+// @Success 201 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Router /categories/add [post]
+func (s *Server) AddCategoryHandler(ctx *gin.Context) {
+	var category models.Category
+	if err := ctx.ShouldBindJSON(&category); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(category); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid category", err)
+		return
+	}
+	uid, err := s.Db.AddCategory(category)
+	if err != nil {
+		sendCategoryError(ctx, err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusCreated, "Category added", uid)
+}
+
+// UpdateCategoryHandler переименовывает категорию или переносит ее к другому родителю
+// @Summary Обновление категории
+// @Tags Категории
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param category body models.Category true "Category data"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Router /categories/{id} [put]
+func (s *Server) UpdateCategoryHandler(ctx *gin.Context) {
+	uid, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+	var category models.Category
+	if err := ctx.ShouldBindJSON(&category); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(category); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid category", err)
+		return
+	}
+	if err := s.Db.UpdateCategory(uid, category); err != nil {
+		sendCategoryError(ctx, err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Category updated", uid)
+}
+
+// DeleteCategoryHandler удаляет категорию без подкатегорий
+// @Summary Удаление категории
+// @Description Товары категории не удаляются, снимается только привязка
+// @Tags Категории
+// @Param id path int true "Category ID"
+// @Produce json
+// @Success 200 {object} responses.Success
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Router /categories/{id} [delete]
+func (s *Server) DeleteCategoryHandler(ctx *gin.Context) {
+	uid, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+	if err := s.Db.DeleteCategory(uid); err != nil {
+		sendCategoryError(ctx, err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Category deleted", uid)
+}
+
+// SetProductCategoriesHandler заменяет категории товара
+// @Summary Категории товара
+// @Description Заменяет набор категорий товара, пустой список снимает все привязки
+// @Tags Продукты
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param categories body models.ProductCategories true "Category ids"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Router /products/{id}/categories [put]
+func (s *Server) SetProductCategoriesHandler(ctx *gin.Context) {
+	uid, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+	var request models.ProductCategories
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid category list", err)
+		return
+	}
+	if err := s.Db.SetProductCategories(uid, request.CategoryIDs); err != nil {
+		if errors.Is(err, repository.ErrProductNotFound) {
+			responses.SendError(ctx, http.StatusNotFound, "Product not found", err)
+			return
+		}
+		if errors.Is(err, repository.ErrCategoryNotFound) {
+			responses.SendError(ctx, http.StatusBadRequest, "Unknown category", err)
+			return
+		}
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to set product categories", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Product categories updated", uid)
+}
+
+// sendCategoryError переводит ошибки дерева категорий в HTTP-статусы
+func sendCategoryError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrCategoryNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Category not found", err)
+	case errors.Is(err, repository.ErrParentNotFound), errors.Is(err, repository.ErrCategoryCycle):
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid parent category", err)
+	case errors.Is(err, repository.ErrCategoryExists), errors.Is(err, repository.ErrCategoryHasChildren):
+		responses.SendError(ctx, http.StatusConflict, "Category conflict", err)
+	default:
+		responses.SendError(ctx, http.StatusInternalServerError, "Category operation failed", err)
+	}
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-resty/resty/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+func TestCategoryHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := Server{Valid: validator.New()}
+	r := gin.New()
+	r.GET("/categories/:id/products", srv.GetCategoryProductsHandler)
+	r.POST("/categories/add", srv.AddCategoryHandler)
+	r.PUT("/categories/:id", srv.UpdateCategoryHandler)
+	r.DELETE("/categories/:id", srv.DeleteCategoryHandler)
+	r.PUT("/products/:id/categories", srv.SetProductCategoriesHandler)
+	r.GET("/products/:id", srv.GetProductByIDHandler)
+	httpSrv := httptest.NewServer(r)
+	defer httpSrv.Close()
+
+	parent := 1
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+	type test struct {
+		name      string
+		method    string
+		request   string
+		body      string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name:    "Test CategoryHandlers; Case 1: subcategory is added",
+			method:  http.MethodPost,
+			request: "/categories/add",
+			body:    `{"name":"phones","parent_id":1}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().AddCategory(models.Category{Name: "phones", ParentID: &parent}).Return(2, nil)
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body:       `{"status":201,"message":"Category added","data":2}`,
+			},
+		},
+		{
+			name:    "Test CategoryHandlers; Case 2: duplicate name",
+			method:  http.MethodPost,
+			request: "/categories/add",
+			body:    `{"name":"phones"}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().AddCategory(models.Category{Name: "phones"}).Return(-1, repository.ErrCategoryExists)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Category conflict","error":"category with this name already exists under the parent"}`,
+			},
+		},
+		{
+			name:    "Test CategoryHandlers; Case 3: move under descendant",
+			method:  http.MethodPut,
+			request: "/categories/3",
+			body:    `{"name":"phones","parent_id":1}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().UpdateCategory(3, models.Category{Name: "phones", ParentID: &parent}).Return(repository.ErrCategoryCycle)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Invalid parent category","error":"category cannot be moved under itself or its descendant"}`,
+			},
+		},
+		{
+			name:    "Test CategoryHandlers; Case 4: delete category with children",
+			method:  http.MethodDelete,
+			request: "/categories/1",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().DeleteCategory(1).Return(repository.ErrCategoryHasChildren)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Category conflict","error":"category has subcategories"}`,
+			},
+		},
+		{
+			name:    "Test CategoryHandlers; Case 5: products of category subtree",
+			method:  http.MethodGet,
+			request: "/categories/1/products?in_stock=true",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetCategoryByID(1).Return(models.Category{UID: 1, Name: "electronics"}, nil)
+				m.EXPECT().GetAllProducts(models.ProductFilter{InStock: true, CategoryID: 1}).
+					Return(models.ProductPage{Items: []models.Product{}, Limit: 20}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"List of products","data":{"items":[],"total":0,"limit":20}}`,
+			},
+		},
+		{
+			name:    "Test CategoryHandlers; Case 6: products of unknown category",
+			method:  http.MethodGet,
+			request: "/categories/9/products",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetCategoryByID(9).Return(models.Category{}, repository.ErrCategoryNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Category not found","error":"category not found"}`,
+			},
+		},
+		{
+			name:    "Test CategoryHandlers; Case 7: assign unknown category",
+			method:  http.MethodPut,
+			request: "/products/5/categories",
+			body:    `{"category_ids":[1,42]}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().SetProductCategories(5, []int{1, 42}).Return(repository.ErrCategoryNotFound)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Unknown category","error":"category not found"}`,
+			},
+		},
+		{
+			name:    "Test CategoryHandlers; Case 8: product with breadcrumbs",
+			method:  http.MethodGet,
+			request: "/products/5",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetProductByID(5).Return(models.Product{UID: 5, Name: "phone", Description: "black", Price: models.Money{Amount: 10000, Currency: "RUB"}, Quantity: 1}, nil)
+				m.EXPECT().GetProductBreadcrumbs(5).Return([][]models.Category{
+					{{UID: 1, Name: "electronics"}, {UID: 2, Name: "phones", ParentID: &parent}},
+				}, nil)
+				m.EXPECT().GetProductVariants(5).Return([]models.ProductVariant{}, nil)
+				m.EXPECT().GetProductAvailability(5).Return(models.Availability{Warehouses: []models.WarehouseAvailability{}}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Product found","data":{"uid":5,"name":"phone","description":"black","price":{"amount":"100.00","currency":"RUB"},"delete":false,"quantity":1,"availability":{"quantity":0,"reserved":0,"available":0,"warehouses":[]},"breadcrumbs":[[{"uid":1,"name":"electronics"},{"uid":2,"name":"phones","parent_id":1}]]}}`,
+			},
+		},
+		{
+			name:    "Test CategoryHandlers; Case 9: breadcrumbs failure",
+			method:  http.MethodGet,
+			request: "/products/5",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetProductByID(5).Return(models.Product{UID: 5}, nil)
+				m.EXPECT().GetProductBreadcrumbs(5).Return(nil, fmt.Errorf("test error"))
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       `{"status":500,"message":"Failed to retrieve categories","error":"test error"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mocks.NewMockRepository(ctrl)
+			if tc.setupMock != nil {
+				tc.setupMock(m)
+			}
+			srv.Db = m
+
+			req := resty.New().R()
+			if tc.body != "" {
+				req.SetHeader("Content-Type", "application/json").SetBody(tc.body)
+			}
+			resp, err := req.Execute(tc.method, httpSrv.URL+tc.request)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
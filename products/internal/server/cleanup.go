@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/payments"
+)
+
+const (
+	// CleanupInterval - период удаления просроченных корзин и ключей идемпотентности
+	CleanupInterval = 10 * time.Minute
+	// ReservationSweepInterval - период снятия просроченных резервов остатков
+	ReservationSweepInterval = time.Minute
+)
+
+// DeleteExpired с периодом interval удаляет просроченные корзины и ключи идемпотентности, пока не завершится ctx
+func (s *Server) DeleteExpired(ctx context.Context, interval time.Duration) {
+	s.every(ctx, interval, "Cleanup", s.deleteExpired)
+}
+
+// SweepReservations с периодом interval отменяет неоплаченные заказы с истекшим резервом, пока не завершится ctx.
+// Платежи, зависшие дольше резерва и отсрочки, считаются неудачными.
+func (s *Server) SweepReservations(ctx context.Context, interval time.Duration) {
+	s.every(ctx, interval, "Reservation sweeper", s.releaseExpiredReservations)
+}
+
+// every вызывает job с периодом interval, пока не завершится ctx
+func (s *Server) every(ctx context.Context, interval time.Duration, name string, job func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info().Msg(name + " shutting down")
+			return
+		case <-ticker.C:
+			job()
+		}
+	}
+}
+
+func (s *Server) deleteExpired() {
+	carts, err := s.Db.DeleteExpiredCarts()
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to delete expired carts")
+	} else if carts > 0 {
+		s.log.Debug().Int("deleted", carts).Msg("Expired carts deleted")
+	}
+	keys, err := s.Db.DeleteExpiredIdempotencyKeys()
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to delete expired idempotency keys")
+	} else if keys > 0 {
+		s.log.Debug().Int("deleted", keys).Msg("Expired idempotency keys deleted")
+	}
+}
+
+func (s *Server) releaseExpiredReservations() {
+	s.failExpiredPayments()
+	orders, err := s.Db.ReleaseExpiredReservations()
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to release expired reservations")
+	} else if orders > 0 {
+		s.log.Info().Int("cancelled", orders).Msg("Orders with expired reservations cancelled")
+	}
+}
+
+// failExpiredPayments отклоняет платежи, по которым провайдер не ответил до конца отсрочки.
+// Отказ отменяет заказ и снимает резерв, затем платеж отменяется у провайдера.
+func (s *Server) failExpiredPayments() {
+	expired, err := s.Db.GetExpiredPayments()
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to get expired payments")
+		return
+	}
+	for _, payment := range expired {
+		payment.Status = models.PaymentStatusFailed
+		payment.FailureReason = "payment_expired"
+		payment.ActionURL = ""
+		if err := s.Db.UpdatePayment(payment); err != nil {
+			s.log.Warn().Err(err).Int("payment", payment.UID).Msg("Failed to expire payment")
+			continue
+		}
+		s.log.Info().Int("payment", payment.UID).Int("order", payment.OrderID).Msg("Payment expired, order cancelled")
+		s.voidPayment(payment)
+	}
+}
+
+// voidPayment снимает блокировку денег по отклоненному платежу. Если провайдер успел списать деньги, они возвращаются.
+// Ошибки провайдера только логируются: платеж в базе уже отклонен.
+func (s *Server) voidPayment(payment models.Payment) {
+	if s.Payments == nil || payment.ProviderPaymentID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), paymentTimeout)
+	defer cancel()
+	err := s.Payments.Void(ctx, payment.ProviderPaymentID)
+	if errors.Is(err, payments.ErrPaymentCaptured) {
+		err = s.Payments.Refund(ctx, payment.ProviderPaymentID, payment.Amount, refundKey(payment))
+	}
+	if err != nil {
+		s.log.Error().Err(err).Int("payment", payment.UID).Msg("Failed to void payment")
+	}
+}
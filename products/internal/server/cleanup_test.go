@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/payments"
+)
+
+func TestSweepReservations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockRepository(ctrl)
+
+	swept := make(chan struct{})
+	m.EXPECT().GetExpiredPayments().Return([]models.Payment{}, nil).AnyTimes()
+	gomock.InOrder(
+		m.EXPECT().ReleaseExpiredReservations().Return(0, errors.New("connection refused")),
+		m.EXPECT().ReleaseExpiredReservations().DoAndReturn(func() (int, error) {
+			close(swept)
+			return 2, nil
+		}),
+		m.EXPECT().ReleaseExpiredReservations().Return(0, nil).AnyTimes(),
+	)
+
+	srv := newServer(t, m, nil, nil)
+	done := make(chan struct{})
+	go func() {
+		srv.SweepReservations(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-swept:
+	case <-time.After(time.Second):
+		t.Fatal("sweeper did not retry after an error")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sweeper did not stop")
+	}
+}
+
+func TestFailExpiredPayments(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockRepository(ctrl)
+
+	amount := models.Money{Amount: 10000, Currency: "RUB"}
+	fake := payments.NewFake("secret", 0)
+	authorized, err := fake.Authorize(ctx, payments.AuthorizeRequest{PaymentID: 1, OrderID: 1, Amount: amount, Token: payments.FakeTokenApproved})
+	require.NoError(t, err)
+	captured, err := fake.Authorize(ctx, payments.AuthorizeRequest{PaymentID: 2, OrderID: 2, Amount: amount, Token: payments.FakeTokenApproved})
+	require.NoError(t, err)
+	require.NoError(t, fake.Capture(ctx, captured.ProviderPaymentID, amount))
+
+	expired := []models.Payment{
+		{UID: 1, OrderID: 1, ProviderPaymentID: authorized.ProviderPaymentID, Status: models.PaymentStatusAuthorized, Amount: amount},
+		{UID: 2, OrderID: 2, ProviderPaymentID: captured.ProviderPaymentID, Status: models.PaymentStatusAuthorized, Amount: amount},
+		{UID: 3, OrderID: 3, Status: models.PaymentStatusPending, Amount: amount},
+		{UID: 4, OrderID: 4, ProviderPaymentID: "fake_4", Status: models.PaymentStatusRequiresAction, ActionURL: "/challenge", Amount: amount},
+	}
+	m.EXPECT().GetExpiredPayments().Return(expired, nil)
+	for _, payment := range expired[:3] {
+		failed := payment
+		failed.Status = models.PaymentStatusFailed
+		failed.FailureReason = "payment_expired"
+		m.EXPECT().UpdatePayment(failed).Return(nil)
+	}
+	// Платеж, по которому провайдер ответил во время прохода, остается как есть
+	m.EXPECT().UpdatePayment(gomock.Any()).Return(models.ErrInvalidPaymentTransition)
+	m.EXPECT().ReleaseExpiredReservations().Return(0, nil)
+
+	srv := newServer(t, m, nil, nil)
+	srv.Payments = fake
+	srv.releaseExpiredReservations()
+
+	// Авторизованный платеж отменен, списанный возвращен покупателю
+	assert.ErrorIs(t, fake.Capture(ctx, authorized.ProviderPaymentID, amount), payments.ErrFakePaymentNotFound)
+	assert.ErrorIs(t, fake.Refund(ctx, captured.ProviderPaymentID, amount, "refund-other"), payments.ErrFakeInvalidState)
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/repository"
+	"github.com/wileytor/go-market/products/internal/server/responses"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader отмечает ответ, повторенный из сохраненного
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotent запоминает первый ответ на запрос с заголовком Idempotency-Key и повторяет его
+// на запросы пользователя с тем же ключом. Тот же ключ с другим запросом получает 422.
+// Ответы 5xx и паника обработчика освобождают ключ, чтобы запрос можно было повторить.
+// Используется после Authenticate.
+func (s *Server) Idempotent() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		keyValue := ctx.GetHeader(IdempotencyKeyHeader)
+		if keyValue == "" {
+			ctx.Next()
+			return
+		}
+		if len(keyValue) > maxIdempotencyKeyLength {
+			responses.SendError(ctx, http.StatusBadRequest, "Idempotency-Key is too long", nil)
+			ctx.Abort()
+			return
+		}
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := models.IdempotencyKey{
+			UserID:      auth.UserID(ctx),
+			Key:         keyValue,
+			RequestHash: requestHash(ctx.Request.Method, ctx.Request.URL.Path, body),
+		}
+		stored, err := s.Db.StartIdempotentRequest(key)
+		switch {
+		case errors.Is(err, repository.ErrIdempotencyKeyReused):
+			responses.SendError(ctx, http.StatusUnprocessableEntity, "Idempotency-Key was used for another request", err)
+			ctx.Abort()
+			return
+		case errors.Is(err, repository.ErrIdempotencyInProgress):
+			responses.SendError(ctx, http.StatusConflict, "Request with this Idempotency-Key is in progress", err)
+			ctx.Abort()
+			return
+		case err != nil:
+			responses.SendError(ctx, http.StatusInternalServerError, "Failed to check Idempotency-Key", err)
+			ctx.Abort()
+			return
+		case stored != nil:
+			ctx.Header(IdempotentReplayedHeader, "true")
+			ctx.Data(stored.StatusCode, "application/json; charset=utf-8", stored.Body)
+			ctx.Abort()
+			return
+		}
+
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				s.releaseIdempotencyKey(key)
+				panic(recovered)
+			}
+		}()
+		recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			s.releaseIdempotencyKey(key)
+			return
+		}
+		response := models.IdempotentResponse{StatusCode: recorder.Status(), Body: recorder.body.Bytes()}
+		if err := s.Db.SaveIdempotentResponse(key, response); err != nil {
+			s.log.Error().Err(err).Msg("Failed to save idempotent response")
+		}
+	}
+}
+
+// releaseIdempotencyKey освобождает ключ запроса, который не дал ответа для повторов
+func (s *Server) releaseIdempotencyKey(key models.IdempotencyKey) {
+	if err := s.Db.DeleteIdempotencyKey(key); err != nil {
+		s.log.Error().Err(err).Msg("Failed to release idempotency key")
+	}
+}
+
+// requestHash отличает запросы с одним ключом: метод, путь и тело
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder копирует тело ответа, чтобы сохранить его для повторов
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(data string) (int, error) {
+	r.body.WriteString(data)
+	return r.ResponseWriter.WriteString(data)
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+func TestIdempotent(t *testing.T) {
+	ts := newTestServer(t)
+
+	token := ts.sign(7, models.RoleBuyer)
+
+	body := `{"items":[{"productID":1,"quantity":2}]}`
+	lines := []models.OrderLine{{ProductID: 1, Quantity: 2}}
+	key := models.IdempotencyKey{
+		UserID:      7,
+		Key:         "order-1",
+		RequestHash: requestHash(http.MethodPost, "/orders/add", []byte(body)),
+	}
+	createdAt := time.Date(2024, 11, 1, 10, 0, 0, 0, time.UTC)
+	order := models.Order{
+		UID:       10,
+		UserID:    7,
+		Status:    models.OrderStatusPending,
+		Items:     []models.OrderItem{},
+		Total:     models.Money{Amount: 20000, Currency: "RUB"},
+		History:   []models.OrderStatusChange{},
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	orderResponse := `{"status":201,"message":"Order created","data":{"uid":10,"userID":7,"status":"pending","items":[],` +
+		`"total":{"amount":"200.00","currency":"RUB"},"history":[],"created_at":"2024-11-01T10:00:00Z","updated_at":"2024-11-01T10:00:00Z"}}`
+
+	type want struct {
+		statusCode int
+		body       string
+		replayed   bool
+	}
+	type test struct {
+		name      string
+		key       string
+		body      string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name: "Test Idempotent; Case 1: request without key is not tracked",
+			body: body,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().CreateOrder(7, models.OrderRequest{Items: lines}).Return(order, nil)
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body:       orderResponse,
+			},
+		},
+		{
+			name: "Test Idempotent; Case 2: first request with key stores the response",
+			key:  "order-1",
+			body: body,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().StartIdempotentRequest(key).Return(nil, nil)
+				m.EXPECT().CreateOrder(7, models.OrderRequest{Items: lines}).Return(order, nil)
+				m.EXPECT().SaveIdempotentResponse(key, gomock.Any()).DoAndReturn(
+					func(_ models.IdempotencyKey, response models.IdempotentResponse) error {
+						assert.Equal(t, http.StatusCreated, response.StatusCode)
+						assert.JSONEq(t, orderResponse, string(response.Body))
+						return nil
+					})
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body:       orderResponse,
+			},
+		},
+		{
+			name: "Test Idempotent; Case 3: retry gets the stored response",
+			key:  "order-1",
+			body: body,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().StartIdempotentRequest(key).Return(&models.IdempotentResponse{
+					StatusCode: http.StatusCreated,
+					Body:       []byte(orderResponse),
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body:       orderResponse,
+				replayed:   true,
+			},
+		},
+		{
+			name: "Test Idempotent; Case 4: same key with another body",
+			key:  "order-1",
+			body: `{"items":[{"productID":1,"quantity":3}]}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().StartIdempotentRequest(gomock.Any()).Return(nil, repository.ErrIdempotencyKeyReused)
+			},
+			want: want{
+				statusCode: http.StatusUnprocessableEntity,
+				body:       `{"status":422,"message":"Idempotency-Key was used for another request","error":"idempotency key was used with another request"}`,
+			},
+		},
+		{
+			name: "Test Idempotent; Case 5: first request is still running",
+			key:  "order-1",
+			body: body,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().StartIdempotentRequest(key).Return(nil, repository.ErrIdempotencyInProgress)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Request with this Idempotency-Key is in progress","error":"request with this idempotency key is in progress"}`,
+			},
+		},
+		{
+			name: "Test Idempotent; Case 6: failed request releases the key",
+			key:  "order-1",
+			body: body,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().StartIdempotentRequest(key).Return(nil, nil)
+				m.EXPECT().CreateOrder(7, models.OrderRequest{Items: lines}).Return(models.Order{}, fmt.Errorf("connection reset"))
+				m.EXPECT().DeleteIdempotencyKey(key).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       `{"status":500,"message":"Order failed","error":"connection reset"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := ts.server(t, tc.setupMock)
+			r := gin.New()
+			r.POST("/orders/add", srv.Authenticate(), srv.Idempotent(), srv.CreateOrderHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			req := resty.New().R().
+				SetHeader("Authorization", "Bearer "+token).
+				SetHeader("Content-Type", "application/json").
+				SetBody(tc.body)
+			if tc.key != "" {
+				req.SetHeader(IdempotencyKeyHeader, tc.key)
+			}
+			resp, err := req.Post(httpSrv.URL + "/orders/add")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+			assert.Equal(t, tc.want.replayed, resp.Header().Get(IdempotentReplayedHeader) == "true")
+		})
+	}
+}
+
+func TestIdempotentPanic(t *testing.T) {
+	ts := newTestServer(t)
+	token := ts.sign(7, models.RoleBuyer)
+
+	body := `{"items":[{"productID":1,"quantity":2}]}`
+	key := models.IdempotencyKey{
+		UserID:      7,
+		Key:         "order-1",
+		RequestHash: requestHash(http.MethodPost, "/orders/add", []byte(body)),
+	}
+	srv := ts.server(t, func(m *mocks.MockRepository) {
+		m.EXPECT().StartIdempotentRequest(key).Return(nil, nil)
+		m.EXPECT().CreateOrder(7, gomock.Any()).DoAndReturn(func(int, models.OrderRequest) (models.Order, error) {
+			panic("storage is broken")
+		})
+		m.EXPECT().DeleteIdempotencyKey(key).Return(nil)
+	})
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.POST("/orders/add", srv.Authenticate(), srv.Idempotent(), srv.CreateOrderHandler)
+	httpSrv := httptest.NewServer(r)
+	defer httpSrv.Close()
+
+	resp, err := resty.New().R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetHeader("Content-Type", "application/json").
+		SetHeader(IdempotencyKeyHeader, key.Key).
+		SetBody(body).
+		Post(httpSrv.URL + "/orders/add")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode())
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/wileytor/go-market/common/broker"
+	j "github.com/wileytor/go-market/common/jwt"
+	"github.com/wileytor/go-market/products/internal/logger"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+// testLogger настраивается один раз: SetupLogger меняет глобальные настройки zerolog,
+// которые читают горутины уже запущенных серверов
+var testLogger *zerolog.Logger
+
+func TestMain(m *testing.M) {
+	testLogger = logger.SetupLogger(true)
+	os.Exit(m.Run())
+}
+
+// newServer создает сервер и после теста останавливает его фоновые горутины
+func newServer(t *testing.T, db repository.Repository, messageBroker broker.Broker, tokens j.Verifier) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := NewServer(ctx, db, testLogger, messageBroker, tokens)
+	t.Cleanup(func() {
+		cancel()
+		srv.Wait()
+	})
+	return srv
+}
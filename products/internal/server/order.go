@@ -0,0 +1,231 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/repository"
+	"github.com/wileytor/go-market/products/internal/server/responses"
+)
+
+// CreateOrderHandler оформляет заказ
+// @Summary Оформление заказа
+// @Description Оформляет заказ из нескольких строк от имени владельца токена.
+// @Description Остатки резервируются по всем строкам сразу: если одной строки не хватает, заказ не создается.
+// @Description Склады выбираются настроенной стратегией; строка может резервироваться на нескольких складах.
+// @Description region нужен стратегии region: сначала резервируются склады этого региона.
+// @Description Неоплаченный заказ отменяется, когда истекает резерв (reserved_until).
+// @Description Для товара с вариантами в строке нужно передать variantID.
+// @Tags Заказы
+// @Accept json
+// @Produce json
+// @Param order body models.OrderRequest true "Строки заказа"
+// @Success 201 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /orders/add [post]
+func (s *Server) CreateOrderHandler(ctx *gin.Context) {
+	var request models.OrderRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid order", err)
+		return
+	}
+	order, err := s.Db.CreateOrder(auth.UserID(ctx), request)
+	if err != nil {
+		sendOrderError(ctx, err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusCreated, "Order created", order)
+}
+
+// sendOrderError отвечает на ошибку оформления заказа
+func sendOrderError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound), errors.Is(err, repository.ErrVariantNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Product not found", err)
+	case errors.Is(err, repository.ErrVariantRequired):
+		responses.SendError(ctx, http.StatusBadRequest, "Variant is required", err)
+	case errors.Is(err, models.ErrCurrencyMismatch):
+		responses.SendError(ctx, http.StatusBadRequest, "Order lines must share one currency", err)
+	case errors.Is(err, repository.ErrInsufficientStock):
+		responses.SendError(ctx, http.StatusConflict, "Not enough stock", err)
+	default:
+		responses.SendError(ctx, http.StatusInternalServerError, "Order failed", err)
+	}
+}
+
+// GetOrderByIDHandler получает заказ
+// @Summary Получение заказа
+// @Description Возвращает заказ со строками и итоговой суммой. Доступен владельцу заказа и администратору.
+// @Tags Заказы
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /orders/{id} [get]
+func (s *Server) GetOrderByIDHandler(ctx *gin.Context) {
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || orderID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid order id", err)
+		return
+	}
+	order, err := s.Db.GetOrderByID(orderID)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		responses.SendError(ctx, http.StatusNotFound, "Order not found", err)
+		return
+	}
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get order", err)
+		return
+	}
+	// Чужой заказ не отличается от несуществующего
+	if order.UserID != auth.UserID(ctx) && auth.Role(ctx) != models.RoleAdmin {
+		responses.SendError(ctx, http.StatusNotFound, "Order not found", repository.ErrOrderNotFound)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Order found", order)
+}
+
+// GetUserOrdersHandler получает заказы пользователя
+// @Summary Получение списка заказов пользователя
+// @Description Возвращает заказы пользователя со строками и итоговыми суммами, новые первыми
+// @Tags Заказы
+// @Produce json
+// @Param id path int true "ID пользователя"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /orders/user/{id} [get]
+func (s *Server) GetUserOrdersHandler(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || userID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid user id", err)
+		return
+	}
+	if userID != auth.UserID(ctx) && auth.Role(ctx) != models.RoleAdmin {
+		responses.SendError(ctx, http.StatusForbidden, "Access denied", nil)
+		return
+	}
+	orders, err := s.Db.GetUserOrders(userID)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get orders", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List orders found", orders)
+}
+
+// GetProductOrdersHandler получает заказы с товаром
+// @Summary Получение списка заказов с товаром
+// @Description Возвращает заказы, в которых есть указанный товар
+// @Tags Заказы
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /orders/product/{id} [get]
+func (s *Server) GetProductOrdersHandler(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || productID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid product id", err)
+		return
+	}
+	orders, err := s.Db.GetProductOrders(productID)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get orders", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List orders found", orders)
+}
+
+// UpdateOrderStatusHandler меняет статус заказа
+// @Summary Смена статуса заказа
+// @Description Переводит заказ в статус shipped, delivered или cancelled. Допустимые переходы: pending -> cancelled,
+// @Description paid -> shipped, shipped -> delivered. Отмена снимает резерв остатков.
+// @Description Оплата и возврат проходят только через /orders/{id}/pay и /orders/{id}/refund.
+// @Tags Заказы
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Param status body models.OrderStatusRequest true "Новый статус"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /orders/{id}/status [put]
+func (s *Server) UpdateOrderStatusHandler(ctx *gin.Context) {
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || orderID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid order id", err)
+		return
+	}
+	var request models.OrderStatusRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid order status", err)
+		return
+	}
+	s.changeOrderStatus(ctx, orderID, request.Status)
+}
+
+// CancelOrderHandler отменяет заказ
+// @Summary Отмена заказа
+// @Description Отменяет неоплаченный заказ и снимает резерв остатков. Доступно владельцу заказа и администратору.
+// @Description Пока провайдер не ответил по платежу заказа, отменить его нельзя.
+// @Tags Заказы
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /orders/{id}/cancel [post]
+func (s *Server) CancelOrderHandler(ctx *gin.Context) {
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || orderID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid order id", err)
+		return
+	}
+	if _, ok := s.ownOrder(ctx, orderID); !ok {
+		return
+	}
+	s.changeOrderStatus(ctx, orderID, models.OrderStatusCancelled)
+}
+
+func (s *Server) changeOrderStatus(ctx *gin.Context, orderID int, status string) {
+	err := s.Db.ChangeOrderStatus(auth.UserID(ctx), orderID, status)
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Order not found", err)
+	case errors.Is(err, models.ErrInvalidOrderTransition), errors.Is(err, repository.ErrPaymentInProgress):
+		responses.SendError(ctx, http.StatusConflict, "Order status cannot be changed", err)
+	case err != nil:
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to change order status", err)
+	default:
+		responses.SendSuccess(ctx, http.StatusOK, "Order status changed", orderID)
+	}
+}
@@ -0,0 +1,520 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/broker"
+	j "github.com/wileytor/go-market/common/jwt"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+// testKeys создает набор ключей подписи во временном каталоге
+func testKeys(t *testing.T) *j.KeySet {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "test.pem"), data, 0o600))
+
+	keys, err := j.LoadKeySet(dir, "")
+	require.NoError(t, err)
+	return keys
+}
+
+// serveTokenCheck отвечает на проверку токена вместо сервиса auth.
+// failures задает ошибку ответа по id сессии.
+func serveTokenCheck(b broker.Broker, keys *j.KeySet, failures map[int]string) {
+	b.ServeRPC(models.UserCheckQueue, broker.ConsumerOptions{}, func(body []byte) ([]byte, error) {
+		var request models.TokenCheckMessage
+		env, err := models.DecodeMessage(body, models.MessageTypeTokenCheck, &request)
+		if err != nil {
+			return nil, broker.Permanent(err)
+		}
+		response := models.TokenCheckResponse{UserID: -1}
+		claims, err := keys.Verify(request.Token)
+		switch {
+		case err != nil:
+			response.Error = models.TokenCheckInvalidToken
+		case failures[claims.SessionID] != "":
+			response.Error = failures[claims.SessionID]
+		default:
+			response.Valid = true
+			response.UserID = claims.UserID
+			response.Role = claims.Role
+		}
+		return models.EncodeMessage(models.MessageTypeTokenCheckResult, response, env.Trace)
+	})
+}
+
+// want - ожидаемый ответ обработчика
+type want struct {
+	statusCode int
+	body       string
+}
+
+// testServer - обвязка тестов обработчиков: ключи, которыми сервис auth подписывает токены
+type testServer struct {
+	t    *testing.T
+	keys *j.KeySet
+}
+
+// newTestServer готовит обвязку для тестов обработчиков t
+func newTestServer(t *testing.T) *testServer {
+	gin.SetMode(gin.TestMode)
+	return &testServer{t: t, keys: testKeys(t)}
+}
+
+// sign выпускает access-токен пользователя
+func (ts *testServer) sign(userID int, role string) string {
+	token, err := ts.keys.Sign(j.Claims{UserID: userID, SessionID: 1, Role: role})
+	require.NoError(ts.t, err)
+	return token
+}
+
+// server создает сервер подтеста t на моке хранилища, который настраивает setupMock
+func (ts *testServer) server(t *testing.T, setupMock func(m *mocks.MockRepository)) *Server {
+	m := mocks.NewMockRepository(gomock.NewController(t))
+	if setupMock != nil {
+		setupMock(m)
+	}
+	return newServer(t, m, nil, ts.keys)
+}
+
+func TestCreateOrderHandler(t *testing.T) {
+	ts := newTestServer(t)
+	b := broker.NewMemory()
+	defer b.Close()
+	go serveTokenCheck(b, ts.keys, map[int]string{2: models.TokenCheckSessionRevoked, 3: models.TokenCheckFailed})
+
+	sign := func(userID, sessionID int) string {
+		token, err := ts.keys.Sign(j.Claims{UserID: userID, SessionID: sessionID, Role: models.RoleBuyer})
+		require.NoError(t, err)
+		return token
+	}
+	rub := func(amount int64) models.Money {
+		return models.Money{Amount: amount, Currency: "RUB"}
+	}
+	variantID := 3
+	createdAt := time.Date(2024, 11, 1, 10, 0, 0, 0, time.UTC)
+
+	type test struct {
+		name      string
+		token     string
+		body      string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name:  "Test CreateOrderHandler; Case 1: order is created for token owner",
+			token: sign(7, 1),
+			body:  `{"items":[{"productID":1,"quantity":2},{"productID":2,"variantID":3,"quantity":1}],"region":"eu"}`,
+			setupMock: func(m *mocks.MockRepository) {
+				lines := []models.OrderLine{
+					{ProductID: 1, Quantity: 2},
+					{ProductID: 2, VariantID: &variantID, Quantity: 1},
+				}
+				m.EXPECT().CreateOrder(7, models.OrderRequest{Items: lines, Region: "eu"}).Return(models.Order{
+					UID:    10,
+					UserID: 7,
+					Status: models.OrderStatusPending,
+					Items: []models.OrderItem{
+						{UID: 1, ProductID: 1, Quantity: 2, Price: rub(10000), Total: rub(20000)},
+						{UID: 2, ProductID: 2, VariantID: &variantID, Quantity: 1, Price: rub(4990), Total: rub(4990)},
+					},
+					Total:     rub(24990),
+					History:   []models.OrderStatusChange{{To: models.OrderStatusPending, ChangedAt: createdAt}},
+					CreatedAt: createdAt,
+					UpdatedAt: createdAt,
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body: `{"status":201,"message":"Order created","data":{"uid":10,"userID":7,"status":"pending","items":[` +
+					`{"uid":1,"productID":1,"quantity":2,"price":{"amount":"100.00","currency":"RUB"},"total":{"amount":"200.00","currency":"RUB"}},` +
+					`{"uid":2,"productID":2,"variantID":3,"quantity":1,"price":{"amount":"49.90","currency":"RUB"},"total":{"amount":"49.90","currency":"RUB"}}],` +
+					`"total":{"amount":"249.90","currency":"RUB"},"history":[{"to":"pending","changed_at":"2024-11-01T10:00:00Z"}],` +
+					`"created_at":"2024-11-01T10:00:00Z","updated_at":"2024-11-01T10:00:00Z"}}`,
+			},
+		},
+		{
+			name:  "Test CreateOrderHandler; Case 2: revoked session",
+			token: sign(7, 2),
+			body:  `{"items":[{"productID":1,"quantity":2}]}`,
+			want: want{
+				statusCode: http.StatusUnauthorized,
+				body:       `{"status":401,"message":"Session revoked"}`,
+			},
+		},
+		{
+			name: "Test CreateOrderHandler; Case 3: missing token",
+			body: `{"items":[{"productID":1,"quantity":2}]}`,
+			want: want{
+				statusCode: http.StatusUnauthorized,
+				body:       `{"status":401,"message":"The header is missing"}`,
+			},
+		},
+		{
+			name:  "Test CreateOrderHandler; Case 4: empty order",
+			token: sign(7, 1),
+			body:  `{"items":[]}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid order","error":"Key: 'OrderRequest.Items' Error:Field validation for 'Items' failed on the 'min' tag"}`,
+			},
+		},
+		{
+			name:  "Test CreateOrderHandler; Case 5: line without quantity",
+			token: sign(7, 1),
+			body:  `{"items":[{"productID":1}]}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid order","error":"Key: 'OrderRequest.Items[0].Quantity' Error:Field validation for 'Quantity' failed on the 'required' tag"}`,
+			},
+		},
+		{
+			name:  "Test CreateOrderHandler; Case 6: product not found",
+			token: sign(7, 1),
+			body:  `{"items":[{"productID":42,"quantity":2}]}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().CreateOrder(7, models.OrderRequest{Items: []models.OrderLine{{ProductID: 42, Quantity: 2}}}).
+					Return(models.Order{}, fmt.Errorf("%w: product 42", repository.ErrProductNotFound))
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Product not found","error":"product not found: product 42"}`,
+			},
+		},
+		{
+			name:  "Test CreateOrderHandler; Case 7: one line is short of stock",
+			token: sign(7, 1),
+			body:  `{"items":[{"productID":1,"quantity":2},{"productID":2,"quantity":9}]}`,
+			setupMock: func(m *mocks.MockRepository) {
+				lines := []models.OrderLine{{ProductID: 1, Quantity: 2}, {ProductID: 2, Quantity: 9}}
+				m.EXPECT().CreateOrder(7, models.OrderRequest{Items: lines}).Return(models.Order{}, fmt.Errorf("%w: product 2", repository.ErrInsufficientStock))
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Not enough stock","error":"not enough stock: product 2"}`,
+			},
+		},
+		{
+			name:  "Test CreateOrderHandler; Case 8: variant is required",
+			token: sign(7, 1),
+			body:  `{"items":[{"productID":1,"quantity":1}]}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().CreateOrder(7, models.OrderRequest{Items: []models.OrderLine{{ProductID: 1, Quantity: 1}}}).
+					Return(models.Order{}, repository.ErrVariantRequired)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Variant is required","error":"product has variants, variant must be specified"}`,
+			},
+		},
+		{
+			name:  "Test CreateOrderHandler; Case 9: lines in different currencies",
+			token: sign(7, 1),
+			body:  `{"items":[{"productID":1,"quantity":1},{"productID":5,"quantity":1}]}`,
+			setupMock: func(m *mocks.MockRepository) {
+				lines := []models.OrderLine{{ProductID: 1, Quantity: 1}, {ProductID: 5, Quantity: 1}}
+				m.EXPECT().CreateOrder(7, models.OrderRequest{Items: lines}).Return(models.Order{}, models.ErrCurrencyMismatch)
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Order lines must share one currency","error":"` + models.ErrCurrencyMismatch.Error() + `"}`,
+			},
+		},
+		{
+			name:  "Test CreateOrderHandler; Case 10: auth failed to check session",
+			token: sign(7, 3),
+			body:  `{"items":[{"productID":1,"quantity":2}]}`,
+			want: want{
+				statusCode: http.StatusServiceUnavailable,
+				body:       `{"status":503,"message":"Failed to check session","error":"token check failed: Failed to check session"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := ts.server(t, tc.setupMock)
+			srv.Broker = b
+			srv.EnableRevocationCheck(time.Minute)
+			r := gin.New()
+			r.POST("/orders/add", srv.Authenticate(), srv.CreateOrderHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			req := resty.New().R().SetHeader("Content-Type", "application/json").SetBody(tc.body)
+			if tc.token != "" {
+				req.SetHeader("Authorization", "Bearer "+tc.token)
+			}
+			resp, err := req.Post(httpSrv.URL + "/orders/add")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
+
+func TestGetOrderByIDHandler(t *testing.T) {
+	ts := newTestServer(t)
+
+	createdAt := time.Date(2024, 11, 1, 10, 0, 0, 0, time.UTC)
+	order := models.Order{
+		UID:       10,
+		UserID:    7,
+		Status:    models.OrderStatusPending,
+		Items:     []models.OrderItem{},
+		Total:     models.Money{Amount: 0, Currency: "RUB"},
+		History:   []models.OrderStatusChange{{To: models.OrderStatusPending, ChangedAt: createdAt}},
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	orderBody := `{"uid":10,"userID":7,"status":"pending","items":[],"total":{"amount":"0.00","currency":"RUB"},` +
+		`"history":[{"to":"pending","changed_at":"2024-11-01T10:00:00Z"}],"created_at":"2024-11-01T10:00:00Z","updated_at":"2024-11-01T10:00:00Z"}`
+
+	type test struct {
+		name      string
+		token     string
+		id        string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name:  "Test GetOrderByIDHandler; Case 1: owner gets the order",
+			token: ts.sign(7, models.RoleBuyer),
+			id:    "10",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetOrderByID(10).Return(order, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Order found","data":` + orderBody + `}`,
+			},
+		},
+		{
+			name:  "Test GetOrderByIDHandler; Case 2: admin gets someone else's order",
+			token: ts.sign(1, models.RoleAdmin),
+			id:    "10",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetOrderByID(10).Return(order, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Order found","data":` + orderBody + `}`,
+			},
+		},
+		{
+			name:  "Test GetOrderByIDHandler; Case 3: someone else's order is hidden",
+			token: ts.sign(8, models.RoleBuyer),
+			id:    "10",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetOrderByID(10).Return(order, nil)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Order not found","error":"order not found"}`,
+			},
+		},
+		{
+			name:  "Test GetOrderByIDHandler; Case 4: invalid id",
+			token: ts.sign(7, models.RoleBuyer),
+			id:    "abc",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Invalid order id","error":"strconv.Atoi: parsing \"abc\": invalid syntax"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := ts.server(t, tc.setupMock)
+			r := gin.New()
+			r.GET("/orders/:id", srv.Authenticate(), srv.GetOrderByIDHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			resp, err := resty.New().R().SetHeader("Authorization", "Bearer "+tc.token).Get(httpSrv.URL + "/orders/" + tc.id)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
+
+func TestOrderStatusHandlers(t *testing.T) {
+	ts := newTestServer(t)
+
+	order := models.Order{UID: 10, UserID: 7, Status: models.OrderStatusPending}
+
+	type test struct {
+		name      string
+		token     string
+		method    string
+		path      string
+		body      string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name:   "Test OrderStatusHandlers; Case 1: seller ships a paid order",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPut,
+			path:   "/orders/10/status",
+			body:   `{"status":"shipped"}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().ChangeOrderStatus(2, 10, models.OrderStatusShipped).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Order status changed","data":10}`,
+			},
+		},
+		{
+			name:   "Test OrderStatusHandlers; Case 2: transition is not allowed",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPut,
+			path:   "/orders/10/status",
+			body:   `{"status":"delivered"}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().ChangeOrderStatus(2, 10, models.OrderStatusDelivered).
+					Return(models.CheckOrderTransition(models.OrderStatusPending, models.OrderStatusDelivered))
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Order status cannot be changed","error":"order status transition is not allowed: pending -> delivered"}`,
+			},
+		},
+		{
+			name:   "Test OrderStatusHandlers; Case 3: unknown status",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPut,
+			path:   "/orders/10/status",
+			body:   `{"status":"lost"}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid order status","error":"Key: 'OrderStatusRequest.Status' Error:Field validation for 'Status' failed on the 'oneof' tag"}`,
+			},
+		},
+		{
+			name:   "Test OrderStatusHandlers; Case 4: buyer cannot set status",
+			token:  ts.sign(7, models.RoleBuyer),
+			method: http.MethodPut,
+			path:   "/orders/10/status",
+			body:   `{"status":"paid"}`,
+			want: want{
+				statusCode: http.StatusForbidden,
+				body:       `{"status":403,"message":"Access denied"}`,
+			},
+		},
+		{
+			name:   "Test OrderStatusHandlers; Case 5: owner cancels the order",
+			token:  ts.sign(7, models.RoleBuyer),
+			method: http.MethodPost,
+			path:   "/orders/10/cancel",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetOrderByID(10).Return(order, nil)
+				m.EXPECT().ChangeOrderStatus(7, 10, models.OrderStatusCancelled).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Order status changed","data":10}`,
+			},
+		},
+		{
+			name:   "Test OrderStatusHandlers; Case 6: someone else's order cannot be cancelled",
+			token:  ts.sign(8, models.RoleBuyer),
+			method: http.MethodPost,
+			path:   "/orders/10/cancel",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetOrderByID(10).Return(order, nil)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Order not found","error":"order not found"}`,
+			},
+		},
+		{
+			name:   "Test OrderStatusHandlers; Case 7: order cannot be marked paid without a payment",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPut,
+			path:   "/orders/10/status",
+			body:   `{"status":"paid"}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid order status","error":"Key: 'OrderStatusRequest.Status' Error:Field validation for 'Status' failed on the 'oneof' tag"}`,
+			},
+		},
+		{
+			name:   "Test OrderStatusHandlers; Case 8: refund goes through the payment provider",
+			token:  ts.sign(1, models.RoleAdmin),
+			method: http.MethodPut,
+			path:   "/orders/10/status",
+			body:   `{"status":"refunded"}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid order status","error":"Key: 'OrderStatusRequest.Status' Error:Field validation for 'Status' failed on the 'oneof' tag"}`,
+			},
+		},
+		{
+			name:   "Test OrderStatusHandlers; Case 9: order with a payment in progress cannot be cancelled",
+			token:  ts.sign(7, models.RoleBuyer),
+			method: http.MethodPost,
+			path:   "/orders/10/cancel",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetOrderByID(10).Return(order, nil)
+				m.EXPECT().ChangeOrderStatus(7, 10, models.OrderStatusCancelled).Return(repository.ErrPaymentInProgress)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Order status cannot be changed","error":"order has a payment in progress"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := ts.server(t, tc.setupMock)
+			r := gin.New()
+			authenticate := srv.Authenticate()
+			r.PUT("/orders/:id/status", authenticate, auth.RequireRole(models.RoleSeller, models.RoleAdmin), srv.UpdateOrderStatusHandler)
+			r.POST("/orders/:id/cancel", authenticate, srv.CancelOrderHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			req := resty.New().R().SetHeader("Authorization", "Bearer "+tc.token)
+			if tc.body != "" {
+				req.SetHeader("Content-Type", "application/json").SetBody(tc.body)
+			}
+			resp, err := req.Execute(tc.method, httpSrv.URL+tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/wileytor/go-market/common/models"
+)
+
+const (
+	outboxBatchSize      = 100
+	outboxPublishTimeout = 5 * time.Second
+)
+
+// RelayOutbox с периодом interval публикует события из outbox, пока не завершится ctx
+func (s *Server) RelayOutbox(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.log.Info().Msg("Outbox relay shutting down")
+			return
+		case <-ticker.C:
+			s.relayOutbox(ctx)
+		}
+	}
+}
+
+// relayOutbox отправляет события пачками, пока outbox не опустеет или не произойдет ошибка.
+// Неотправленные события останутся в outbox до следующего запуска.
+func (s *Server) relayOutbox(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := s.Db.ProcessOutbox(outboxBatchSize, func(msg models.OutboxMessage) error {
+			publishCtx, cancel := context.WithTimeout(ctx, outboxPublishTimeout)
+			defer cancel()
+			return s.Broker.PublishEvent(publishCtx, msg.EventType, msg.Message)
+		})
+		if sent > 0 {
+			s.log.Debug().Int("sent", sent).Msg("Outbox events published")
+		}
+		if err != nil {
+			s.log.Error().Err(err).Msg("Failed to relay outbox")
+			return
+		}
+		if sent < outboxBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/broker"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+)
+
+func TestRelayOutbox(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	message, err := models.EncodeMessage(models.EventProductDeleted, models.ProductDeletedEvent{ProductID: 3}, models.TraceContext{})
+	require.NoError(t, err)
+	pending := []models.OutboxMessage{
+		{ID: 1, EventType: models.EventProductDeleted, Message: message},
+	}
+	process := func(limit int, publish func(models.OutboxMessage) error) (int, error) {
+		sent := 0
+		for _, msg := range pending {
+			if err := publish(msg); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		return sent, nil
+	}
+
+	t.Run("Test RelayOutbox; Case 1: pending events are published", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mocks.NewMockRepository(ctrl)
+		// Пока потребитель не подписался, событие отбрасывается, и ретранслятор публикует его снова
+		m.EXPECT().ProcessOutbox(outboxBatchSize, gomock.Any()).DoAndReturn(process).MinTimes(1)
+
+		b := broker.NewMemory()
+		defer b.Close()
+		received := make(chan []byte, 1)
+		go b.Consume(models.EventProductDeleted, broker.ConsumerOptions{}, func(msg broker.Message) error {
+			received <- msg.Body
+			return nil
+		})
+
+		srv := newServer(t, m, b, nil)
+		var body []byte
+		require.Eventually(t, func() bool {
+			srv.relayOutbox(ctx)
+			select {
+			case body = <-received:
+				return true
+			case <-time.After(10 * time.Millisecond):
+				return false
+			}
+		}, time.Second, 5*time.Millisecond, "event was not published")
+
+		var event models.ProductDeletedEvent
+		_, err := models.DecodeMessage(body, models.EventProductDeleted, &event)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, event.ProductID)
+	})
+
+	t.Run("Test RelayOutbox; Case 2: relay stops on publish error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		m := mocks.NewMockRepository(ctrl)
+		m.EXPECT().ProcessOutbox(outboxBatchSize, gomock.Any()).DoAndReturn(process).Times(1)
+
+		b := broker.NewMemory()
+		b.Close()
+
+		srv := newServer(t, m, b, nil)
+		srv.relayOutbox(ctx)
+	})
+}
@@ -0,0 +1,361 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/payments"
+	"github.com/wileytor/go-market/products/internal/repository"
+	"github.com/wileytor/go-market/products/internal/server/responses"
+)
+
+const paymentTimeout = 10 * time.Second
+
+// PayOrderHandler оплачивает заказ
+// @Summary Оплата заказа
+// @Description Авторизует и списывает полную сумму заказа у платежного провайдера.
+// @Description Если провайдер требует 3-D Secure, ответит позже или недоступен, возвращается 202 и платеж ждет вебхука.
+// @Description При отказе заказ отменяется и резерв остатков снимается.
+// @Tags Платежи
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Param payment body models.PaymentRequest true "Платежный токен"
+// @Success 200 {object} responses.Success
+// @Success 202 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 402 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /orders/{id}/pay [post]
+func (s *Server) PayOrderHandler(ctx *gin.Context) {
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || orderID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid order id", err)
+		return
+	}
+	var request models.PaymentRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid payment", err)
+		return
+	}
+	if _, ok := s.ownOrder(ctx, orderID); !ok {
+		return
+	}
+
+	payment, err := s.Db.CreatePayment(orderID, s.Payments.Name())
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Order not found", err)
+		return
+	case errors.Is(err, repository.ErrOrderNotPayable), errors.Is(err, repository.ErrPaymentExists):
+		responses.SendError(ctx, http.StatusConflict, "Order cannot be paid", err)
+		return
+	case err != nil:
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to create payment", err)
+		return
+	}
+
+	providerCtx, cancel := context.WithTimeout(ctx.Request.Context(), paymentTimeout)
+	defer cancel()
+	authorization, err := s.Payments.Authorize(providerCtx, payments.AuthorizeRequest{
+		PaymentID: payment.UID,
+		OrderID:   orderID,
+		Amount:    payment.Amount,
+		Token:     request.Token,
+	})
+	if err != nil {
+		// Провайдер мог авторизовать платеж, не успев ответить. Платеж остается pending,
+		// итог придет вебхуком, иначе его отклонит сборщик просроченных платежей.
+		s.log.Error().Err(err).Int("payment", payment.UID).Msg("Payment authorization failed")
+		responses.SendSuccess(ctx, http.StatusAccepted, "Payment is being processed", payment)
+		return
+	}
+	payment, err = s.applyAuthorization(providerCtx, payment, authorization)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Payment failed", err)
+		return
+	}
+
+	switch payment.Status {
+	case models.PaymentStatusCaptured:
+		responses.SendSuccess(ctx, http.StatusOK, "Order paid", payment)
+	case models.PaymentStatusFailed:
+		responses.SendError(ctx, http.StatusPaymentRequired, "Payment declined", errors.New(payment.FailureReason))
+	default:
+		responses.SendSuccess(ctx, http.StatusAccepted, "Payment is being processed", payment)
+	}
+}
+
+// applyAuthorization сохраняет ответ провайдера на авторизацию и списывает одобренный платеж
+func (s *Server) applyAuthorization(ctx context.Context, payment models.Payment, authorization payments.Authorization) (models.Payment, error) {
+	payment.ProviderPaymentID = authorization.ProviderPaymentID
+	switch authorization.Result {
+	case payments.AuthorizationApproved:
+		payment.Status = models.PaymentStatusAuthorized
+	case payments.AuthorizationRequiresAction:
+		payment.Status = models.PaymentStatusRequiresAction
+		payment.ActionURL = authorization.ActionURL
+	case payments.AuthorizationPending:
+	default:
+		payment.Status = models.PaymentStatusFailed
+		payment.FailureReason = authorization.DeclineReason
+	}
+	if err := s.Db.UpdatePayment(payment); err != nil {
+		return payment, err
+	}
+	if payment.Status == models.PaymentStatusAuthorized {
+		return s.capturePayment(ctx, payment)
+	}
+	return payment, nil
+}
+
+// capturePayment списывает авторизованный платеж. Неудачное списание считается отказом,
+// и авторизация отменяется у провайдера, чтобы не держать деньги покупателя.
+// Если заказ за время списания перестал ждать оплаты, деньги возвращаются покупателю.
+func (s *Server) capturePayment(ctx context.Context, payment models.Payment) (models.Payment, error) {
+	payment.ActionURL = ""
+	if err := s.Payments.Capture(ctx, payment.ProviderPaymentID, payment.Amount); err != nil {
+		s.log.Error().Err(err).Int("payment", payment.UID).Msg("Payment capture failed")
+		payment.Status = models.PaymentStatusFailed
+		payment.FailureReason = "capture_failed"
+		if err := s.Db.UpdatePayment(payment); err != nil {
+			return payment, err
+		}
+		s.voidPayment(payment)
+		return payment, nil
+	}
+	payment.Status = models.PaymentStatusCaptured
+	err := s.Db.UpdatePayment(payment)
+	if !errors.Is(err, models.ErrInvalidOrderTransition) && !errors.Is(err, models.ErrInvalidPaymentTransition) {
+		return payment, err
+	}
+
+	s.log.Warn().Err(err).Int("payment", payment.UID).Msg("Order is no longer payable, refunding captured payment")
+	if err := s.Payments.Refund(ctx, payment.ProviderPaymentID, payment.Amount, refundKey(payment)); err != nil {
+		return payment, fmt.Errorf("failed to refund payment %d of unpayable order: %w", payment.UID, err)
+	}
+	payment.Status = models.PaymentStatusFailed
+	payment.FailureReason = "order_not_payable"
+	return payment, s.Db.UpdatePayment(payment)
+}
+
+// PaymentWebhookHandler принимает вебхук платежного провайдера
+// @Summary Вебхук платежного провайдера
+// @Description Принимает итог платежа, который провайдер сообщает после 3-D Secure или отложенной обработки.
+// @Description Подпись проверяется провайдером; повторные вебхуки по завершенному платежу игнорируются.
+// @Tags Платежи
+// @Accept json
+// @Produce json
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /payments/webhook [post]
+func (s *Server) PaymentWebhookHandler(ctx *gin.Context) {
+	payload, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	err = s.ProcessPaymentWebhook(ctx.Request.Context(), ctx.Request.Header, payload)
+	switch {
+	case errors.Is(err, payments.ErrInvalidSignature), errors.Is(err, payments.ErrInvalidWebhook):
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid webhook", err)
+	case errors.Is(err, repository.ErrPaymentNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Payment not found", err)
+	case err != nil:
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to process webhook", err)
+	default:
+		responses.SendSuccess(ctx, http.StatusOK, "Webhook processed", nil)
+	}
+}
+
+// ProcessPaymentWebhook проверяет вебхук и применяет итог платежа.
+// Платеж, который уже авторизован, списан или отклонен, не меняется.
+func (s *Server) ProcessPaymentWebhook(ctx context.Context, header http.Header, payload []byte) error {
+	event, err := s.Payments.VerifyWebhook(header, payload)
+	if err != nil {
+		return err
+	}
+	payment, err := s.Db.GetPaymentByProviderID(s.Payments.Name(), event.ProviderPaymentID)
+	if err != nil {
+		return err
+	}
+	if payment.Status != models.PaymentStatusPending && payment.Status != models.PaymentStatusRequiresAction {
+		return nil
+	}
+
+	providerCtx, cancel := context.WithTimeout(ctx, paymentTimeout)
+	defer cancel()
+	if event.Status == models.PaymentStatusAuthorized {
+		payment.Status = models.PaymentStatusAuthorized
+		payment.ActionURL = ""
+		if err := s.Db.UpdatePayment(payment); err != nil {
+			return err
+		}
+		_, err = s.capturePayment(providerCtx, payment)
+		return err
+	}
+	payment.Status = models.PaymentStatusFailed
+	payment.FailureReason = event.Reason
+	return s.Db.UpdatePayment(payment)
+}
+
+// RefundOrderHandler возвращает деньги за заказ
+// @Summary Возврат средств по заказу
+// @Description Возвращает покупателю списанный платеж, переводит заказ в refunded и возвращает остатки на склад.
+// @Description На время обращения к провайдеру платеж находится в статусе refunding, и второй возврат получает 409.
+// @Tags Платежи
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 502 {object} responses.Error
+// @Router /orders/{id}/refund [post]
+func (s *Server) RefundOrderHandler(ctx *gin.Context) {
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || orderID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid order id", err)
+		return
+	}
+	payment, err := s.Db.StartRefund(orderID)
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Order not found", err)
+		return
+	case errors.Is(err, models.ErrInvalidOrderTransition), errors.Is(err, repository.ErrNoCapturedPayment),
+		errors.Is(err, repository.ErrRefundInProgress):
+		responses.SendError(ctx, http.StatusConflict, "Order cannot be refunded", err)
+		return
+	case err != nil:
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to start refund", err)
+		return
+	}
+
+	providerCtx, cancel := context.WithTimeout(ctx.Request.Context(), paymentTimeout)
+	defer cancel()
+	if err := s.Payments.Refund(providerCtx, payment.ProviderPaymentID, payment.Amount, refundKey(payment)); err != nil {
+		// Повторный возврат пройдет с тем же ключом идемпотентности, даже если провайдер успел вернуть деньги
+		payment.Status = models.PaymentStatusCaptured
+		if err := s.Db.UpdatePayment(payment); err != nil {
+			s.log.Error().Err(err).Int("payment", payment.UID).Msg("Failed to reopen payment after refund failure")
+		}
+		responses.SendError(ctx, http.StatusBadGateway, "Refund failed", err)
+		return
+	}
+	payment.Status = models.PaymentStatusRefunded
+	if err := s.Db.UpdatePayment(payment); err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to save refund", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Order refunded", payment)
+}
+
+// refundKey - ключ идемпотентности возврата у провайдера. Платеж возвращается только целиком,
+// поэтому все попытки возврата одного платежа используют один ключ.
+func refundKey(payment models.Payment) string {
+	return fmt.Sprintf("refund-%d", payment.UID)
+}
+
+// GetOrderPaymentsHandler возвращает платежи по заказу
+// @Summary Платежи по заказу
+// @Description Возвращает все попытки оплаты заказа. Доступно владельцу заказа и администратору.
+// @Tags Платежи
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /orders/{id}/payments [get]
+func (s *Server) GetOrderPaymentsHandler(ctx *gin.Context) {
+	orderID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || orderID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid order id", err)
+		return
+	}
+	if _, ok := s.ownOrder(ctx, orderID); !ok {
+		return
+	}
+	orderPayments, err := s.Db.GetOrderPayments(orderID)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get payments", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List of payments", orderPayments)
+}
+
+// FakeChallengeHandler завершает проверку 3-D Secure фейкового провайдера
+// @Summary Проверка 3-D Secure фейкового провайдера
+// @Description Доступна только с фейковым провайдером. Итог проверки приходит вебхуком.
+// @Tags Платежи
+// @Accept json
+// @Produce json
+// @Param paymentID path string true "ID платежа у провайдера"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Router /payments/fake/challenge/{paymentID} [post]
+func (s *Server) FakeChallengeHandler(ctx *gin.Context) {
+	fake, ok := s.Payments.(*payments.Fake)
+	if !ok {
+		responses.SendError(ctx, http.StatusNotFound, "Fake provider is disabled", nil)
+		return
+	}
+	var request struct {
+		Success bool `json:"success"`
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	err := fake.CompleteChallenge(ctx.Param("paymentID"), request.Success)
+	switch {
+	case errors.Is(err, payments.ErrFakePaymentNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Payment not found", err)
+	case err != nil:
+		responses.SendError(ctx, http.StatusConflict, "Challenge is not pending", err)
+	default:
+		responses.SendSuccess(ctx, http.StatusOK, "Challenge completed", nil)
+	}
+}
+
+// ownOrder возвращает заказ, если он принадлежит пользователю или запрос сделал администратор.
+// Чужой заказ не отличается от несуществующего.
+func (s *Server) ownOrder(ctx *gin.Context, orderID int) (models.Order, bool) {
+	order, err := s.Db.GetOrderByID(orderID)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		responses.SendError(ctx, http.StatusNotFound, "Order not found", err)
+		return models.Order{}, false
+	}
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get order", err)
+		return models.Order{}, false
+	}
+	if order.UserID != auth.UserID(ctx) && auth.Role(ctx) != models.RoleAdmin {
+		responses.SendError(ctx, http.StatusNotFound, "Order not found", repository.ErrOrderNotFound)
+		return models.Order{}, false
+	}
+	return order, true
+}
@@ -0,0 +1,407 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/payments"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+func TestPaymentHandlers(t *testing.T) {
+	ts := newTestServer(t)
+
+	buyer := ts.sign(7, models.RoleBuyer)
+	admin := ts.sign(1, models.RoleAdmin)
+
+	createdAt := time.Date(2024, 11, 4, 10, 0, 0, 0, time.UTC)
+	amount := models.Money{Amount: 10000, Currency: "RUB"}
+	pendingOrder := models.Order{UID: 10, UserID: 7, Status: models.OrderStatusPending, Total: amount}
+	paidOrder := models.Order{UID: 10, UserID: 7, Status: models.OrderStatusPaid, Total: amount}
+	payment := models.Payment{
+		UID:       1,
+		OrderID:   10,
+		Provider:  payments.FakeProviderName,
+		Status:    models.PaymentStatusPending,
+		Amount:    amount,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	// withStatus возвращает копию платежа у провайдера в другом статусе
+	withStatus := func(status string) models.Payment {
+		p := payment
+		p.ProviderPaymentID = "fake_1"
+		p.Status = status
+		return p
+	}
+	paymentBody := func(status, extra string) string {
+		return `{"uid":1,"orderID":10,"provider":"fake","provider_payment_id":"fake_1","status":"` + status + `",` +
+			`"amount":{"amount":"100.00","currency":"RUB"}` + extra + `,"created_at":"2024-11-04T10:00:00Z","updated_at":"2024-11-04T10:00:00Z"}`
+	}
+	webhook := []byte(`{"payment_id":"fake_1","status":"failed","reason":"authentication_failed"}`)
+
+	type test struct {
+		name      string
+		path      string
+		token     string
+		header    http.Header
+		body      string
+		setupMock func(m *mocks.MockRepository, fake *payments.Fake)
+		want      want
+	}
+	tests := []test{
+		{
+			name:  "Test PaymentHandlers; Case 1: approved payment is captured",
+			path:  "/orders/10/pay",
+			token: buyer,
+			body:  `{"token":"tok_approved"}`,
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				m.EXPECT().GetOrderByID(10).Return(pendingOrder, nil)
+				m.EXPECT().CreatePayment(10, payments.FakeProviderName).Return(payment, nil)
+				m.EXPECT().UpdatePayment(withStatus(models.PaymentStatusAuthorized)).Return(nil)
+				m.EXPECT().UpdatePayment(withStatus(models.PaymentStatusCaptured)).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Order paid","data":` + paymentBody("captured", "") + `}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 2: declined payment",
+			path:  "/orders/10/pay",
+			token: buyer,
+			body:  `{"token":"tok_insufficient_funds"}`,
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				failed := withStatus(models.PaymentStatusFailed)
+				failed.FailureReason = "insufficient_funds"
+				m.EXPECT().GetOrderByID(10).Return(pendingOrder, nil)
+				m.EXPECT().CreatePayment(10, payments.FakeProviderName).Return(payment, nil)
+				m.EXPECT().UpdatePayment(failed).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+				body:       `{"status":402,"message":"Payment declined","error":"insufficient_funds"}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 3: payment requires 3-D Secure",
+			path:  "/orders/10/pay",
+			token: buyer,
+			body:  `{"token":"tok_3ds"}`,
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				requiresAction := withStatus(models.PaymentStatusRequiresAction)
+				requiresAction.ActionURL = payments.FakeChallengePath + "fake_1"
+				m.EXPECT().GetOrderByID(10).Return(pendingOrder, nil)
+				m.EXPECT().CreatePayment(10, payments.FakeProviderName).Return(payment, nil)
+				m.EXPECT().UpdatePayment(requiresAction).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusAccepted,
+				body: `{"status":202,"message":"Payment is being processed","data":` +
+					paymentBody("requires_action", `,"action_url":"/payments/fake/challenge/fake_1"`) + `}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 4: order is already paid",
+			path:  "/orders/10/pay",
+			token: buyer,
+			body:  `{"token":"tok_approved"}`,
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				m.EXPECT().GetOrderByID(10).Return(paidOrder, nil)
+				m.EXPECT().CreatePayment(10, payments.FakeProviderName).Return(models.Payment{}, repository.ErrOrderNotPayable)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Order cannot be paid","error":"only pending orders can be paid"}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 5: order of another user",
+			path:  "/orders/10/pay",
+			token: buyer,
+			body:  `{"token":"tok_approved"}`,
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				m.EXPECT().GetOrderByID(10).Return(models.Order{UID: 10, UserID: 8, Status: models.OrderStatusPending}, nil)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Order not found","error":"order not found"}`,
+			},
+		},
+		{
+			name:   "Test PaymentHandlers; Case 6: webhook with a wrong signature",
+			path:   "/payments/webhook",
+			header: http.Header{payments.FakeSignatureHeader: {"00"}},
+			body:   string(webhook),
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Invalid webhook","error":"invalid webhook signature"}`,
+			},
+		},
+		{
+			name:   "Test PaymentHandlers; Case 7: webhook fails payment after 3-D Secure",
+			path:   "/payments/webhook",
+			header: payments.NewFake("secret", 0).SignWebhook(webhook),
+			body:   string(webhook),
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				failed := withStatus(models.PaymentStatusFailed)
+				failed.FailureReason = "authentication_failed"
+				m.EXPECT().GetPaymentByProviderID(payments.FakeProviderName, "fake_1").Return(withStatus(models.PaymentStatusRequiresAction), nil)
+				m.EXPECT().UpdatePayment(failed).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Webhook processed","data":null}`,
+			},
+		},
+		{
+			name:   "Test PaymentHandlers; Case 8: repeated webhook is ignored",
+			path:   "/payments/webhook",
+			header: payments.NewFake("secret", 0).SignWebhook(webhook),
+			body:   string(webhook),
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				m.EXPECT().GetPaymentByProviderID(payments.FakeProviderName, "fake_1").Return(withStatus(models.PaymentStatusCaptured), nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Webhook processed","data":null}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 9: refund",
+			path:  "/orders/10/refund",
+			token: admin,
+			setupMock: func(m *mocks.MockRepository, fake *payments.Fake) {
+				_, err := fake.Authorize(context.Background(), payments.AuthorizeRequest{PaymentID: 1, OrderID: 10, Amount: amount, Token: payments.FakeTokenApproved})
+				require.NoError(t, err)
+				require.NoError(t, fake.Capture(context.Background(), "fake_1", amount))
+				m.EXPECT().StartRefund(10).Return(withStatus(models.PaymentStatusRefunding), nil)
+				m.EXPECT().UpdatePayment(withStatus(models.PaymentStatusRefunded)).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Order refunded","data":` + paymentBody("refunded", "") + `}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 10: refund of an unpaid order",
+			path:  "/orders/10/refund",
+			token: admin,
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				m.EXPECT().StartRefund(10).Return(models.Payment{}, models.CheckOrderTransition(models.OrderStatusPending, models.OrderStatusRefunded))
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Order cannot be refunded","error":"order status transition is not allowed: pending -> refunded"}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 11: refund by a buyer",
+			path:  "/orders/10/refund",
+			token: buyer,
+			want: want{
+				statusCode: http.StatusForbidden,
+				body:       `{"status":403,"message":"Access denied"}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 12: capture of an order that is no longer payable is refunded",
+			path:  "/orders/10/pay",
+			token: buyer,
+			body:  `{"token":"tok_approved"}`,
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				failed := withStatus(models.PaymentStatusFailed)
+				failed.FailureReason = "order_not_payable"
+				m.EXPECT().GetOrderByID(10).Return(pendingOrder, nil)
+				m.EXPECT().CreatePayment(10, payments.FakeProviderName).Return(payment, nil)
+				m.EXPECT().UpdatePayment(withStatus(models.PaymentStatusAuthorized)).Return(nil)
+				m.EXPECT().UpdatePayment(withStatus(models.PaymentStatusCaptured)).
+					Return(models.CheckOrderTransition(models.OrderStatusCancelled, models.OrderStatusPaid))
+				m.EXPECT().UpdatePayment(failed).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+				body:       `{"status":402,"message":"Payment declined","error":"order_not_payable"}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 13: second refund while the first is in progress",
+			path:  "/orders/10/refund",
+			token: admin,
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				m.EXPECT().StartRefund(10).Return(models.Payment{}, repository.ErrRefundInProgress)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Order cannot be refunded","error":"order refund is already in progress"}`,
+			},
+		},
+		{
+			name:  "Test PaymentHandlers; Case 14: failed refund leaves the payment captured",
+			path:  "/orders/10/refund",
+			token: admin,
+			setupMock: func(m *mocks.MockRepository, _ *payments.Fake) {
+				m.EXPECT().StartRefund(10).Return(withStatus(models.PaymentStatusRefunding), nil)
+				m.EXPECT().UpdatePayment(withStatus(models.PaymentStatusCaptured)).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusBadGateway,
+				body:       `{"status":502,"message":"Refund failed","error":"fake payment not found"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := payments.NewFake("secret", 0)
+			srv := ts.server(t, func(m *mocks.MockRepository) {
+				if tc.setupMock != nil {
+					tc.setupMock(m, fake)
+				}
+			})
+			srv.Payments = fake
+			r := gin.New()
+			orderGroup := r.Group("/orders", srv.Authenticate())
+			orderGroup.POST("/:id/pay", srv.PayOrderHandler)
+			orderGroup.POST("/:id/refund", auth.RequireRole(models.RoleSeller, models.RoleAdmin), srv.RefundOrderHandler)
+			r.POST("/payments/webhook", srv.PaymentWebhookHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			req := resty.New().R().SetHeader("Content-Type", "application/json")
+			if tc.token != "" {
+				req.SetHeader("Authorization", "Bearer "+tc.token)
+			}
+			for key := range tc.header {
+				req.SetHeader(key, tc.header.Get(key))
+			}
+			if tc.body != "" {
+				req.SetBody(tc.body)
+			}
+			resp, err := req.Post(httpSrv.URL + tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
+
+// flakyProvider - фейковый провайдер, у которого авторизация или списание заканчиваются ошибкой
+type flakyProvider struct {
+	*payments.Fake
+	authorizeErr error
+	captureErr   error
+	voided       []string
+}
+
+func (p *flakyProvider) Authorize(ctx context.Context, req payments.AuthorizeRequest) (payments.Authorization, error) {
+	if p.authorizeErr != nil {
+		return payments.Authorization{}, p.authorizeErr
+	}
+	return p.Fake.Authorize(ctx, req)
+}
+
+func (p *flakyProvider) Capture(ctx context.Context, providerPaymentID string, amount models.Money) error {
+	if p.captureErr != nil {
+		return p.captureErr
+	}
+	return p.Fake.Capture(ctx, providerPaymentID, amount)
+}
+
+func (p *flakyProvider) Void(ctx context.Context, providerPaymentID string) error {
+	p.voided = append(p.voided, providerPaymentID)
+	return p.Fake.Void(ctx, providerPaymentID)
+}
+
+func TestPayOrderProviderErrors(t *testing.T) {
+	ts := newTestServer(t)
+	buyer := ts.sign(7, models.RoleBuyer)
+
+	createdAt := time.Date(2024, 11, 4, 10, 0, 0, 0, time.UTC)
+	amount := models.Money{Amount: 10000, Currency: "RUB"}
+	order := models.Order{UID: 10, UserID: 7, Status: models.OrderStatusPending, Total: amount}
+	payment := models.Payment{
+		UID:       1,
+		OrderID:   10,
+		Provider:  payments.FakeProviderName,
+		Status:    models.PaymentStatusPending,
+		Amount:    amount,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	authorized := payment
+	authorized.ProviderPaymentID = "fake_1"
+	authorized.Status = models.PaymentStatusAuthorized
+	failed := authorized
+	failed.Status = models.PaymentStatusFailed
+	failed.FailureReason = "capture_failed"
+
+	tests := []struct {
+		name       string
+		provider   *flakyProvider
+		setupMock  func(m *mocks.MockRepository)
+		want       want
+		wantVoided []string
+	}{
+		{
+			name:     "Test PayOrderProviderErrors; Case 1: authorization timeout leaves the payment pending",
+			provider: &flakyProvider{authorizeErr: context.DeadlineExceeded},
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetOrderByID(10).Return(order, nil)
+				m.EXPECT().CreatePayment(10, payments.FakeProviderName).Return(payment, nil)
+			},
+			want: want{
+				statusCode: http.StatusAccepted,
+				body: `{"status":202,"message":"Payment is being processed","data":{"uid":1,"orderID":10,"provider":"fake","status":"pending",` +
+					`"amount":{"amount":"100.00","currency":"RUB"},"created_at":"2024-11-04T10:00:00Z","updated_at":"2024-11-04T10:00:00Z"}}`,
+			},
+		},
+		{
+			name:     "Test PayOrderProviderErrors; Case 2: failed capture voids the authorization",
+			provider: &flakyProvider{captureErr: context.DeadlineExceeded},
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetOrderByID(10).Return(order, nil)
+				m.EXPECT().CreatePayment(10, payments.FakeProviderName).Return(payment, nil)
+				m.EXPECT().UpdatePayment(authorized).Return(nil)
+				m.EXPECT().UpdatePayment(failed).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusPaymentRequired,
+				body:       `{"status":402,"message":"Payment declined","error":"capture_failed"}`,
+			},
+			wantVoided: []string{"fake_1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.provider.Fake = payments.NewFake("secret", 0)
+			srv := ts.server(t, tc.setupMock)
+			srv.Payments = tc.provider
+			r := gin.New()
+			r.POST("/orders/:id/pay", srv.Authenticate(), srv.PayOrderHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			resp, err := resty.New().R().
+				SetHeader("Content-Type", "application/json").
+				SetHeader("Authorization", "Bearer "+buyer).
+				SetBody(`{"token":"tok_approved"}`).
+				Post(httpSrv.URL + "/orders/10/pay")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+			assert.Equal(t, tc.wantVoided, tc.provider.voided)
+		})
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
 	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/repository"
 	"github.com/wileytor/go-market/products/internal/server/responses"
 )
 
@@ -42,30 +44,118 @@ func (s *Server) deleter(ctx context.Context) {
 	}
 }
 
-// GetAllProductsHandler получает все продукты
-// @Summary Получить список всех продуктов
-// @Description Возвращает список всех продуктов
+// GetAllProductsHandler получает страницу каталога
+// @Summary Получить список продуктов
+// @Description Возвращает неудаленные продукты с фильтрацией, сортировкой и постраничным выводом.
+// @Description cursor и offset нельзя передавать одновременно.
 // @Tags Продукты
 // @Produce json
-// @Success 200 {object} responses.Success
+// @Param limit query int false "Размер страницы (1-100, по умолчанию 20)"
+// @Param offset query int false "Смещение"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param min_price query string false "Минимальная цена в валюте currency, например 10.50"
+// @Param max_price query string false "Максимальная цена в валюте currency"
+// @Param currency query string false "Валюта ISO 4217, обязательна вместе с ценой"
+// @Param in_stock query bool false "Только товары в наличии"
+// @Param name query string false "Подстрока названия"
+// @Param sort query string false "Сортировка" Enums(newest, price_asc, price_desc, name_asc, name_desc)
+// @Param category_id query int false "Категория вместе с подкатегориями"
+// @Success 200 {object} responses.Success{data=models.ProductPage}
+// @Failure 400 {object} responses.Error
 // @Failure 500 {object} responses.Error
 // @Router /products [get]
 func (s *Server) GetAllProductsHandler(ctx *gin.Context) {
-	products, err := s.Db.GetAllProducts()
+	filter, ok := s.bindProductFilter(ctx)
+	if !ok {
+		return
+	}
+	s.sendProductPage(ctx, filter)
+}
+
+// bindProductFilter читает параметры выборки каталога и отвечает 400, если они неверны
+func (s *Server) bindProductFilter(ctx *gin.Context) (models.ProductFilter, bool) {
+	var filter models.ProductFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid query parameters", err)
+		return filter, false
+	}
+	if err := s.Valid.Struct(filter); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid query parameters", err)
+		return filter, false
+	}
+	if filter.Cursor != "" && filter.Offset > 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Cursor and offset cannot be combined", nil)
+		return filter, false
+	}
+	if _, _, err := filter.PriceRange(); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid price range", err)
+		return filter, false
+	}
+	return filter, true
+}
+
+func (s *Server) sendProductPage(ctx *gin.Context, filter models.ProductFilter) {
+	page, err := s.Db.GetAllProducts(filter)
 	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			responses.SendError(ctx, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		if errors.Is(err, repository.ErrInvalidFilter) {
+			responses.SendError(ctx, http.StatusBadRequest, "Invalid query parameters", err)
+			return
+		}
 		responses.SendError(ctx, http.StatusInternalServerError, "Failed to retrieve products", err)
 		return
 	}
-	responses.SendSuccess(ctx, http.StatusOK, "List of products", products)
+	responses.SendSuccess(ctx, http.StatusOK, "List of products", page)
+}
+
+// SearchProductsHandler выполняет полнотекстовый поиск продуктов
+// @Summary Поиск продуктов
+// @Description Ищет продукты по названию и описанию с учетом морфологии русского и английского языков.
+// @Description Последнее слово ищется по префиксу. Результаты отсортированы по релевантности,
+// @Description совпадения в snippet выделены тегом <b>.
+// @Tags Продукты
+// @Produce json
+// @Param q query string true "Строка поиска"
+// @Param limit query int false "Размер страницы (1-100, по умолчанию 20)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} responses.Success{data=models.ProductSearchPage}
+// @Failure 400 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /products/search [get]
+func (s *Server) SearchProductsHandler(ctx *gin.Context) {
+	var search models.ProductSearch
+	if err := ctx.ShouldBindQuery(&search); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	if err := s.Valid.Struct(search); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	page, err := s.Db.SearchProducts(search)
+	if err != nil {
+		if errors.Is(err, repository.ErrEmptySearchQuery) {
+			responses.SendError(ctx, http.StatusBadRequest, "Invalid search query", err)
+			return
+		}
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to search products", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Search results", page)
 }
 
 // GetProductByIDHandler получает проукты по id
 // @Summary Получение списка продуктов по id
-// @Description Получить продукт по ID
+// @Description Получить продукт по ID вместе с вариантами, путями к его категориям
+// @Description и остатками: общим (quantity) и по каждому складу (availability)
 // @Tags Продукты
 // @Param id path int true "Product ID"
 // @Produce json
-// @Success 200 {object} responses.Success
+// @Success 200 {object} responses.Success{data=models.ProductDetails}
 // @Failure 400 {object} responses.Error
 // @Failure 404 {object} responses.Error
 // @Router /products/{id} [get]
@@ -87,7 +177,27 @@ func (s *Server) GetProductByIDHandler(ctx *gin.Context) {
 			return
 		}
 	}
-	responses.SendSuccess(ctx, http.StatusOK, "Product found", product)
+	breadcrumbs, err := s.Db.GetProductBreadcrumbs(uIdInt)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to retrieve categories", err)
+		return
+	}
+	variants, err := s.Db.GetProductVariants(uIdInt)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to retrieve variants", err)
+		return
+	}
+	availability, err := s.Db.GetProductAvailability(uIdInt)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to retrieve availability", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Product found", models.ProductDetails{
+		Product:      product,
+		Variants:     variants,
+		Availability: &availability,
+		Breadcrumbs:  breadcrumbs,
+	})
 }
 
 // AddProductHandler добавить новые продукт
@@ -111,6 +221,10 @@ func (s *Server) AddProductHandler(ctx *gin.Context) {
 		responses.SendError(ctx, http.StatusBadRequest, "Not a valid product", err)
 		return
 	}
+	if product.Price.IsZero() {
+		responses.SendError(ctx, http.StatusBadRequest, "Price must be positive", nil)
+		return
+	}
 
 	if product.Quantity < 0 {
 		responses.SendError(ctx, http.StatusBadRequest, "Quantity cannot be negative", nil)
@@ -126,7 +240,7 @@ func (s *Server) AddProductHandler(ctx *gin.Context) {
 		return
 	}
 
-	productUID, err := s.Db.AddProduct(product)
+	productUID, err := s.Db.AddProduct(auth.UserID(ctx), product)
 	if err != nil {
 		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
 		return
@@ -136,7 +250,8 @@ func (s *Server) AddProductHandler(ctx *gin.Context) {
 
 // UpdateProductHandler обновляет данные продукта
 // @Summary Обновление продукта
-// @Description Обновить данные продукта
+// @Description Обновить данные продукта. Новый остаток записывается в журнал остатков как корректировка.
+// @Description Остаток нельзя опустить ниже зарезервированного под заказы.
 // @Tags Продукты
 // @Accept json
 // @Produce json
@@ -144,6 +259,7 @@ func (s *Server) AddProductHandler(ctx *gin.Context) {
 // @Param product body models.Product true "Product data"
 // @Success 200 {object} responses.Success
 // @Failure 400 {object} responses.Error
+// @Failure 409 {object} responses.Error
 // @Failure 500 {object} responses.Error
 // @Router /products/{id} [put]
 func (s *Server) UpdateProductHandler(ctx *gin.Context) {
@@ -157,6 +273,10 @@ func (s *Server) UpdateProductHandler(ctx *gin.Context) {
 		responses.SendError(ctx, http.StatusBadRequest, "Not a valid product", err)
 		return
 	}
+	if product.Price.IsZero() {
+		responses.SendError(ctx, http.StatusBadRequest, "Price must be positive", nil)
+		return
+	}
 
 	uid := ctx.Param("id")
 	uIdInt, err := strconv.Atoi(uid)
@@ -166,7 +286,11 @@ func (s *Server) UpdateProductHandler(ctx *gin.Context) {
 	}
 	product.UID = uIdInt
 
-	productUID, err := s.Db.UpdateProduct(uIdInt, product)
+	productUID, err := s.Db.UpdateProduct(auth.UserID(ctx), uIdInt, product)
+	if errors.Is(err, repository.ErrNegativeStock) {
+		responses.SendError(ctx, http.StatusConflict, "Quantity is below reserved stock", err)
+		return
+	}
 	if err != nil {
 		responses.SendError(ctx, http.StatusInternalServerError, "error", err)
 		return
@@ -3,13 +3,14 @@ package server
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/go-resty/resty/v2"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/wileytor/go-market/common/models"
 	mocks "github.com/wileytor/go-market/mocks_prod"
-	"github.com/wileytor/go-market/products/internal/logger"
+	"github.com/wileytor/go-market/products/internal/repository"
 	"github.com/wileytor/go-market/products/internal/server/responses"
 	"net/http"
 	"net/http/httptest"
@@ -17,7 +18,7 @@ import (
 )
 
 func TestGetAllProductsHandler(t *testing.T) {
-	var srv Server
+	srv := Server{Valid: validator.New()}
 	r := gin.Default()
 	r.GET("/products", srv.GetAllProductsHandler)
 	httpSrv := httptest.NewServer(r)
@@ -28,12 +29,13 @@ func TestGetAllProductsHandler(t *testing.T) {
 		products   string
 	}
 	type test struct {
-		name     string
-		method   string
-		request  string
-		products []models.Product
-		err      error
-		want     want
+		name    string
+		method  string
+		request string
+		filter  *models.ProductFilter
+		page    models.ProductPage
+		err     error
+		want    want
 	}
 
 	tests := []test{
@@ -41,50 +43,131 @@ func TestGetAllProductsHandler(t *testing.T) {
 			name:    "Test GetAllProductsHandler; Case 1:",
 			method:  http.MethodGet,
 			request: "/products",
+			filter:  &models.ProductFilter{},
 			err:     nil,
-			products: []models.Product{
-				{
-					UID:         1,
-					Name:        "apple",
-					Description: "red",
-					Price:       10,
-					Delete:      false,
-					Quantity:    12,
+			page: models.ProductPage{
+				Items: []models.Product{
+					{
+						UID:         1,
+						Name:        "apple",
+						Description: "red",
+						Price:       models.Money{Amount: 1000, Currency: "RUB"},
+						Delete:      false,
+						Quantity:    12,
+					},
 				},
+				Total: 1,
+				Limit: 20,
 			},
 			want: want{
 				statusCode: http.StatusOK,
-				products:   `{"status":200,"message":"List of products","data":[{"uid":1,"name":"apple","description":"red","price":10,"delete":false,"quantity":12}]}`,
+				products:   `{"status":200,"message":"List of products","data":{"items":[{"uid":1,"name":"apple","description":"red","price":{"amount":"10.00","currency":"RUB"},"delete":false,"quantity":12}],"total":1,"limit":20}}`,
 				errFlag:    false,
 			},
 		},
 		{
-			name:     "Test GetAllProductsHandler; Case 2;",
-			method:   http.MethodGet,
-			request:  "/products",
-			err:      fmt.Errorf("test error"),
-			products: nil,
+			name:    "Test GetAllProductsHandler; Case 2;",
+			method:  http.MethodGet,
+			request: "/products",
+			filter:  &models.ProductFilter{},
+			err:     fmt.Errorf("test error"),
 			want: want{
 				statusCode: http.StatusInternalServerError,
 				products:   `{"status":500,"message":"Failed to retrieve products","error":"test error"}`,
 				errFlag:    true,
 			},
 		},
+		{
+			name:    "Test GetAllProductsHandler; Case 3: filters are passed to repository",
+			method:  http.MethodGet,
+			request: "/products?limit=1&min_price=5&max_price=50.50&currency=RUB&in_stock=true&name=app&sort=price_desc",
+			filter: &models.ProductFilter{
+				Limit:    1,
+				MinPrice: "5",
+				MaxPrice: "50.50",
+				Currency: "RUB",
+				InStock:  true,
+				Name:     "app",
+				Sort:     models.SortPriceDesc,
+			},
+			page: models.ProductPage{Items: []models.Product{}, Total: 3, Limit: 1, NextCursor: "next"},
+			want: want{
+				statusCode: http.StatusOK,
+				products:   `{"status":200,"message":"List of products","data":{"items":[],"total":3,"limit":1,"next_cursor":"next"}}`,
+			},
+		},
+		{
+			name:    "Test GetAllProductsHandler; Case 4: unknown sort",
+			method:  http.MethodGet,
+			request: "/products?sort=rating",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				products:   `{"status":400,"message":"Invalid query parameters","error":"Key: 'ProductFilter.Sort' Error:Field validation for 'Sort' failed on the 'oneof' tag"}`,
+			},
+		},
+		{
+			name:    "Test GetAllProductsHandler; Case 5: cursor with offset",
+			method:  http.MethodGet,
+			request: "/products?cursor=abc&offset=10",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				products:   `{"status":400,"message":"Cursor and offset cannot be combined"}`,
+			},
+		},
+		{
+			name:    "Test GetAllProductsHandler; Case 6: invalid cursor",
+			method:  http.MethodGet,
+			request: "/products?cursor=abc",
+			filter:  &models.ProductFilter{Cursor: "abc"},
+			err:     fmt.Errorf("%w: bad data", repository.ErrInvalidCursor),
+			want: want{
+				statusCode: http.StatusBadRequest,
+				products:   `{"status":400,"message":"Invalid cursor","error":"invalid cursor: bad data"}`,
+			},
+		},
+		{
+			name:    "Test GetAllProductsHandler; Case 7: price without currency",
+			method:  http.MethodGet,
+			request: "/products?min_price=5",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				products:   `{"status":400,"message":"Invalid query parameters","error":"Key: 'ProductFilter.Currency' Error:Field validation for 'Currency' failed on the 'required_with' tag"}`,
+			},
+		},
+		{
+			name:    "Test GetAllProductsHandler; Case 8: inverted price range",
+			method:  http.MethodGet,
+			request: "/products?min_price=50&max_price=5&currency=RUB",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				products:   `{"status":400,"message":"Invalid price range","error":"min_price cannot exceed max_price"}`,
+			},
+		},
+		{
+			name:    "Test GetAllProductsHandler; Case 9: price with too many decimals",
+			method:  http.MethodGet,
+			request: "/products?min_price=5.555&currency=RUB",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				products:   `{"status":400,"message":"Invalid price range","error":"min_price: invalid money amount: \"5.555\""}`,
+			},
+		},
 	}
 
-	log := logger.SetupLogger(true)
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			m := mocks.NewMockRepository(ctrl)
 			defer ctrl.Finish()
-			m.EXPECT().GetAllProducts().Return(tc.products, tc.err)
+			if tc.filter != nil {
+				m.EXPECT().GetAllProducts(*tc.filter).Return(tc.page, tc.err)
+			}
 			srv.Db = m
 			req := resty.New().R()
 			req.Method = tc.method
 			req.URL = httpSrv.URL + tc.request
 			resp, err := req.Send()
-			log.Debug().Err(err).Str("body", string(resp.Body())).Any("str", resp.String()).Send()
+			testLogger.Debug().Err(err).Str("body", string(resp.Body())).Any("str", resp.String()).Send()
 			if !tc.want.errFlag {
 				assert.NoError(t, err)
 			}
@@ -94,6 +177,93 @@ func TestGetAllProductsHandler(t *testing.T) {
 	}
 }
 
+func TestSearchProductsHandler(t *testing.T) {
+	srv := Server{Valid: validator.New()}
+	r := gin.Default()
+	r.GET("/products/search", srv.SearchProductsHandler)
+	httpSrv := httptest.NewServer(r)
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+	type test struct {
+		name    string
+		request string
+		search  *models.ProductSearch
+		page    models.ProductSearchPage
+		err     error
+		want    want
+	}
+
+	tests := []test{
+		{
+			name:    "Test SearchProductsHandler; Case 1: results are ranked",
+			request: "/products/search?q=red+app&limit=5",
+			search:  &models.ProductSearch{Query: "red app", Limit: 5},
+			page: models.ProductSearchPage{
+				Items: []models.ProductSearchResult{
+					{
+						Product: models.Product{UID: 1, Name: "apple", Description: "red", Price: models.Money{Amount: 1000, Currency: "RUB"}, Quantity: 12},
+						Rank:    0.5,
+						Snippet: "<b>apple</b> <b>red</b>",
+					},
+				},
+				Total: 1,
+				Limit: 5,
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Search results","data":{"items":[{"uid":1,"name":"apple","description":"red","price":{"amount":"10.00","currency":"RUB"},"delete":false,"quantity":12,"rank":0.5,"snippet":"\u003cb\u003eapple\u003c/b\u003e \u003cb\u003ered\u003c/b\u003e"}],"total":1,"limit":5}}`,
+			},
+		},
+		{
+			name:    "Test SearchProductsHandler; Case 2: missing query",
+			request: "/products/search",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Invalid query parameters","error":"Key: 'ProductSearch.Query' Error:Field validation for 'Query' failed on the 'required' tag"}`,
+			},
+		},
+		{
+			name:    "Test SearchProductsHandler; Case 3: query without words",
+			request: "/products/search?q=%2A%2A%2A",
+			search:  &models.ProductSearch{Query: "***"},
+			err:     repository.ErrEmptySearchQuery,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Invalid search query","error":"search query has no words"}`,
+			},
+		},
+		{
+			name:    "Test SearchProductsHandler; Case 4: database error",
+			request: "/products/search?q=apple",
+			search:  &models.ProductSearch{Query: "apple"},
+			err:     fmt.Errorf("test error"),
+			want: want{
+				statusCode: http.StatusInternalServerError,
+				body:       `{"status":500,"message":"Failed to search products","error":"test error"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			m := mocks.NewMockRepository(ctrl)
+			defer ctrl.Finish()
+			if tc.search != nil {
+				m.EXPECT().SearchProducts(*tc.search).Return(tc.page, tc.err)
+			}
+			srv.Db = m
+			resp, err := resty.New().R().Get(httpSrv.URL + tc.request)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.Equal(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
+
 func TestGetProductByIDHandler(t *testing.T) {
 	var srv Server
 	r := gin.Default()
@@ -110,8 +280,10 @@ func TestGetProductByIDHandler(t *testing.T) {
 		method  string
 		request string
 		product models.Product
-		err     error
-		want    want
+		// availability - остатки по складам для успешного ответа
+		availability models.Availability
+		err          error
+		want         want
 	}
 
 	tests := []test{
@@ -124,14 +296,26 @@ func TestGetProductByIDHandler(t *testing.T) {
 				UID:         1,
 				Name:        "apple",
 				Description: "red",
-				Price:       10,
+				Price:       models.Money{Amount: 1000, Currency: "RUB"},
 				Delete:      false,
 				Quantity:    12,
 			},
+			availability: models.Availability{
+				Quantity:  12,
+				Reserved:  2,
+				Available: 10,
+				Warehouses: []models.WarehouseAvailability{
+					{WarehouseID: 1, Name: "main", Active: true, Quantity: 9, Reserved: 2, Available: 7},
+					{WarehouseID: 2, Name: "north", Region: "spb", Active: true, Quantity: 3, Available: 3},
+				},
+			},
 			want: want{
 				statusCode: http.StatusOK,
-				product:    `{"status":200,"message":"Product found","data":{"uid":1,"name":"apple","description":"red","price":10,"delete":false,"quantity":12}}`,
-				errFlag:    false,
+				product: `{"status":200,"message":"Product found","data":{"uid":1,"name":"apple","description":"red","price":{"amount":"10.00","currency":"RUB"},"delete":false,"quantity":12,` +
+					`"availability":{"quantity":12,"reserved":2,"available":10,"warehouses":[` +
+					`{"warehouseID":1,"name":"main","active":true,"quantity":9,"reserved":2,"available":7},` +
+					`{"warehouseID":2,"name":"north","region":"spb","active":true,"quantity":3,"reserved":0,"available":3}]}}}`,
+				errFlag: false,
 			},
 		},
 		{
@@ -171,7 +355,6 @@ func TestGetProductByIDHandler(t *testing.T) {
 			},
 		},
 	}
-	log := logger.SetupLogger(true)
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
@@ -179,6 +362,9 @@ func TestGetProductByIDHandler(t *testing.T) {
 			defer ctrl.Finish()
 			if !tc.want.errFlag {
 				m.EXPECT().GetProductByID(1).Return(tc.product, tc.err)
+				m.EXPECT().GetProductBreadcrumbs(1).Return(nil, nil)
+				m.EXPECT().GetProductVariants(1).Return([]models.ProductVariant{}, nil)
+				m.EXPECT().GetProductAvailability(1).Return(tc.availability, nil)
 			} else {
 				m.EXPECT().GetProductByID(mock.Anything).Return(models.Product{}, tc.err)
 			}
@@ -187,7 +373,7 @@ func TestGetProductByIDHandler(t *testing.T) {
 			req.Method = tc.method
 			req.URL = httpSrv.URL + tc.request
 			resp, err := req.Send()
-			log.Debug().Err(err).Str("body", string(resp.Body())).Any("str", resp.String()).Send()
+			testLogger.Debug().Err(err).Str("body", string(resp.Body())).Any("str", resp.String()).Send()
 			if !tc.want.errFlag {
 				assert.NoError(t, err)
 			}
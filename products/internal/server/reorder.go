@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/repository"
+	"github.com/wileytor/go-market/products/internal/server/responses"
+)
+
+// SetReorderPolicyHandler задает порог дозаказа товара
+// @Summary Порог дозаказа
+// @Description Задает порог доступного остатка, на котором позиция товара считается заканчивающейся.
+// @Description Когда заказ опускает остаток до порога, поставщику создается заявка на quantity и отправляется событие stock.low.
+// @Description Пустой threshold отключает дозаказ.
+// @Tags Остатки
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param policy body models.ReorderPolicy true "Порог дозаказа"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /products/{id}/reorder [put]
+func (s *Server) SetReorderPolicyHandler(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || productID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid product id", err)
+		return
+	}
+	var policy models.ReorderPolicy
+	if err := ctx.ShouldBindJSON(&policy); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(policy); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid reorder policy", err)
+		return
+	}
+	err = s.Db.SetReorderPolicy(productID, policy)
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Product not found", err)
+	case err != nil:
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to set reorder policy", err)
+	default:
+		responses.SendSuccess(ctx, http.StatusOK, "Reorder policy updated", productID)
+	}
+}
+
+// GetLowStockHandler возвращает заканчивающиеся позиции
+// @Summary Заканчивающиеся товары
+// @Description Возвращает позиции товаров, доступный остаток которых не выше порога дозаказа, самые дефицитные первыми
+// @Tags Остатки
+// @Produce json
+// @Success 200 {object} responses.Success{data=[]models.LowStockItem}
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /products/low-stock [get]
+func (s *Server) GetLowStockHandler(ctx *gin.Context) {
+	items, err := s.Db.GetLowStock()
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get low stock", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List of low stock items", items)
+}
+
+// GetRestockRequestsHandler возвращает заявки поставщику
+// @Summary Заявки поставщику
+// @Description Возвращает заявки на пополнение остатков, новые первыми
+// @Tags Остатки
+// @Produce json
+// @Param status query string false "Статус заявки: open или fulfilled"
+// @Success 200 {object} responses.Success{data=[]models.RestockRequest}
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /products/restock-requests [get]
+func (s *Server) GetRestockRequestsHandler(ctx *gin.Context) {
+	status := ctx.Query("status")
+	if status != "" && !models.IsRestockStatus(status) {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid status", fmt.Errorf("unknown restock request status %q", status))
+		return
+	}
+	requests, err := s.Db.GetRestockRequests(status)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get restock requests", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List of restock requests", requests)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+func TestReorderHandlers(t *testing.T) {
+	ts := newTestServer(t)
+
+	at := time.Date(2024, 11, 4, 10, 0, 0, 0, time.UTC)
+	variantID, requestID, orderID := 3, 1, 12
+
+	type test struct {
+		name      string
+		token     string
+		method    string
+		path      string
+		body      string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name:   "Test ReorderHandlers; Case 1: threshold is set",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPut,
+			path:   "/products/5/reorder",
+			body:   `{"threshold":3,"quantity":20}`,
+			setupMock: func(m *mocks.MockRepository) {
+				threshold := 3
+				m.EXPECT().SetReorderPolicy(5, models.ReorderPolicy{Threshold: &threshold, Quantity: 20}).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Reorder policy updated","data":5}`,
+			},
+		},
+		{
+			name:   "Test ReorderHandlers; Case 2: threshold without quantity",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPut,
+			path:   "/products/5/reorder",
+			body:   `{"threshold":3}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid reorder policy","error":"Key: 'ReorderPolicy.Quantity' Error:Field validation for 'Quantity' failed on the 'required_with' tag"}`,
+			},
+		},
+		{
+			name:   "Test ReorderHandlers; Case 3: reorder is disabled for a missing product",
+			token:  ts.sign(1, models.RoleAdmin),
+			method: http.MethodPut,
+			path:   "/products/9/reorder",
+			body:   `{"threshold":null}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().SetReorderPolicy(9, models.ReorderPolicy{}).Return(repository.ErrProductNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Product not found","error":"product not found"}`,
+			},
+		},
+		{
+			name:   "Test ReorderHandlers; Case 4: buyer cannot set threshold",
+			token:  ts.sign(7, models.RoleBuyer),
+			method: http.MethodPut,
+			path:   "/products/5/reorder",
+			body:   `{"threshold":3,"quantity":20}`,
+			want: want{
+				statusCode: http.StatusForbidden,
+				body:       `{"status":403,"message":"Access denied"}`,
+			},
+		},
+		{
+			name:   "Test ReorderHandlers; Case 5: low stock items",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodGet,
+			path:   "/products/low-stock",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetLowStock().Return([]models.LowStockItem{
+					{ProductID: 5, VariantID: &variantID, Name: "T-shirt", SKU: "TS-RED-M", Available: 2, Threshold: 3, ReorderQuantity: 20, RestockRequestID: &requestID},
+					{ProductID: 8, Name: "Mug", Available: 4, Threshold: 5, ReorderQuantity: 10},
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body: `{"status":200,"message":"List of low stock items","data":[` +
+					`{"productID":5,"variantID":3,"name":"T-shirt","sku":"TS-RED-M","available":2,"threshold":3,"reorderQuantity":20,"restockRequestID":1},` +
+					`{"productID":8,"name":"Mug","available":4,"threshold":5,"reorderQuantity":10}]}`,
+			},
+		},
+		{
+			name:   "Test ReorderHandlers; Case 6: open restock requests",
+			token:  ts.sign(1, models.RoleAdmin),
+			method: http.MethodGet,
+			path:   "/products/restock-requests?status=open",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetRestockRequests(models.RestockStatusOpen).Return([]models.RestockRequest{
+					{UID: 1, ProductID: 5, VariantID: &variantID, Quantity: 20, Status: models.RestockStatusOpen, OrderID: &orderID, CreatedAt: at},
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body: `{"status":200,"message":"List of restock requests","data":[` +
+					`{"uid":1,"productID":5,"variantID":3,"quantity":20,"status":"open","orderID":12,"created_at":"2024-11-04T10:00:00Z"}]}`,
+			},
+		},
+		{
+			name:   "Test ReorderHandlers; Case 7: unknown status",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodGet,
+			path:   "/products/restock-requests?status=closed",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Invalid status","error":"unknown restock request status \"closed\""}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := ts.server(t, tc.setupMock)
+			r := gin.New()
+			productGroup := r.Group("/products", srv.Authenticate(), auth.RequireRole(models.RoleSeller, models.RoleAdmin))
+			productGroup.GET("/low-stock", srv.GetLowStockHandler)
+			productGroup.GET("/restock-requests", srv.GetRestockRequestsHandler)
+			productGroup.PUT("/:id/reorder", srv.SetReorderPolicyHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			req := resty.New().R().SetHeader("Authorization", "Bearer "+tc.token)
+			if tc.body != "" {
+				req.SetHeader("Content-Type", "application/json").SetBody(tc.body)
+			}
+			resp, err := req.Execute(tc.method, httpSrv.URL+tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
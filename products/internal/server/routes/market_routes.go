@@ -2,25 +2,84 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/payments"
 	"github.com/wileytor/go-market/products/internal/server"
 )
 
 func SetupMarketRoutes(s *server.Server) *gin.Engine {
 	r := gin.Default()
+	r.Use(s.Trace())
+	authenticate := s.Authenticate()
+	idempotent := s.Idempotent()
+	catalogAccess := auth.RequireRole(models.RoleSeller, models.RoleAdmin)
+	adminOnly := auth.RequireRole(models.RoleAdmin)
 
 	productGroup := r.Group("/products")
 	{
 		productGroup.GET("/", s.GetAllProductsHandler)
+		productGroup.GET("/search", s.SearchProductsHandler)
+		productGroup.GET("/low-stock", authenticate, catalogAccess, s.GetLowStockHandler)
+		productGroup.GET("/restock-requests", authenticate, catalogAccess, s.GetRestockRequestsHandler)
 		productGroup.GET("/:id", s.GetProductByIDHandler)
-		productGroup.POST("/add", s.AddProductHandler)
-		productGroup.PUT("/:id", s.UpdateProductHandler)
-		productGroup.DELETE("/:id", s.DeleteProductHandler)
+		productGroup.POST("/add", authenticate, catalogAccess, idempotent, s.AddProductHandler)
+		productGroup.PUT("/:id", authenticate, catalogAccess, s.UpdateProductHandler)
+		productGroup.DELETE("/:id", authenticate, catalogAccess, s.DeleteProductHandler)
+		productGroup.PUT("/:id/categories", authenticate, catalogAccess, s.SetProductCategoriesHandler)
+		productGroup.GET("/:id/variants", s.GetProductVariantsHandler)
+		productGroup.POST("/:id/variants", authenticate, catalogAccess, idempotent, s.AddVariantHandler)
+		productGroup.PUT("/:id/variants/:variantID", authenticate, catalogAccess, s.UpdateVariantHandler)
+		productGroup.DELETE("/:id/variants/:variantID", authenticate, catalogAccess, s.DeleteVariantHandler)
+		productGroup.GET("/:id/stock", authenticate, catalogAccess, s.GetStockReportHandler)
+		productGroup.POST("/:id/stock", authenticate, catalogAccess, idempotent, s.AdjustStockHandler)
+		productGroup.GET("/:id/stock/movements", authenticate, catalogAccess, s.GetStockMovementsHandler)
+		productGroup.PUT("/:id/reorder", authenticate, catalogAccess, s.SetReorderPolicyHandler)
 	}
-	purchaseGroup := r.Group("/purchases")
+	categoryGroup := r.Group("/categories")
 	{
-		purchaseGroup.GET("/user/:id", s.GetUserPurchasesHandler)
-		purchaseGroup.GET("/product/:id", s.GetProductPurchasesHandler)
-		purchaseGroup.POST("/add", s.MakePurchaseHandler)
+		categoryGroup.GET("/", s.GetCategoriesHandler)
+		categoryGroup.GET("/:id", s.GetCategoryByIDHandler)
+		categoryGroup.GET("/:id/products", s.GetCategoryProductsHandler)
+		categoryGroup.POST("/add", authenticate, catalogAccess, idempotent, s.AddCategoryHandler)
+		categoryGroup.PUT("/:id", authenticate, catalogAccess, s.UpdateCategoryHandler)
+		categoryGroup.DELETE("/:id", authenticate, catalogAccess, s.DeleteCategoryHandler)
+	}
+	cartGroup := r.Group("/cart", authenticate)
+	{
+		cartGroup.GET("/", s.GetCartHandler)
+		cartGroup.DELETE("/", s.ClearCartHandler)
+		cartGroup.POST("/items", idempotent, s.AddCartItemHandler)
+		cartGroup.PUT("/items/:itemID", s.UpdateCartItemHandler)
+		cartGroup.DELETE("/items/:itemID", s.RemoveCartItemHandler)
+		cartGroup.POST("/checkout", idempotent, s.CheckoutCartHandler)
+	}
+	orderGroup := r.Group("/orders", authenticate)
+	{
+		orderGroup.POST("/add", idempotent, s.CreateOrderHandler)
+		orderGroup.GET("/user/:id", s.GetUserOrdersHandler)
+		orderGroup.GET("/product/:id", catalogAccess, s.GetProductOrdersHandler)
+		orderGroup.GET("/:id", s.GetOrderByIDHandler)
+		orderGroup.PUT("/:id/status", catalogAccess, s.UpdateOrderStatusHandler)
+		orderGroup.POST("/:id/cancel", s.CancelOrderHandler)
+		orderGroup.POST("/:id/pay", idempotent, s.PayOrderHandler)
+		orderGroup.POST("/:id/refund", catalogAccess, idempotent, s.RefundOrderHandler)
+		orderGroup.GET("/:id/payments", s.GetOrderPaymentsHandler)
+	}
+	warehouseGroup := r.Group("/warehouses", authenticate, catalogAccess)
+	{
+		warehouseGroup.GET("/", s.GetWarehousesHandler)
+		warehouseGroup.POST("/add", adminOnly, idempotent, s.AddWarehouseHandler)
+		warehouseGroup.PUT("/:id", adminOnly, s.UpdateWarehouseHandler)
+		warehouseGroup.GET("/transfers", s.GetStockTransfersHandler)
+		warehouseGroup.POST("/transfers", idempotent, s.TransferStockHandler)
+	}
+	paymentGroup := r.Group("/payments")
+	{
+		paymentGroup.POST("/webhook", s.PaymentWebhookHandler)
+		if _, ok := s.Payments.(*payments.Fake); ok {
+			paymentGroup.POST("/fake/challenge/:paymentID", s.FakeChallengeHandler)
+		}
 	}
 	return r
 }
@@ -2,10 +2,18 @@ package server
 
 import (
 	"context"
-	"github.com/wileytor/go-market/common/rabbitmq"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/broker"
+	j "github.com/wileytor/go-market/common/jwt"
+	"github.com/wileytor/go-market/common/models"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog"
+	"github.com/wileytor/go-market/products/internal/payments"
 	"github.com/wileytor/go-market/products/internal/repository"
 )
 
@@ -15,10 +23,15 @@ type Server struct {
 	deleteChan chan int
 	Valid      *validator.Validate
 	log        zerolog.Logger
-	Rabbit     *rabbitmq.RabbitMQ
+	Broker     broker.Broker
+	Tokens     j.Verifier
+	revocation auth.RevocationChecker
+	Payments   payments.Provider
+	// background - фоновые горутины, запущенные NewServer
+	background sync.WaitGroup
 }
 
-func NewServer(ctx context.Context, db repository.Repository, zlog *zerolog.Logger, rabbitClient *rabbitmq.RabbitMQ) *Server {
+func NewServer(ctx context.Context, db repository.Repository, zlog *zerolog.Logger, messageBroker broker.Broker, tokens j.Verifier) *Server {
 	validate := validator.New()
 	dChan := make(chan int, 5)
 	errChan := make(chan error)
@@ -28,14 +41,46 @@ func NewServer(ctx context.Context, db repository.Repository, zlog *zerolog.Logg
 		ErrorChan:  errChan,
 		log:        *zlog,
 		Valid:      validate,
-		Rabbit:     rabbitClient,
+		Broker:     messageBroker,
+		Tokens:     tokens,
 	}
-	go srv.deleter(ctx)
+	srv.background.Add(1)
+	go func() {
+		defer srv.background.Done()
+		srv.deleter(ctx)
+	}()
 	return srv
 }
 
+// Wait ждет, пока фоновые горутины сервера завершатся после отмены контекста NewServer
+func (s *Server) Wait() {
+	s.background.Wait()
+}
+
+// EnableRevocationCheck включает кэшируемую проверку отзыва сессий в сервисе auth
+func (s *Server) EnableRevocationCheck(cacheTTL time.Duration) {
+	s.revocation = auth.NewCachedRevocationChecker(remoteRevocationChecker{s: s}, cacheTTL)
+}
+
+// Authenticate проверяет access-токен локально по ключам сервиса auth
+func (s *Server) Authenticate() gin.HandlerFunc {
+	if s.revocation == nil {
+		return auth.Authenticate(s.Tokens)
+	}
+	return auth.Authenticate(s.Tokens, auth.WithRevocationCheck(s.revocation))
+}
+
+// Trace сохраняет контекст трассировки запроса для исходящих сообщений
+func (s *Server) Trace() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		trace := models.TraceFromHeader(ctx.Request.Header)
+		ctx.Request = ctx.Request.WithContext(models.ContextWithTrace(ctx.Request.Context(), trace))
+		ctx.Next()
+	}
+}
+
 func (s *Server) Close() {
-	if s.Rabbit != nil {
-		s.Rabbit.CloseRabbit()
+	if s.Broker != nil {
+		s.Broker.Close()
 	}
 }
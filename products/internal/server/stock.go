@@ -0,0 +1,159 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/repository"
+	"github.com/wileytor/go-market/products/internal/server/responses"
+)
+
+// AdjustStockHandler вручную меняет остаток
+// @Summary Изменение остатка
+// @Description Приход (restock) или корректировка (adjustment) остатка товара или варианта на delta на складе warehouseID.
+// @Description Приход только положительный; уменьшить остаток можно корректировкой.
+// @Description Без warehouseID остаток меняется на складе по умолчанию - активном складе с наибольшим приоритетом.
+// @Description Каждое изменение записывается в журнал остатков вместе с автором. Остаток не может стать отрицательным.
+// @Tags Остатки
+// @Accept json
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param adjustment body models.StockAdjustment true "Изменение остатка"
+// @Success 201 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /products/{id}/stock [post]
+func (s *Server) AdjustStockHandler(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || productID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid product id", err)
+		return
+	}
+	var adjustment models.StockAdjustment
+	if err := ctx.ShouldBindJSON(&adjustment); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(adjustment); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid stock adjustment", err)
+		return
+	}
+	// Приход только увеличивает остаток, списание и пересчет оформляются как adjustment
+	if adjustment.Reason == models.StockReasonRestock && adjustment.Delta <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Restock delta must be positive", nil)
+		return
+	}
+	movement, err := s.Db.AdjustStock(auth.UserID(ctx), productID, adjustment)
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound), errors.Is(err, repository.ErrVariantNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Product not found", err)
+	case errors.Is(err, repository.ErrWarehouseNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Warehouse not found", err)
+	case errors.Is(err, repository.ErrVariantRequired):
+		responses.SendError(ctx, http.StatusBadRequest, "Variant is required", err)
+	case errors.Is(err, repository.ErrNegativeStock):
+		responses.SendError(ctx, http.StatusConflict, "Not enough stock", err)
+	case err != nil:
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to adjust stock", err)
+	default:
+		responses.SendSuccess(ctx, http.StatusCreated, "Stock adjusted", movement)
+	}
+}
+
+// GetStockMovementsHandler возвращает журнал остатков товара
+// @Summary Журнал остатков
+// @Description Возвращает движения остатков товара и его вариантов за период, старые первыми:
+// @Description приход, продажи, возвраты, корректировки, резервы под заказы и перемещения между складами.
+// @Tags Остатки
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param from query string false "Начало периода, RFC3339"
+// @Param to query string false "Конец периода, RFC3339"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /products/{id}/stock/movements [get]
+func (s *Server) GetStockMovementsHandler(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || productID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid product id", err)
+		return
+	}
+	from, err := queryTime(ctx, "from")
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid from", err)
+		return
+	}
+	to, err := queryTime(ctx, "to")
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid to", err)
+		return
+	}
+	movements, err := s.Db.GetStockMovements(productID, from, to)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get stock movements", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List of stock movements", movements)
+}
+
+// GetStockReportHandler восстанавливает остатки товара на момент времени
+// @Summary Остатки на момент времени
+// @Description Пересчитывает по журналу остаток, резерв и доступное количество товара и его вариантов на каждом складе на момент at.
+// @Description Без at возвращаются текущие остатки.
+// @Tags Остатки
+// @Produce json
+// @Param id path int true "ID продукта"
+// @Param at query string false "Момент времени, RFC3339"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /products/{id}/stock [get]
+func (s *Server) GetStockReportHandler(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || productID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid product id", err)
+		return
+	}
+	at, err := queryTime(ctx, "at")
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid at", err)
+		return
+	}
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	report, err := s.Db.GetStockReport(productID, at)
+	if errors.Is(err, repository.ErrProductNotFound) {
+		responses.SendError(ctx, http.StatusNotFound, "Product not found", err)
+		return
+	}
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get stock report", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Stock report", report)
+}
+
+// queryTime читает из query-параметра время в формате RFC3339. Пустой параметр дает нулевое время.
+func queryTime(ctx *gin.Context, name string) (time.Time, error) {
+	value := ctx.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+func TestStockHandlers(t *testing.T) {
+	ts := newTestServer(t)
+
+	token := ts.sign(2, models.RoleSeller)
+
+	at := time.Date(2024, 11, 4, 10, 0, 0, 0, time.UTC)
+	actorID, orderID, variantID := 2, 10, 3
+
+	type test struct {
+		name      string
+		method    string
+		path      string
+		body      string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name:   "Test StockHandlers; Case 1: restock",
+			method: http.MethodPost,
+			path:   "/products/1/stock",
+			body:   `{"delta":5,"reason":"restock","comment":"supplier delivery"}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().AdjustStock(2, 1, models.StockAdjustment{Delta: 5, Reason: models.StockReasonRestock, Comment: "supplier delivery"}).
+					Return(models.StockMovement{UID: 7, WarehouseID: 1, ProductID: 1, Delta: 5, Reason: models.StockReasonRestock, ActorID: &actorID, Comment: "supplier delivery", CreatedAt: at}, nil)
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body: `{"status":201,"message":"Stock adjusted","data":{"uid":7,"warehouseID":1,"productID":1,"delta":5,"reserved_delta":0,"reason":"restock",` +
+					`"actorID":2,"comment":"supplier delivery","created_at":"2024-11-04T10:00:00Z"}}`,
+			},
+		},
+		{
+			name:   "Test StockHandlers; Case 2: sale is not a manual reason",
+			method: http.MethodPost,
+			path:   "/products/1/stock",
+			body:   `{"delta":-1,"reason":"sale"}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid stock adjustment","error":"Key: 'StockAdjustment.Reason' Error:Field validation for 'Reason' failed on the 'oneof' tag"}`,
+			},
+		},
+		{
+			name:   "Test StockHandlers; Case 3: adjustment below zero",
+			method: http.MethodPost,
+			path:   "/products/1/stock",
+			body:   `{"delta":-50,"reason":"adjustment"}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().AdjustStock(2, 1, models.StockAdjustment{Delta: -50, Reason: models.StockReasonAdjustment}).
+					Return(models.StockMovement{}, repository.ErrNegativeStock)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Not enough stock","error":"stock cannot become negative"}`,
+			},
+		},
+		{
+			name:   "Test StockHandlers; Case 4: movements for a period",
+			method: http.MethodGet,
+			path:   "/products/1/stock/movements?from=2024-11-04T00:00:00Z&to=2024-11-05T00:00:00%2B03:00",
+			setupMock: func(m *mocks.MockRepository) {
+				from := time.Date(2024, 11, 4, 0, 0, 0, 0, time.UTC)
+				m.EXPECT().GetStockMovements(1, from, gomock.Any()).DoAndReturn(func(_ int, _, to time.Time) ([]models.StockMovement, error) {
+					assert.True(t, to.Equal(time.Date(2024, 11, 4, 21, 0, 0, 0, time.UTC)))
+					return []models.StockMovement{{
+						UID: 8, WarehouseID: 2, ProductID: 1, VariantID: &variantID, Delta: -2, ReservedDelta: -2,
+						Reason: models.StockReasonSale, OrderID: &orderID, CreatedAt: at,
+					}}, nil
+				})
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body: `{"status":200,"message":"List of stock movements","data":[{"uid":8,"warehouseID":2,"productID":1,"variantID":3,"delta":-2,` +
+					`"reserved_delta":-2,"reason":"sale","orderID":10,"created_at":"2024-11-04T10:00:00Z"}]}`,
+			},
+		},
+		{
+			name:   "Test StockHandlers; Case 5: invalid period",
+			method: http.MethodGet,
+			path:   "/products/1/stock/movements?from=yesterday",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Invalid from","error":"parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\""}`,
+			},
+		},
+		{
+			name:   "Test StockHandlers; Case 6: stock at a point in time",
+			method: http.MethodGet,
+			path:   "/products/1/stock?at=2024-11-04T10:00:00Z",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetStockReport(1, at).Return(models.StockReport{
+					ProductID: 1,
+					At:        at,
+					Levels: []models.StockLevel{
+						{WarehouseID: 1, VariantID: &variantID, Quantity: 6, Reserved: 3, Available: 3},
+						{WarehouseID: 2, VariantID: &variantID, Quantity: 4, Available: 4},
+					},
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body: `{"status":200,"message":"Stock report","data":{"productID":1,"at":"2024-11-04T10:00:00Z",` +
+					`"levels":[{"warehouseID":1,"variantID":3,"quantity":6,"reserved":3,"available":3},` +
+					`{"warehouseID":2,"variantID":3,"quantity":4,"reserved":0,"available":4}]}}`,
+			},
+		},
+		{
+			name:   "Test StockHandlers; Case 8: adjustment at a missing warehouse",
+			method: http.MethodPost,
+			path:   "/products/1/stock",
+			body:   `{"warehouseID":9,"delta":5,"reason":"restock"}`,
+			setupMock: func(m *mocks.MockRepository) {
+				warehouseID := 9
+				m.EXPECT().AdjustStock(2, 1, models.StockAdjustment{WarehouseID: &warehouseID, Delta: 5, Reason: models.StockReasonRestock}).
+					Return(models.StockMovement{}, repository.ErrWarehouseNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Warehouse not found","error":"warehouse not found"}`,
+			},
+		},
+		{
+			name:   "Test StockHandlers; Case 9: negative restock",
+			method: http.MethodPost,
+			path:   "/products/1/stock",
+			body:   `{"delta":-5,"reason":"restock"}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Restock delta must be positive"}`,
+			},
+		},
+		{
+			name:   "Test StockHandlers; Case 7: report for a missing product",
+			method: http.MethodGet,
+			path:   "/products/9/stock",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetStockReport(9, gomock.Any()).Return(models.StockReport{}, repository.ErrProductNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Product not found","error":"product not found"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := ts.server(t, tc.setupMock)
+			r := gin.New()
+			productGroup := r.Group("/products", srv.Authenticate())
+			productGroup.GET("/:id/stock", srv.GetStockReportHandler)
+			productGroup.POST("/:id/stock", srv.AdjustStockHandler)
+			productGroup.GET("/:id/stock/movements", srv.GetStockMovementsHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			req := resty.New().R().SetHeader("Authorization", "Bearer "+token)
+			if tc.body != "" {
+				req.SetHeader("Content-Type", "application/json").SetBody(tc.body)
+			}
+			resp, err := req.Execute(tc.method, httpSrv.URL+tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
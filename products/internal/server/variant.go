@@ -0,0 +1,160 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/repository"
+	"github.com/wileytor/go-market/products/internal/server/responses"
+)
+
+// GetProductVariantsHandler возвращает варианты товара
+// @Summary Варианты товара
+// @Tags Варианты
+// @Param id path int true "Product ID"
+// @Produce json
+// @Success 200 {object} responses.Success{data=[]models.ProductVariant}
+// @Failure 400 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /products/{id}/variants [get]
+func (s *Server) GetProductVariantsHandler(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+	variants, err := s.Db.GetProductVariants(productID)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to retrieve variants", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List of variants", variants)
+}
+
+// AddVariantHandler добавляет вариант товара
+// @Summary Добавление варианта
+// @Description Создает вариант товара со своим артикулом, атрибутами, ценой и остатком
+// @Tags Варианты
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variant body models.ProductVariant true "Variant data"
+// @Success 201 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Router /products/{id}/variants [post]
+func (s *Server) AddVariantHandler(ctx *gin.Context) {
+	variant, ok := s.bindVariant(ctx)
+	if !ok {
+		return
+	}
+	uid, err := s.Db.AddVariant(auth.UserID(ctx), variant)
+	if err != nil {
+		sendVariantError(ctx, err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusCreated, "Variant added", uid)
+}
+
+// UpdateVariantHandler обновляет вариант товара
+// @Summary Обновление варианта
+// @Tags Варианты
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantID path int true "Variant ID"
+// @Param variant body models.ProductVariant true "Variant data"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Router /products/{id}/variants/{variantID} [put]
+func (s *Server) UpdateVariantHandler(ctx *gin.Context) {
+	variantID, err := strconv.Atoi(ctx.Param("variantID"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid variant id", err)
+		return
+	}
+	variant, ok := s.bindVariant(ctx)
+	if !ok {
+		return
+	}
+	variant.UID = variantID
+	if err := s.Db.UpdateVariant(auth.UserID(ctx), variant); err != nil {
+		sendVariantError(ctx, err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Variant updated", variantID)
+}
+
+// DeleteVariantHandler удаляет вариант товара
+// @Summary Удаление варианта
+// @Description Вариант помечается удаленным: он пропадает из списка вариантов и его нельзя купить,
+// @Description но заказы и журнал остатков сохраняют ссылку на него
+// @Tags Варианты
+// @Param id path int true "Product ID"
+// @Param variantID path int true "Variant ID"
+// @Produce json
+// @Success 200 {object} responses.Success
+// @Failure 404 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /products/{id}/variants/{variantID} [delete]
+func (s *Server) DeleteVariantHandler(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid id", err)
+		return
+	}
+	variantID, err := strconv.Atoi(ctx.Param("variantID"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid variant id", err)
+		return
+	}
+	if err := s.Db.DeleteVariant(productID, variantID); err != nil {
+		sendVariantError(ctx, err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "Variant deleted", variantID)
+}
+
+// bindVariant читает вариант из тела запроса и id товара из пути
+func (s *Server) bindVariant(ctx *gin.Context) (models.ProductVariant, bool) {
+	var variant models.ProductVariant
+	productID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid id", err)
+		return variant, false
+	}
+	if err := ctx.ShouldBindJSON(&variant); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return variant, false
+	}
+	if err := s.Valid.Struct(variant); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid variant", err)
+		return variant, false
+	}
+	variant.ProductID = productID
+	return variant, true
+}
+
+func sendVariantError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrVariantNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Variant not found", err)
+	case errors.Is(err, repository.ErrProductNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Product not found", err)
+	case errors.Is(err, models.ErrCurrencyMismatch):
+		responses.SendError(ctx, http.StatusBadRequest, "Variant price must be in product currency", err)
+	case errors.Is(err, repository.ErrNegativeStock):
+		responses.SendError(ctx, http.StatusConflict, "Quantity is below reserved stock", err)
+	case errors.Is(err, repository.ErrSKUExists):
+		responses.SendError(ctx, http.StatusConflict, "Variant conflict", err)
+	default:
+		responses.SendError(ctx, http.StatusInternalServerError, "Variant operation failed", err)
+	}
+}
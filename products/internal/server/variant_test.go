@@ -0,0 +1,156 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-resty/resty/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+func TestVariantHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := Server{Valid: validator.New()}
+	r := gin.New()
+	r.GET("/products/:id/variants", srv.GetProductVariantsHandler)
+	r.POST("/products/:id/variants", srv.AddVariantHandler)
+	r.PUT("/products/:id/variants/:variantID", srv.UpdateVariantHandler)
+	r.DELETE("/products/:id/variants/:variantID", srv.DeleteVariantHandler)
+	httpSrv := httptest.NewServer(r)
+	defer httpSrv.Close()
+
+	price := models.Money{Amount: 1550, Currency: "RUB"}
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+	type test struct {
+		name      string
+		method    string
+		request   string
+		body      string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name:    "Test VariantHandlers; Case 1: list variants",
+			method:  http.MethodGet,
+			request: "/products/1/variants",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetProductVariants(1).Return([]models.ProductVariant{
+					{UID: 3, ProductID: 1, SKU: "TS-RED-M", Attributes: map[string]string{"size": "M", "color": "red"}, Price: &price, Quantity: 4},
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"List of variants","data":[{"uid":3,"product_id":1,"sku":"TS-RED-M","attributes":{"color":"red","size":"M"},"price":{"amount":"15.50","currency":"RUB"},"quantity":4}]}`,
+			},
+		},
+		{
+			name:    "Test VariantHandlers; Case 2: variant is added to product from path",
+			method:  http.MethodPost,
+			request: "/products/1/variants",
+			body:    `{"product_id":99,"sku":"TS-RED-L","attributes":{"size":"L"},"quantity":2}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().AddVariant(0, models.ProductVariant{ProductID: 1, SKU: "TS-RED-L", Attributes: map[string]string{"size": "L"}, Quantity: 2}).Return(4, nil)
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body:       `{"status":201,"message":"Variant added","data":4}`,
+			},
+		},
+		{
+			name:    "Test VariantHandlers; Case 3: negative stock",
+			method:  http.MethodPost,
+			request: "/products/1/variants",
+			body:    `{"sku":"TS-RED-L","quantity":-1}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid variant","error":"Key: 'ProductVariant.Quantity' Error:Field validation for 'Quantity' failed on the 'min' tag"}`,
+			},
+		},
+		{
+			name:    "Test VariantHandlers; Case 7: price in another currency",
+			method:  http.MethodPost,
+			request: "/products/1/variants",
+			body:    `{"sku":"TS-RED-XL","price":{"amount":"20.00","currency":"USD"},"quantity":1}`,
+			setupMock: func(m *mocks.MockRepository) {
+				usd := models.Money{Amount: 2000, Currency: "USD"}
+				m.EXPECT().AddVariant(0, models.ProductVariant{ProductID: 1, SKU: "TS-RED-XL", Price: &usd, Quantity: 1}).
+					Return(-1, fmt.Errorf("%w: variant price in USD, product price in RUB", models.ErrCurrencyMismatch))
+			},
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Variant price must be in product currency","error":"currency mismatch: variant price in USD, product price in RUB"}`,
+			},
+		},
+		{
+			name:    "Test VariantHandlers; Case 4: duplicate sku",
+			method:  http.MethodPut,
+			request: "/products/1/variants/3",
+			body:    `{"sku":"TS-RED-L","quantity":1}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().UpdateVariant(0, models.ProductVariant{UID: 3, ProductID: 1, SKU: "TS-RED-L", Quantity: 1}).Return(repository.ErrSKUExists)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Variant conflict","error":"variant with this sku already exists"}`,
+			},
+		},
+		{
+			name:    "Test VariantHandlers; Case 5: delete purchased variant",
+			method:  http.MethodDelete,
+			request: "/products/1/variants/3",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().DeleteVariant(1, 3).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Variant deleted","data":3}`,
+			},
+		},
+		{
+			name:    "Test VariantHandlers; Case 6: variant of another product",
+			method:  http.MethodDelete,
+			request: "/products/2/variants/3",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().DeleteVariant(2, 3).Return(repository.ErrVariantNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Variant not found","error":"variant not found"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mocks.NewMockRepository(ctrl)
+			if tc.setupMock != nil {
+				tc.setupMock(m)
+			}
+			srv.Db = m
+
+			req := resty.New().R()
+			if tc.body != "" {
+				req.SetHeader("Content-Type", "application/json").SetBody(tc.body)
+			}
+			resp, err := req.Execute(tc.method, httpSrv.URL+tc.request)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	"github.com/wileytor/go-market/products/internal/repository"
+	"github.com/wileytor/go-market/products/internal/server/responses"
+)
+
+// GetWarehousesHandler возвращает склады
+// @Summary Список складов
+// @Description Возвращает все склады, включая неактивные, в порядке приоритета
+// @Tags Склады
+// @Produce json
+// @Success 200 {object} responses.Success{data=[]models.Warehouse}
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /warehouses [get]
+func (s *Server) GetWarehousesHandler(ctx *gin.Context) {
+	warehouses, err := s.Db.GetWarehouses()
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get warehouses", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List of warehouses", warehouses)
+}
+
+// AddWarehouseHandler создает склад
+// @Summary Добавление склада
+// @Description Создает активный склад без остатков. Остатки появляются приходом или перемещением.
+// @Tags Склады
+// @Accept json
+// @Produce json
+// @Param warehouse body models.Warehouse true "Склад"
+// @Success 201 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /warehouses/add [post]
+func (s *Server) AddWarehouseHandler(ctx *gin.Context) {
+	var warehouse models.Warehouse
+	if err := ctx.ShouldBindJSON(&warehouse); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(warehouse); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid warehouse", err)
+		return
+	}
+	uid, err := s.Db.AddWarehouse(warehouse)
+	if errors.Is(err, repository.ErrWarehouseExists) {
+		responses.SendError(ctx, http.StatusConflict, "Warehouse conflict", err)
+		return
+	}
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to add warehouse", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusCreated, "Warehouse added", uid)
+}
+
+// UpdateWarehouseHandler изменяет склад
+// @Summary Изменение склада
+// @Description Перезаписывает название, регион, приоритет и активность склада.
+// @Description Неактивный склад сохраняет остатки и резервы, но новые заказы на нем не резервируются.
+// @Tags Склады
+// @Accept json
+// @Produce json
+// @Param id path int true "ID склада"
+// @Param warehouse body models.Warehouse true "Склад"
+// @Success 200 {object} responses.Success
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /warehouses/{id} [put]
+func (s *Server) UpdateWarehouseHandler(ctx *gin.Context) {
+	warehouseID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || warehouseID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid warehouse id", err)
+		return
+	}
+	var warehouse models.Warehouse
+	if err := ctx.ShouldBindJSON(&warehouse); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(warehouse); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid warehouse", err)
+		return
+	}
+	warehouse.UID = warehouseID
+	err = s.Db.UpdateWarehouse(warehouse)
+	switch {
+	case errors.Is(err, repository.ErrWarehouseNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Warehouse not found", err)
+	case errors.Is(err, repository.ErrWarehouseExists):
+		responses.SendError(ctx, http.StatusConflict, "Warehouse conflict", err)
+	case err != nil:
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to update warehouse", err)
+	default:
+		responses.SendSuccess(ctx, http.StatusOK, "Warehouse updated", warehouseID)
+	}
+}
+
+// TransferStockHandler перемещает остаток между складами
+// @Summary Перемещение остатка
+// @Description Перемещает quantity товара или варианта со склада fromWarehouseID на склад toWarehouseID.
+// @Description Перемещается только доступный остаток: зарезервированное под заказы остается на складе.
+// @Description Общий остаток не меняется; в журнал остатков пишутся два движения transfer.
+// @Tags Склады
+// @Accept json
+// @Produce json
+// @Param transfer body models.StockTransferRequest true "Перемещение"
+// @Success 201 {object} responses.Success{data=models.StockTransfer}
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 404 {object} responses.Error
+// @Failure 409 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /warehouses/transfers [post]
+func (s *Server) TransferStockHandler(ctx *gin.Context) {
+	var request models.StockTransferRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid request data", err)
+		return
+	}
+	if err := s.Valid.Struct(request); err != nil {
+		responses.SendError(ctx, http.StatusBadRequest, "Not a valid stock transfer", err)
+		return
+	}
+	transfer, err := s.Db.TransferStock(auth.UserID(ctx), request)
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound), errors.Is(err, repository.ErrVariantNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Product not found", err)
+	case errors.Is(err, repository.ErrWarehouseNotFound):
+		responses.SendError(ctx, http.StatusNotFound, "Warehouse not found", err)
+	case errors.Is(err, repository.ErrVariantRequired):
+		responses.SendError(ctx, http.StatusBadRequest, "Variant is required", err)
+	case errors.Is(err, repository.ErrInsufficientStock):
+		responses.SendError(ctx, http.StatusConflict, "Not enough stock", err)
+	case err != nil:
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to transfer stock", err)
+	default:
+		responses.SendSuccess(ctx, http.StatusCreated, "Stock transferred", transfer)
+	}
+}
+
+// GetStockTransfersHandler возвращает перемещения товара между складами
+// @Summary Перемещения товара
+// @Description Возвращает перемещения товара и его вариантов между складами, новые первыми
+// @Tags Склады
+// @Produce json
+// @Param productID query int true "ID продукта"
+// @Success 200 {object} responses.Success{data=[]models.StockTransfer}
+// @Failure 400 {object} responses.Error
+// @Failure 401 {object} responses.Error
+// @Failure 403 {object} responses.Error
+// @Failure 500 {object} responses.Error
+// @Router /warehouses/transfers [get]
+func (s *Server) GetStockTransfersHandler(ctx *gin.Context) {
+	productID, err := strconv.Atoi(ctx.Query("productID"))
+	if err != nil || productID <= 0 {
+		responses.SendError(ctx, http.StatusBadRequest, "Invalid product id", err)
+		return
+	}
+	transfers, err := s.Db.GetStockTransfers(productID)
+	if err != nil {
+		responses.SendError(ctx, http.StatusInternalServerError, "Failed to get stock transfers", err)
+		return
+	}
+	responses.SendSuccess(ctx, http.StatusOK, "List of stock transfers", transfers)
+}
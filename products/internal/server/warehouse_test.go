@@ -0,0 +1,215 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/wileytor/go-market/common/auth"
+	"github.com/wileytor/go-market/common/models"
+	mocks "github.com/wileytor/go-market/mocks_prod"
+	"github.com/wileytor/go-market/products/internal/repository"
+)
+
+func TestWarehouseHandlers(t *testing.T) {
+	ts := newTestServer(t)
+
+	at := time.Date(2024, 11, 4, 10, 0, 0, 0, time.UTC)
+	actorID := 2
+
+	type test struct {
+		name      string
+		token     string
+		method    string
+		path      string
+		body      string
+		setupMock func(m *mocks.MockRepository)
+		want      want
+	}
+	tests := []test{
+		{
+			name:   "Test WarehouseHandlers; Case 1: list warehouses",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodGet,
+			path:   "/warehouses/",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetWarehouses().Return([]models.Warehouse{
+					{UID: 1, Name: "main", Priority: 100, Active: true, CreatedAt: at},
+					{UID: 2, Name: "north", Region: "spb", Priority: 10, Active: false, CreatedAt: at},
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body: `{"status":200,"message":"List of warehouses","data":[` +
+					`{"uid":1,"name":"main","region":"","priority":100,"active":true,"created_at":"2024-11-04T10:00:00Z"},` +
+					`{"uid":2,"name":"north","region":"spb","priority":10,"active":false,"created_at":"2024-11-04T10:00:00Z"}]}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 2: admin adds a warehouse",
+			token:  ts.sign(1, models.RoleAdmin),
+			method: http.MethodPost,
+			path:   "/warehouses/add",
+			body:   `{"name":"north","region":"spb","priority":10}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().AddWarehouse(models.Warehouse{Name: "north", Region: "spb", Priority: 10}).Return(2, nil)
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body:       `{"status":201,"message":"Warehouse added","data":2}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 3: seller cannot add a warehouse",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPost,
+			path:   "/warehouses/add",
+			body:   `{"name":"north"}`,
+			want: want{
+				statusCode: http.StatusForbidden,
+				body:       `{"status":403,"message":"Access denied"}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 4: duplicate name",
+			token:  ts.sign(1, models.RoleAdmin),
+			method: http.MethodPut,
+			path:   "/warehouses/2",
+			body:   `{"name":"main","active":true}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().UpdateWarehouse(models.Warehouse{UID: 2, Name: "main", Active: true}).Return(repository.ErrWarehouseExists)
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Warehouse conflict","error":"warehouse with this name already exists"}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 5: warehouse is deactivated",
+			token:  ts.sign(1, models.RoleAdmin),
+			method: http.MethodPut,
+			path:   "/warehouses/2",
+			body:   `{"name":"north","region":"spb","priority":10,"active":false}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().UpdateWarehouse(models.Warehouse{UID: 2, Name: "north", Region: "spb", Priority: 10}).Return(nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"Warehouse updated","data":2}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 6: stock is transferred",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPost,
+			path:   "/warehouses/transfers",
+			body:   `{"fromWarehouseID":1,"toWarehouseID":2,"productID":5,"quantity":3,"comment":"rebalance"}`,
+			setupMock: func(m *mocks.MockRepository) {
+				request := models.StockTransferRequest{FromWarehouseID: 1, ToWarehouseID: 2, ProductID: 5, Quantity: 3, Comment: "rebalance"}
+				m.EXPECT().TransferStock(2, request).Return(models.StockTransfer{
+					UID: 4, FromWarehouseID: 1, ToWarehouseID: 2, ProductID: 5, Quantity: 3,
+					ActorID: &actorID, Comment: "rebalance", CreatedAt: at,
+				}, nil)
+			},
+			want: want{
+				statusCode: http.StatusCreated,
+				body: `{"status":201,"message":"Stock transferred","data":{"uid":4,"fromWarehouseID":1,"toWarehouseID":2,"productID":5,` +
+					`"quantity":3,"actorID":2,"comment":"rebalance","created_at":"2024-11-04T10:00:00Z"}}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 7: transfer to the same warehouse",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPost,
+			path:   "/warehouses/transfers",
+			body:   `{"fromWarehouseID":1,"toWarehouseID":1,"productID":5,"quantity":3}`,
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Not a valid stock transfer","error":"Key: 'StockTransferRequest.ToWarehouseID' Error:Field validation for 'ToWarehouseID' failed on the 'nefield' tag"}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 8: source has not enough free stock",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPost,
+			path:   "/warehouses/transfers",
+			body:   `{"fromWarehouseID":1,"toWarehouseID":2,"productID":5,"quantity":30}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().TransferStock(2, models.StockTransferRequest{FromWarehouseID: 1, ToWarehouseID: 2, ProductID: 5, Quantity: 30}).
+					Return(models.StockTransfer{}, fmt.Errorf("%w: 7 available at warehouse 1", repository.ErrInsufficientStock))
+			},
+			want: want{
+				statusCode: http.StatusConflict,
+				body:       `{"status":409,"message":"Not enough stock","error":"not enough stock: 7 available at warehouse 1"}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 9: transfer to a missing warehouse",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodPost,
+			path:   "/warehouses/transfers",
+			body:   `{"fromWarehouseID":1,"toWarehouseID":9,"productID":5,"quantity":1}`,
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().TransferStock(2, models.StockTransferRequest{FromWarehouseID: 1, ToWarehouseID: 9, ProductID: 5, Quantity: 1}).
+					Return(models.StockTransfer{}, repository.ErrWarehouseNotFound)
+			},
+			want: want{
+				statusCode: http.StatusNotFound,
+				body:       `{"status":404,"message":"Warehouse not found","error":"warehouse not found"}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 10: transfers of a product",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodGet,
+			path:   "/warehouses/transfers?productID=5",
+			setupMock: func(m *mocks.MockRepository) {
+				m.EXPECT().GetStockTransfers(5).Return([]models.StockTransfer{}, nil)
+			},
+			want: want{
+				statusCode: http.StatusOK,
+				body:       `{"status":200,"message":"List of stock transfers","data":[]}`,
+			},
+		},
+		{
+			name:   "Test WarehouseHandlers; Case 11: transfers without product",
+			token:  ts.sign(2, models.RoleSeller),
+			method: http.MethodGet,
+			path:   "/warehouses/transfers",
+			want: want{
+				statusCode: http.StatusBadRequest,
+				body:       `{"status":400,"message":"Invalid product id","error":"strconv.Atoi: parsing \"\": invalid syntax"}`,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := ts.server(t, tc.setupMock)
+			r := gin.New()
+			adminOnly := auth.RequireRole(models.RoleAdmin)
+			warehouseGroup := r.Group("/warehouses", srv.Authenticate(), auth.RequireRole(models.RoleSeller, models.RoleAdmin))
+			warehouseGroup.GET("/", srv.GetWarehousesHandler)
+			warehouseGroup.POST("/add", adminOnly, srv.AddWarehouseHandler)
+			warehouseGroup.PUT("/:id", adminOnly, srv.UpdateWarehouseHandler)
+			warehouseGroup.GET("/transfers", srv.GetStockTransfersHandler)
+			warehouseGroup.POST("/transfers", srv.TransferStockHandler)
+			httpSrv := httptest.NewServer(r)
+			defer httpSrv.Close()
+
+			req := resty.New().R().SetHeader("Authorization", "Bearer "+tc.token)
+			if tc.body != "" {
+				req.SetHeader("Content-Type", "application/json").SetBody(tc.body)
+			}
+			resp, err := req.Execute(tc.method, httpSrv.URL+tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want.statusCode, resp.StatusCode())
+			assert.JSONEq(t, tc.want.body, string(resp.Body()))
+		})
+	}
+}
@@ -0,0 +1,14 @@
+-- Пока status_code пустой, запрос с ключом еще выполняется
+CREATE TABLE IF NOT EXISTS idempotency_keys
+    (
+        user_id INT NOT NULL,
+        key TEXT NOT NULL,
+        request_hash TEXT NOT NULL,
+        status_code INT,
+        response BYTEA,
+        created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+        expires_at TIMESTAMP NOT NULL,
+        PRIMARY KEY (user_id, key)
+    );
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_idx ON idempotency_keys (expires_at);
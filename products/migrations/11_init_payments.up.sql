@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS payments
+    (
+        uid serial PRIMARY KEY,
+        order_id INT NOT NULL REFERENCES orders(uid) ON DELETE CASCADE,
+        provider TEXT NOT NULL,
+        provider_payment_id TEXT,
+        status TEXT NOT NULL DEFAULT 'pending'
+            CHECK (status IN ('pending', 'requires_action', 'authorized', 'captured', 'failed', 'refunded')),
+        amount BIGINT NOT NULL CHECK (amount >= 0),
+        currency CHAR(3) NOT NULL,
+        failure_reason TEXT NOT NULL DEFAULT '',
+        action_url TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+        updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS payments_order_idx ON payments (order_id);
+CREATE UNIQUE INDEX IF NOT EXISTS payments_provider_idx ON payments (provider, provider_payment_id);
+
+-- У заказа не больше одного незавершенного или успешного платежа; после отказа можно платить снова
+CREATE UNIQUE INDEX IF NOT EXISTS payments_active_idx ON payments (order_id)
+    WHERE status IN ('pending', 'requires_action', 'authorized', 'captured');
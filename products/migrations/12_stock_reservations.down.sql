@@ -0,0 +1,12 @@
+-- Зарезервированное снова списывается с остатков, как до появления резервов
+UPDATE products p SET quantity = GREATEST(p.quantity - r.quantity, 0)
+FROM (SELECT product_id, SUM(quantity) AS quantity FROM stock_reservations
+    WHERE variant_id IS NULL GROUP BY product_id) r
+WHERE p.uid = r.product_id;
+
+UPDATE product_variants v SET quantity = GREATEST(v.quantity - r.quantity, 0)
+FROM (SELECT variant_id, SUM(quantity) AS quantity FROM stock_reservations
+    WHERE variant_id IS NOT NULL GROUP BY variant_id) r
+WHERE v.uid = r.variant_id;
+
+DROP TABLE IF EXISTS stock_reservations;
@@ -0,0 +1,33 @@
+-- Резерв удерживает остаток под неоплаченный заказ до expires_at.
+-- Доступный остаток - это quantity товара или варианта минус все его резервы.
+CREATE TABLE IF NOT EXISTS stock_reservations
+    (
+        uid serial PRIMARY KEY,
+        order_id INT NOT NULL REFERENCES orders(uid) ON DELETE CASCADE,
+        product_id INT NOT NULL REFERENCES products(uid),
+        variant_id INT REFERENCES product_variants(uid),
+        quantity INT NOT NULL CHECK (quantity > 0),
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS stock_reservations_order_idx ON stock_reservations (order_id);
+CREATE INDEX IF NOT EXISTS stock_reservations_product_idx ON stock_reservations (product_id, variant_id);
+CREATE INDEX IF NOT EXISTS stock_reservations_expires_idx ON stock_reservations (expires_at);
+
+-- Остатки неоплаченных заказов раньше списывались сразу: возвращаем их и держим в резерве
+INSERT INTO stock_reservations (order_id, product_id, variant_id, quantity, expires_at)
+SELECT i.order_id, i.product_id, i.variant_id, i.quantity, NOW() + INTERVAL '15 minutes'
+FROM order_items i
+JOIN orders o ON o.uid = i.order_id
+WHERE o.status = 'pending';
+
+UPDATE products p SET quantity = p.quantity + r.quantity
+FROM (SELECT product_id, SUM(quantity) AS quantity FROM stock_reservations
+    WHERE variant_id IS NULL GROUP BY product_id) r
+WHERE p.uid = r.product_id;
+
+UPDATE product_variants v SET quantity = v.quantity + r.quantity
+FROM (SELECT variant_id, SUM(quantity) AS quantity FROM stock_reservations
+    WHERE variant_id IS NOT NULL GROUP BY variant_id) r
+WHERE v.uid = r.variant_id;
@@ -0,0 +1,27 @@
+-- Журнал движения остатков. Сумма delta по позиции равна ее quantity,
+-- сумма reserved_delta - количеству в резервах заказов.
+CREATE TABLE IF NOT EXISTS stock_movements
+    (
+        uid serial PRIMARY KEY,
+        product_id INT NOT NULL REFERENCES products(uid) ON DELETE CASCADE,
+        variant_id INT REFERENCES product_variants(uid) ON DELETE CASCADE,
+        delta INT NOT NULL DEFAULT 0,
+        reserved_delta INT NOT NULL DEFAULT 0,
+        reason TEXT NOT NULL CHECK (reason IN ('restock', 'sale', 'return', 'adjustment', 'reservation')),
+        actor_id INT,
+        order_id INT REFERENCES orders(uid) ON DELETE SET NULL,
+        comment TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS stock_movements_product_idx ON stock_movements (product_id, created_at);
+
+-- Начальные остатки: до появления журнала история изменений не сохранялась
+INSERT INTO stock_movements (product_id, delta, reason, comment)
+SELECT uid, quantity, 'adjustment', 'opening balance' FROM products WHERE quantity <> 0;
+
+INSERT INTO stock_movements (product_id, variant_id, delta, reason, comment)
+SELECT product_id, uid, quantity, 'adjustment', 'opening balance' FROM product_variants WHERE quantity <> 0;
+
+INSERT INTO stock_movements (product_id, variant_id, reserved_delta, reason, order_id, created_at)
+SELECT product_id, variant_id, quantity, 'reservation', order_id, created_at FROM stock_reservations;
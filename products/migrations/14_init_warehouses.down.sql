@@ -0,0 +1,12 @@
+-- Перемещения не меняют общий остаток, поэтому без складов их движения теряют смысл
+DELETE FROM stock_movements WHERE reason = 'transfer';
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_reason_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_reason_check
+    CHECK (reason IN ('restock', 'sale', 'return', 'adjustment', 'reservation'));
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS transfer_id;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS warehouse_id;
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS warehouse_id;
+
+DROP TABLE IF EXISTS stock_transfers;
+DROP TABLE IF EXISTS warehouse_stock;
+DROP TABLE IF EXISTS warehouses;
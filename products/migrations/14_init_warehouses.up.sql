@@ -0,0 +1,59 @@
+-- Склады. quantity товаров и вариантов остается общим остатком и равен сумме остатков по складам.
+CREATE TABLE IF NOT EXISTS warehouses
+    (
+        uid serial PRIMARY KEY,
+        name TEXT NOT NULL UNIQUE,
+        region TEXT NOT NULL DEFAULT '',
+        priority INT NOT NULL DEFAULT 0,
+        active BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+-- Все существующие остатки, резервы и движения относятся к основному складу
+INSERT INTO warehouses (name, priority) VALUES ('main', 100);
+
+CREATE TABLE IF NOT EXISTS warehouse_stock
+    (
+        uid serial PRIMARY KEY,
+        warehouse_id INT NOT NULL REFERENCES warehouses(uid),
+        product_id INT NOT NULL REFERENCES products(uid) ON DELETE CASCADE,
+        variant_id INT REFERENCES product_variants(uid) ON DELETE CASCADE,
+        quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0)
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS warehouse_stock_line_idx ON warehouse_stock (warehouse_id, product_id, COALESCE(variant_id, 0));
+CREATE INDEX IF NOT EXISTS warehouse_stock_product_idx ON warehouse_stock (product_id, variant_id);
+
+INSERT INTO warehouse_stock (warehouse_id, product_id, quantity)
+SELECT w.uid, p.uid, p.quantity FROM products p, warehouses w WHERE w.name = 'main' AND p.quantity > 0;
+
+INSERT INTO warehouse_stock (warehouse_id, product_id, variant_id, quantity)
+SELECT w.uid, v.product_id, v.uid, v.quantity FROM product_variants v, warehouses w WHERE w.name = 'main' AND v.quantity > 0;
+
+CREATE TABLE IF NOT EXISTS stock_transfers
+    (
+        uid serial PRIMARY KEY,
+        from_warehouse_id INT NOT NULL REFERENCES warehouses(uid),
+        to_warehouse_id INT NOT NULL REFERENCES warehouses(uid),
+        product_id INT NOT NULL REFERENCES products(uid) ON DELETE CASCADE,
+        variant_id INT REFERENCES product_variants(uid) ON DELETE CASCADE,
+        quantity INT NOT NULL CHECK (quantity > 0),
+        actor_id INT,
+        comment TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+        CHECK (from_warehouse_id <> to_warehouse_id)
+    );
+
+CREATE INDEX IF NOT EXISTS stock_transfers_product_idx ON stock_transfers (product_id, created_at);
+
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS warehouse_id INT REFERENCES warehouses(uid);
+UPDATE stock_reservations SET warehouse_id = (SELECT uid FROM warehouses WHERE name = 'main');
+ALTER TABLE stock_reservations ALTER COLUMN warehouse_id SET NOT NULL;
+
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS warehouse_id INT REFERENCES warehouses(uid);
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS transfer_id INT REFERENCES stock_transfers(uid) ON DELETE SET NULL;
+UPDATE stock_movements SET warehouse_id = (SELECT uid FROM warehouses WHERE name = 'main');
+ALTER TABLE stock_movements ALTER COLUMN warehouse_id SET NOT NULL;
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_reason_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_reason_check
+    CHECK (reason IN ('restock', 'sale', 'return', 'adjustment', 'reservation', 'transfer'));
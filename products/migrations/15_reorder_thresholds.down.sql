@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS restock_requests;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_quantity;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_threshold;
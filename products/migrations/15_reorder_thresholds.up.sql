@@ -0,0 +1,20 @@
+-- Порог дозаказа: когда заказ опускает доступный остаток позиции до reorder_threshold,
+-- поставщику создается заявка на reorder_quantity. NULL отключает дозаказ.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_threshold INT CHECK (reorder_threshold >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_quantity INT NOT NULL DEFAULT 0 CHECK (reorder_quantity >= 0);
+
+CREATE TABLE IF NOT EXISTS restock_requests
+    (
+        uid serial PRIMARY KEY,
+        product_id INT NOT NULL REFERENCES products(uid) ON DELETE CASCADE,
+        variant_id INT REFERENCES product_variants(uid) ON DELETE CASCADE,
+        quantity INT NOT NULL CHECK (quantity > 0),
+        status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'fulfilled')),
+        order_id INT REFERENCES orders(uid) ON DELETE SET NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+        fulfilled_at TIMESTAMP
+    );
+
+-- По позиции открыта не больше одной заявки
+CREATE UNIQUE INDEX IF NOT EXISTS restock_requests_open_idx ON restock_requests (product_id, COALESCE(variant_id, 0))
+    WHERE status = 'open';
@@ -0,0 +1,9 @@
+-- Журнал движения остатков не удаляется вместе с товаром или вариантом:
+-- товар с историей остатков остается мягко удаленным
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_product_id_fkey;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(uid) ON DELETE RESTRICT;
+
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_variant_id_fkey;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_variant_id_fkey
+    FOREIGN KEY (variant_id) REFERENCES product_variants(uid) ON DELETE RESTRICT;
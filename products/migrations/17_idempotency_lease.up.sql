@@ -0,0 +1,6 @@
+-- Ключ занят выполняющимся запросом до locked_until. Если запрос не сохранил ответ к этому сроку
+-- (упал процесс или не удалось записать ответ), ключ освобождается для повтора.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
+
+-- Незавершенные запросы, начатые до появления аренды, сразу можно повторить
+UPDATE idempotency_keys SET locked_until = NOW() WHERE status_code IS NULL;
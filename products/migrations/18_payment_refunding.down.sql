@@ -0,0 +1,9 @@
+UPDATE payments SET status = 'captured' WHERE status = 'refunding';
+
+DROP INDEX IF EXISTS payments_active_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS payments_active_idx ON payments (order_id)
+    WHERE status IN ('pending', 'requires_action', 'authorized', 'captured');
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'requires_action', 'authorized', 'captured', 'failed', 'refunded'));
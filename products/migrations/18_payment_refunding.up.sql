@@ -0,0 +1,8 @@
+-- refunding: возврат запрошен у провайдера, и повторный возврат того же платежа не начнется
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'requires_action', 'authorized', 'captured', 'refunding', 'failed', 'refunded'));
+
+DROP INDEX IF EXISTS payments_active_idx;
+CREATE UNIQUE INDEX IF NOT EXISTS payments_active_idx ON payments (order_id)
+    WHERE status IN ('pending', 'requires_action', 'authorized', 'captured', 'refunding');
@@ -0,0 +1 @@
+ALTER TABLE product_variants DROP COLUMN IF EXISTS deleted;
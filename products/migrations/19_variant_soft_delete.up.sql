@@ -0,0 +1,2 @@
+-- Вариант не удаляется, а помечается удаленным, как товар: на него ссылаются заказы и журнал остатков
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS deleted BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS outbox
+    (
+        id BIGSERIAL PRIMARY KEY,
+        event_type TEXT NOT NULL,
+        message JSONB NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+        sent_at TIMESTAMP,
+        attempts INT NOT NULL DEFAULT 0,
+        last_error TEXT
+    );
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (id) WHERE sent_at IS NULL;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS categories
+    (
+        uid serial PRIMARY KEY,
+        name TEXT NOT NULL,
+        parent_id INT REFERENCES categories(uid)
+    );
+
+-- Имена уникальны среди соседей одного родителя
+CREATE UNIQUE INDEX IF NOT EXISTS categories_parent_name_idx ON categories (COALESCE(parent_id, 0), lower(name));
+CREATE INDEX IF NOT EXISTS categories_parent_idx ON categories (parent_id);
+
+CREATE TABLE IF NOT EXISTS product_categories
+    (
+        product_id INT NOT NULL REFERENCES products(uid) ON DELETE CASCADE,
+        category_id INT NOT NULL REFERENCES categories(uid) ON DELETE CASCADE,
+        PRIMARY KEY (product_id, category_id)
+    );
+
+CREATE INDEX IF NOT EXISTS product_categories_category_idx ON product_categories (category_id);
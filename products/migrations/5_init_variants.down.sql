@@ -0,0 +1,2 @@
+ALTER TABLE purchases DROP COLUMN IF EXISTS variant_id;
+DROP TABLE IF EXISTS product_variants;
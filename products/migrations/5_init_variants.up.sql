@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS product_variants
+    (
+        uid serial PRIMARY KEY,
+        product_id INT NOT NULL REFERENCES products(uid) ON DELETE CASCADE,
+        sku TEXT NOT NULL UNIQUE,
+        attributes JSONB NOT NULL DEFAULT '{}',
+        price double precision,
+        quantity INT NOT NULL DEFAULT 0 CHECK (quantity >= 0)
+    );
+
+CREATE INDEX IF NOT EXISTS product_variants_product_idx ON product_variants (product_id);
+
+-- Покупки товаров без вариантов остаются с пустым variant_id
+ALTER TABLE purchases ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(uid);
@@ -0,0 +1,9 @@
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS price double precision;
+UPDATE product_variants SET price = price_amount / 100.0 WHERE price_amount IS NOT NULL;
+ALTER TABLE product_variants DROP COLUMN price_amount;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price double precision;
+UPDATE products SET price = price_amount / 100.0;
+ALTER TABLE products ALTER COLUMN price SET NOT NULL;
+ALTER TABLE products DROP COLUMN price_amount;
+ALTER TABLE products DROP COLUMN currency;
@@ -0,0 +1,13 @@
+-- Цены хранятся в минимальных единицах валюты; существующие цены считаются рублевыми
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price_amount BIGINT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB';
+UPDATE products SET price_amount = ROUND(price::numeric * 100);
+ALTER TABLE products ALTER COLUMN price_amount SET NOT NULL;
+ALTER TABLE products ADD CONSTRAINT products_price_amount_check CHECK (price_amount >= 0);
+ALTER TABLE products ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE products DROP COLUMN price;
+
+-- Цена варианта указывается в валюте товара
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS price_amount BIGINT CHECK (price_amount >= 0);
+UPDATE product_variants SET price_amount = ROUND(price::numeric * 100) WHERE price IS NOT NULL;
+ALTER TABLE product_variants DROP COLUMN price;
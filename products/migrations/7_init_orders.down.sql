@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS purchases
+    (
+        uid serial PRIMARY KEY,
+        user_id INT NOT NULL,
+        product_id INT NOT NULL REFERENCES products(uid),
+        quantity INT NOT NULL,
+        purchase_date TIMESTAMP NOT NULL DEFAULT NOW(),
+        variant_id INT REFERENCES product_variants(uid)
+    );
+
+INSERT INTO purchases (user_id, product_id, quantity, purchase_date, variant_id)
+SELECT o.user_id, i.product_id, i.quantity, o.created_at, i.variant_id
+FROM order_items i
+JOIN orders o ON o.uid = i.order_id
+ORDER BY i.uid;
+
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS orders
+    (
+        uid serial PRIMARY KEY,
+        user_id INT NOT NULL,
+        total_amount BIGINT NOT NULL CHECK (total_amount >= 0),
+        currency CHAR(3) NOT NULL,
+        created_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS orders_user_idx ON orders (user_id, created_at DESC);
+
+-- Цена фиксируется на момент оформления заказа
+CREATE TABLE IF NOT EXISTS order_items
+    (
+        uid serial PRIMARY KEY,
+        order_id INT NOT NULL REFERENCES orders(uid) ON DELETE CASCADE,
+        product_id INT NOT NULL REFERENCES products(uid),
+        variant_id INT REFERENCES product_variants(uid),
+        quantity INT NOT NULL CHECK (quantity > 0),
+        price_amount BIGINT NOT NULL CHECK (price_amount >= 0),
+        currency CHAR(3) NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS order_items_order_idx ON order_items (order_id);
+CREATE INDEX IF NOT EXISTS order_items_product_idx ON order_items (product_id);
+
+-- Каждая покупка становится заказом из одной строки. Цена покупки не сохранялась,
+-- поэтому берется текущая цена товара или варианта.
+INSERT INTO orders (uid, user_id, total_amount, currency, created_at)
+SELECT pu.uid, pu.user_id, pu.quantity * COALESCE(v.price_amount, p.price_amount), p.currency, pu.purchase_date
+FROM purchases pu
+JOIN products p ON p.uid = pu.product_id
+LEFT JOIN product_variants v ON v.uid = pu.variant_id;
+
+INSERT INTO order_items (order_id, product_id, variant_id, quantity, price_amount, currency)
+SELECT pu.uid, pu.product_id, pu.variant_id, pu.quantity, COALESCE(v.price_amount, p.price_amount), p.currency
+FROM purchases pu
+JOIN products p ON p.uid = pu.product_id
+LEFT JOIN product_variants v ON v.uid = pu.variant_id;
+
+SELECT setval(pg_get_serial_sequence('orders', 'uid'), COALESCE(MAX(uid), 0) + 1, false) FROM orders;
+
+DROP TABLE IF EXISTS purchases;
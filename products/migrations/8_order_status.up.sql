@@ -0,0 +1,21 @@
+-- Оформленные до появления статусов покупки считаются оплаченными
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'paid'
+    CHECK (status IN ('pending', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded'));
+ALTER TABLE orders ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+UPDATE orders SET updated_at = created_at;
+
+-- История переходов заказа между статусами; у первой записи нет предыдущего статуса
+CREATE TABLE IF NOT EXISTS order_status_history
+    (
+        uid serial PRIMARY KEY,
+        order_id INT NOT NULL REFERENCES orders(uid) ON DELETE CASCADE,
+        from_status TEXT,
+        to_status TEXT NOT NULL,
+        changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+CREATE INDEX IF NOT EXISTS order_status_history_order_idx ON order_status_history (order_id);
+
+INSERT INTO order_status_history (order_id, to_status, changed_at)
+SELECT uid, status, created_at FROM orders;
@@ -0,0 +1,21 @@
+-- Корзина живет до expires_at; срок продлевается при каждом изменении
+CREATE TABLE IF NOT EXISTS carts
+    (
+        user_id INT PRIMARY KEY,
+        expires_at TIMESTAMP NOT NULL
+    );
+
+CREATE INDEX IF NOT EXISTS carts_expires_idx ON carts (expires_at);
+
+CREATE TABLE IF NOT EXISTS cart_items
+    (
+        uid serial PRIMARY KEY,
+        user_id INT NOT NULL REFERENCES carts(user_id) ON DELETE CASCADE,
+        product_id INT NOT NULL REFERENCES products(uid) ON DELETE CASCADE,
+        variant_id INT REFERENCES product_variants(uid) ON DELETE CASCADE,
+        quantity INT NOT NULL CHECK (quantity > 0),
+        added_at TIMESTAMP NOT NULL DEFAULT NOW()
+    );
+
+-- Один товар или вариант занимает в корзине одну строку
+CREATE UNIQUE INDEX IF NOT EXISTS cart_items_line_idx ON cart_items (user_id, product_id, COALESCE(variant_id, 0));